@@ -23,11 +23,6 @@ import (
 )
 
 func main() {
-	botToken := os.Getenv("BOT_TOKEN")
-	if botToken == "" {
-		log.Fatal("BOT_TOKEN environment variable is required")
-	}
-
 	adminIDsStr := os.Getenv("ADMIN_IDS")
 	forumChatIDStr := os.Getenv("FORUM_CHAT_ID")
 	topicIDStr := os.Getenv("TOPIC_ID")
@@ -55,6 +50,52 @@ func main() {
 	replyRepo := db.NewReplyRepository(dbQueue)
 	adminConfigRepo := db.NewAdminConfigRepository(dbQueue)
 	adminStateRepo := db.NewAdminStateRepository(dbQueue)
+	if ttlStr := os.Getenv("AUTH_CACHE_TTL"); ttlStr != "" {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil {
+			log.Fatalf("Invalid AUTH_CACHE_TTL: %q", ttlStr)
+		}
+		adminConfigRepo.SetCacheTTL(ttl)
+	}
+	userRepo := db.NewUserRepository(dbQueue)
+	adminPINRepo := db.NewAdminPINRepository(dbQueue)
+	replyTemplateRepo := db.NewReplyTemplateRepository(dbQueue)
+	commentRepo := db.NewCommentRepository(dbQueue)
+	ticketRepo := db.NewTicketRepository(dbQueue)
+	sandboxMessageRepo := db.NewSandboxMessageRepository(dbQueue)
+	typeVersionRepo := db.NewPostTypeVersionRepository(dbQueue)
+	uploadedImageRepo := db.NewUploadedImageRepository(dbQueue)
+
+	// CONFIG_FILE describes types, admins and destinations; it is applied
+	// once the bot is connected. With CONFIG_DRY_RUN the planned changes are
+	// printed and the bot exits before applying them; the schema above has
+	// already been created or migrated, since the plan reads current data.
+	var contentConfig *services.ContentConfig
+	var configMode string
+	if configPath := os.Getenv("CONFIG_FILE"); configPath != "" {
+		contentConfig, err = services.LoadContentConfig(configPath)
+		if err != nil {
+			log.Fatalf("Failed to load %s: %v", configPath, err)
+		}
+		configMode, err = services.ParseReconcileMode(os.Getenv("CONFIG_MODE"))
+		if err != nil {
+			log.Fatalf("Invalid CONFIG_MODE: %v", err)
+		}
+		if os.Getenv("CONFIG_DRY_RUN") == "true" {
+			reconciler := services.NewConfigReconciler(services.NewTypeBundleManager(nil, postTypeRepo), adminConfigRepo, postTypeRepo, typeVersionRepo, uploadedImageRepo)
+			plan, err := reconciler.Plan(contentConfig, configMode)
+			if err != nil {
+				log.Fatalf("Failed to plan %s: %v", configPath, err)
+			}
+			logConfigPlan(plan, "planned")
+			return
+		}
+	}
+
+	botToken := os.Getenv("BOT_TOKEN")
+	if botToken == "" {
+		log.Fatal("BOT_TOKEN environment variable is required")
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
@@ -141,11 +182,78 @@ func main() {
 		log.Fatalf("Failed to connect to Telegram API after %d attempts", maxAttempts)
 	}
 
+	typeBundleManager := services.NewTypeBundleManager(b, postTypeRepo)
+	if contentConfig != nil {
+		reconciler := services.NewConfigReconciler(typeBundleManager, adminConfigRepo, postTypeRepo, typeVersionRepo, uploadedImageRepo)
+		plan, err := reconciler.Plan(contentConfig, configMode)
+		if err == nil {
+			err = reconciler.Apply(ctx, plan)
+		}
+		if err != nil {
+			log.Fatalf("Failed to apply content config: %v", err)
+		}
+		logConfigPlan(plan, "applied")
+	}
+
 	postManager := services.NewPostManager(publishedPostRepo, postTypeRepo, adminConfigRepo)
 	postTypeManager := services.NewPostTypeManager(postTypeRepo)
 	settingsManager := services.NewSettingsManager(adminConfigRepo)
 	backupManager := services.NewBackupManager(b, dbPath, dbQueue)
 	adminAuthMiddleware := services.NewAdminAuthMiddleware(adminConfigRepo)
+	userManager := services.NewUserManager(b, userRepo, adminConfigRepo)
+	go userManager.RunPruning(ctx, 24*time.Hour)
+	commentManager := services.NewCommentManager(commentRepo, publishedPostRepo, replyRepo, adminConfigRepo)
+
+	confirmIdleTimeout := 15 * time.Minute
+	if idleStr := os.Getenv("CONFIRM_IDLE_TIMEOUT"); idleStr != "" {
+		confirmIdleTimeout, err = time.ParseDuration(idleStr)
+		if err != nil || confirmIdleTimeout <= 0 {
+			log.Fatalf("Invalid CONFIRM_IDLE_TIMEOUT: %q", idleStr)
+		}
+	}
+	requireSecondAdmin := os.Getenv("CONFIRM_SECOND_ADMIN") == "true"
+	confirmationGuard := services.NewConfirmationGuard(adminPINRepo, confirmIdleTimeout, requireSecondAdmin)
+
+	var supportInbox *services.SupportInbox
+	if os.Getenv("SUPPORT_INBOX") == "true" {
+		var supportChatID int64
+		if chatIDStr := os.Getenv("SUPPORT_CHAT_ID"); chatIDStr != "" {
+			supportChatID, err = strconv.ParseInt(chatIDStr, 10, 64)
+			if err != nil {
+				log.Fatalf("Invalid SUPPORT_CHAT_ID: %q", chatIDStr)
+			}
+		}
+		supportInbox = services.NewSupportInbox(ticketRepo, supportChatID)
+		log.Printf("Support inbox enabled, chat: %d", supportChatID)
+	}
+
+	if syncIntervalStr := os.Getenv("ADMIN_SYNC_INTERVAL"); syncIntervalStr != "" {
+		syncInterval, err := time.ParseDuration(syncIntervalStr)
+		if err != nil || syncInterval <= 0 {
+			log.Fatalf("Invalid ADMIN_SYNC_INTERVAL: %q", syncIntervalStr)
+		}
+		allowedRights, err := services.ParseAdminRights(os.Getenv("ADMIN_SYNC_RIGHTS"))
+		if err != nil {
+			log.Fatalf("Invalid ADMIN_SYNC_RIGHTS: %v", err)
+		}
+		ownerIDsStr := os.Getenv("OWNER_IDS")
+		if ownerIDsStr == "" {
+			ownerIDsStr = adminIDsStr
+		}
+		ownerIDs := parseIDList(ownerIDsStr)
+
+		adminSyncer := services.NewAdminSyncer(b, adminConfigRepo, userManager, ownerIDs, allowedRights)
+		go adminSyncer.Run(ctx, syncInterval)
+		log.Printf("Admin sync enabled: every %v, rights %v, owners %v", syncInterval, allowedRights, ownerIDs)
+	}
+
+	statsLocation := time.UTC
+	if tzName := os.Getenv("STATS_TIMEZONE"); tzName != "" {
+		statsLocation, err = time.LoadLocation(tzName)
+		if err != nil {
+			log.Fatalf("Invalid STATS_TIMEZONE: %q", tzName)
+		}
+	}
 
 	forumAdminHandler := handlers.NewForumAdminHandler(
 		b,
@@ -159,12 +267,32 @@ func main() {
 		postTypeManager,
 		settingsManager,
 		backupManager,
+		userManager,
+		confirmationGuard,
+		replyTemplateRepo,
+		commentRepo,
+		commentManager,
+		ticketRepo,
+		supportInbox,
+		sandboxMessageRepo,
+		services.NewChatResolver(b, time.Hour),
+		typeBundleManager,
+		db.NewAdminSignatureRepository(dbQueue),
+		typeVersionRepo,
+		db.NewPostEventRepository(dbQueue),
+		statsLocation,
 	)
 
 	b.RegisterHandlerMatchFunc(func(update *tgmodels.Update) bool {
 		return true
 	}, func(ctx context.Context, b *bot.Bot, update *tgmodels.Update) {
 		if update.Message != nil {
+			if forumAdminHandler.HandleComment(ctx, update.Message) {
+				return
+			}
+			if forumAdminHandler.HandleSupportMessage(ctx, update.Message) {
+				return
+			}
 			if forumAdminHandler.HandleCommand(ctx, update.Message) {
 				return
 			}
@@ -173,7 +301,7 @@ func main() {
 		if update.CallbackQuery != nil {
 			forumAdminHandler.HandleCallback(ctx, update.CallbackQuery)
 		}
-	}, logMiddleware)
+	}, logMiddleware(forumAdminHandler), trackUsersMiddleware(userManager))
 
 	log.Printf("Bot started. DB: %s", dbPath)
 	if botUser != nil {
@@ -183,14 +311,57 @@ func main() {
 	b.Start(ctx)
 }
 
-func logMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
-	return func(ctx context.Context, b *bot.Bot, update *tgmodels.Update) {
-		if update.Message != nil {
-			log.Printf("[MSG] from=%d text=%q", update.Message.From.ID, update.Message.Text)
+func parseIDList(s string) []int64 {
+	ids := []int64{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
 		}
-		if update.CallbackQuery != nil {
-			log.Printf("[CALLBACK] from=%d data=%q", update.CallbackQuery.From.ID, update.CallbackQuery.Data)
+		if id, err := strconv.ParseInt(part, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func logConfigPlan(plan *services.ConfigPlan, verb string) {
+	if len(plan.Changes) == 0 {
+		log.Printf("Content config (%s): no changes", plan.Mode)
+		return
+	}
+	log.Printf("Content config (%s): %s %d changes", plan.Mode, verb, len(plan.Changes))
+	for _, change := range plan.Changes {
+		log.Printf("  %s", change)
+	}
+}
+
+// logMiddleware logs incoming messages and callbacks. Text typed in reply to
+// a PIN prompt is not logged.
+func logMiddleware(forumAdminHandler *handlers.ForumAdminHandler) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *tgmodels.Update) {
+			if msg := update.Message; msg != nil && msg.From != nil {
+				if msg.Text != "" && forumAdminHandler.ExpectsPIN(msg.From.ID) {
+					log.Printf("[MSG] from=%d text=<redacted>", msg.From.ID)
+				} else {
+					log.Printf("[MSG] from=%d text=%q", msg.From.ID, msg.Text)
+				}
+			}
+			if update.CallbackQuery != nil {
+				log.Printf("[CALLBACK] from=%d data=%q", update.CallbackQuery.From.ID, update.CallbackQuery.Data)
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// trackUsersMiddleware keeps stored user profiles in sync with incoming updates.
+func trackUsersMiddleware(userManager *services.UserManager) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *tgmodels.Update) {
+			userManager.TrackUpdate(update)
+			next(ctx, b, update)
 		}
-		next(ctx, b, update)
 	}
 }
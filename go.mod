@@ -5,6 +5,7 @@ go 1.26
 require (
 	github.com/go-telegram/bot v1.18.0
 	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.45.0
 	pgregory.net/rapid v1.2.0
 )
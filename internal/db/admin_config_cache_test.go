@@ -0,0 +1,132 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+func setupAdminConfigRepo(tb testing.TB) (*AdminConfigRepository, *sql.DB) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		tb.Fatalf("Failed to open db: %v", err)
+	}
+	tb.Cleanup(func() { testDB.Close() })
+
+	if err := InitSchema(testDB); err != nil {
+		tb.Fatalf("Failed to init schema: %v", err)
+	}
+
+	return NewAdminConfigRepository(NewDBQueueForTest(testDB)), testDB
+}
+
+func TestAdminConfigCacheInvalidatedOnWrites(t *testing.T) {
+	repo, _ := setupAdminConfigRepo(t)
+
+	if isAdmin, _ := repo.IsAdmin(1); isAdmin {
+		t.Fatal("Expected empty admin list")
+	}
+
+	if err := repo.AddAdmin(1); err != nil {
+		t.Fatal(err)
+	}
+	if isAdmin, _ := repo.IsAdmin(1); !isAdmin {
+		t.Error("Expected AddAdmin to be visible immediately")
+	}
+
+	if err := repo.SetForumConfig(-100, 5); err != nil {
+		t.Fatal(err)
+	}
+	config, _ := repo.Get()
+	if config.ForumChatID != -100 || config.TopicID != 5 {
+		t.Errorf("Expected SetForumConfig to be visible immediately, got %+v", config)
+	}
+
+	if err := repo.RemoveAdmin(1); err != nil {
+		t.Fatal(err)
+	}
+	if isAdmin, _ := repo.IsAdmin(1); isAdmin {
+		t.Error("Expected RemoveAdmin to be visible immediately")
+	}
+}
+
+func TestAdminConfigCacheReturnsCopies(t *testing.T) {
+	repo, _ := setupAdminConfigRepo(t)
+	if err := repo.AddAdmin(1); err != nil {
+		t.Fatal(err)
+	}
+
+	config, _ := repo.Get()
+	config.AdminIDs[0] = 2
+
+	if isAdmin, _ := repo.IsAdmin(1); !isAdmin {
+		t.Error("Mutating a returned config must not change the cache")
+	}
+}
+
+func TestAdminConfigCacheExternalChanges(t *testing.T) {
+	repo, testDB := setupAdminConfigRepo(t)
+	if err := repo.AddAdmin(1); err != nil {
+		t.Fatal(err)
+	}
+	repo.IsAdmin(1)
+
+	if _, err := testDB.Exec(`UPDATE admin_config SET value = '2' WHERE key = 'admin_ids'`); err != nil {
+		t.Fatal(err)
+	}
+	if isAdmin, _ := repo.IsAdmin(2); isAdmin {
+		t.Error("Expected external change to be hidden by the cache")
+	}
+
+	repo.Invalidate()
+	if isAdmin, _ := repo.IsAdmin(2); !isAdmin {
+		t.Error("Expected Invalidate to reload the config")
+	}
+
+	repo.SetCacheTTL(time.Millisecond)
+	if _, err := testDB.Exec(`UPDATE admin_config SET value = '3' WHERE key = 'admin_ids'`); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if isAdmin, _ := repo.IsAdmin(3); !isAdmin {
+		t.Error("Expected expired cache to be reloaded")
+	}
+}
+
+func BenchmarkAdminConfigIsAdminCached(b *testing.B) {
+	repo, _ := setupAdminConfigRepo(b)
+	if err := repo.Save(adminConfigForBenchmark()); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		repo.IsAdmin(10)
+	}
+}
+
+func BenchmarkAdminConfigIsAdminUncached(b *testing.B) {
+	repo, _ := setupAdminConfigRepo(b)
+	if err := repo.Save(adminConfigForBenchmark()); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		repo.Invalidate()
+		repo.IsAdmin(10)
+	}
+}
+
+func adminConfigForBenchmark() *models.AdminConfig {
+	config := &models.AdminConfig{ForumChatID: -1001234567890, TopicID: 42}
+	for id := int64(1); id <= 20; id++ {
+		config.AdminIDs = append(config.AdminIDs, id)
+	}
+	return config
+}
@@ -4,19 +4,94 @@ import (
 	"database/sql"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ad/go-telegram-admin/internal/models"
 )
 
+// AdminConfigRepository caches the config in memory because it is read on
+// every update for authorization. Writes through the repository invalidate
+// the cache; SetCacheTTL additionally limits how long a cached copy is
+// trusted when the database may be changed externally.
 type AdminConfigRepository struct {
 	queue *DBQueue
+
+	mu         sync.RWMutex
+	cached     *models.AdminConfig
+	adminSet   map[int64]struct{}
+	loadedAt   time.Time
+	generation uint64
+	ttl        time.Duration
 }
 
 func NewAdminConfigRepository(queue *DBQueue) *AdminConfigRepository {
 	return &AdminConfigRepository{queue: queue}
 }
 
+// SetCacheTTL sets how long the cached config is used before it is reloaded.
+// Zero means the cache is only refreshed after writes or Invalidate.
+func (r *AdminConfigRepository) SetCacheTTL(ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ttl = ttl
+}
+
+// Invalidate drops the cached config so the next read goes to the database.
+func (r *AdminConfigRepository) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cached = nil
+	r.adminSet = nil
+	r.generation++
+}
+
 func (r *AdminConfigRepository) Get() (*models.AdminConfig, error) {
+	config, _, err := r.getCached()
+	if err != nil {
+		return nil, err
+	}
+	return copyAdminConfig(config), nil
+}
+
+func (r *AdminConfigRepository) getCached() (*models.AdminConfig, map[int64]struct{}, error) {
+	r.mu.RLock()
+	if r.cached != nil && (r.ttl <= 0 || time.Since(r.loadedAt) < r.ttl) {
+		config, adminSet := r.cached, r.adminSet
+		r.mu.RUnlock()
+		return config, adminSet, nil
+	}
+	generation := r.generation
+	r.mu.RUnlock()
+
+	config, err := r.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	adminSet := make(map[int64]struct{}, len(config.AdminIDs))
+	for _, id := range config.AdminIDs {
+		adminSet[id] = struct{}{}
+	}
+
+	r.mu.Lock()
+	// A write that happened while loading makes this snapshot stale.
+	if r.generation == generation {
+		r.cached = config
+		r.adminSet = adminSet
+		r.loadedAt = time.Now()
+	}
+	r.mu.Unlock()
+
+	return config, adminSet, nil
+}
+
+func copyAdminConfig(config *models.AdminConfig) *models.AdminConfig {
+	c := *config
+	c.AdminIDs = append([]int64{}, config.AdminIDs...)
+	return &c
+}
+
+func (r *AdminConfigRepository) load() (*models.AdminConfig, error) {
 	db := r.queue.DB()
 	config := &models.AdminConfig{
 		AdminIDs:    []int64{},
@@ -54,10 +129,33 @@ func (r *AdminConfigRepository) Get() (*models.AdminConfig, error) {
 		}
 	}
 
+	var sandboxChatIDStr string
+	err = db.QueryRow(`SELECT value FROM admin_config WHERE key = ?`, "sandbox_chat_id").Scan(&sandboxChatIDStr)
+	if err == nil && sandboxChatIDStr != "" {
+		if id, err := strconv.ParseInt(sandboxChatIDStr, 10, 64); err == nil {
+			config.SandboxChatID = id
+		}
+	}
+
+	var sandboxTopicIDStr string
+	err = db.QueryRow(`SELECT value FROM admin_config WHERE key = ?`, "sandbox_topic_id").Scan(&sandboxTopicIDStr)
+	if err == nil && sandboxTopicIDStr != "" {
+		if id, err := strconv.ParseInt(sandboxTopicIDStr, 10, 64); err == nil {
+			config.SandboxTopicID = id
+		}
+	}
+
+	var typeTwoColumnsStr string
+	err = db.QueryRow(`SELECT value FROM admin_config WHERE key = ?`, "type_two_columns").Scan(&typeTwoColumnsStr)
+	if err == nil {
+		config.TypeTwoColumns = typeTwoColumnsStr == "true"
+	}
+
 	return config, nil
 }
 
 func (r *AdminConfigRepository) Save(config *models.AdminConfig) error {
+	defer r.Invalidate()
 	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
 		adminIDsStrs := make([]string, len(config.AdminIDs))
 		for i, id := range config.AdminIDs {
@@ -82,6 +180,27 @@ func (r *AdminConfigRepository) Save(config *models.AdminConfig) error {
 		_, err = db.Exec(`
 			INSERT OR REPLACE INTO admin_config (key, value) VALUES (?, ?)
 		`, "topic_id", strconv.FormatInt(config.TopicID, 10))
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = db.Exec(`
+			INSERT OR REPLACE INTO admin_config (key, value) VALUES (?, ?)
+		`, "sandbox_chat_id", strconv.FormatInt(config.SandboxChatID, 10))
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = db.Exec(`
+			INSERT OR REPLACE INTO admin_config (key, value) VALUES (?, ?)
+		`, "sandbox_topic_id", strconv.FormatInt(config.SandboxTopicID, 10))
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = db.Exec(`
+			INSERT OR REPLACE INTO admin_config (key, value) VALUES (?, ?)
+		`, "type_two_columns", strconv.FormatBool(config.TypeTwoColumns))
 		return nil, err
 	})
 	return err
@@ -121,17 +240,13 @@ func (r *AdminConfigRepository) RemoveAdmin(adminID int64) error {
 }
 
 func (r *AdminConfigRepository) IsAdmin(userID int64) (bool, error) {
-	config, err := r.Get()
+	_, adminSet, err := r.getCached()
 	if err != nil {
 		return false, err
 	}
 
-	for _, id := range config.AdminIDs {
-		if id == userID {
-			return true, nil
-		}
-	}
-	return false, nil
+	_, ok := adminSet[userID]
+	return ok, nil
 }
 
 func (r *AdminConfigRepository) SetForumConfig(chatID, topicID int64) error {
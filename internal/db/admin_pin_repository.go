@@ -0,0 +1,45 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type AdminPINRepository struct {
+	queue *DBQueue
+}
+
+func NewAdminPINRepository(queue *DBQueue) *AdminPINRepository {
+	return &AdminPINRepository{queue: queue}
+}
+
+func (r *AdminPINRepository) Set(pin *models.AdminPIN) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`
+			INSERT OR REPLACE INTO admin_pins (user_id, pin_hash, salt, updated_at)
+			VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		`, pin.UserID, pin.Hash, pin.Salt)
+		return nil, err
+	})
+	return err
+}
+
+func (r *AdminPINRepository) Get(userID int64) (*models.AdminPIN, error) {
+	var pin models.AdminPIN
+	err := r.queue.DB().QueryRow(`
+		SELECT user_id, pin_hash, salt, updated_at FROM admin_pins WHERE user_id = ?
+	`, userID).Scan(&pin.UserID, &pin.Hash, &pin.Salt, &pin.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &pin, nil
+}
+
+func (r *AdminPINRepository) Delete(userID int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`DELETE FROM admin_pins WHERE user_id = ?`, userID)
+		return nil, err
+	})
+	return err
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type AdminSignatureRepository struct {
+	queue *DBQueue
+}
+
+func NewAdminSignatureRepository(queue *DBQueue) *AdminSignatureRepository {
+	return &AdminSignatureRepository{queue: queue}
+}
+
+func (r *AdminSignatureRepository) Set(signature *models.AdminSignature) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`
+			INSERT OR REPLACE INTO admin_signatures (user_id, text, entities, updated_at)
+			VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		`, signature.UserID, signature.Text, signature.Entities)
+		return nil, err
+	})
+	return err
+}
+
+func (r *AdminSignatureRepository) Get(userID int64) (*models.AdminSignature, error) {
+	var signature models.AdminSignature
+	err := r.queue.DB().QueryRow(`
+		SELECT user_id, text, COALESCE(entities, ''), updated_at FROM admin_signatures WHERE user_id = ?
+	`, userID).Scan(&signature.UserID, &signature.Text, &signature.Entities, &signature.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &signature, nil
+}
+
+func (r *AdminSignatureRepository) Delete(userID int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`DELETE FROM admin_signatures WHERE user_id = ?`, userID)
+		return nil, err
+	})
+	return err
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestAdminSignatureRepository(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewAdminSignatureRepository(NewDBQueueForTest(testDB))
+	if _, err := repo.Get(1); err != sql.ErrNoRows {
+		t.Fatalf("Get() without a signature error = %v, want sql.ErrNoRows", err)
+	}
+
+	for _, text := range []string{"— Анна", "— Анна, редакция"} {
+		if err := repo.Set(&models.AdminSignature{UserID: 1, Text: text, Entities: `[{"type":"italic","offset":2,"length":4}]`}); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	signature, err := repo.Get(1)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if signature.Text != "— Анна, редакция" || signature.Entities == "" {
+		t.Errorf("Get() = %+v, want the last signature", signature)
+	}
+
+	if err := repo.Delete(1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.Get(1); err != sql.ErrNoRows {
+		t.Errorf("Get() after Delete() error = %v, want sql.ErrNoRows", err)
+	}
+}
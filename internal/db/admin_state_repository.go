@@ -17,8 +17,8 @@ func NewAdminStateRepository(queue *DBQueue) *AdminStateRepository {
 func (r *AdminStateRepository) Save(state *models.AdminState) error {
 	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
 		_, err := db.Exec(`
-			INSERT INTO admin_state (user_id, current_state, selected_type_id, draft_text, draft_photo_id, draft_entities, editing_post_id, editing_type_id, temp_name, temp_emoji, temp_photo_id, temp_template, last_bot_message_id, reply_target_chat_id, reply_target_message_id, draft_user_photo_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			INSERT INTO admin_state (user_id, current_state, selected_type_id, draft_text, draft_photo_id, draft_entities, editing_post_id, editing_type_id, temp_name, temp_emoji, temp_photo_id, temp_template, last_bot_message_id, reply_target_chat_id, reply_target_message_id, draft_user_photo_id, reply_template_id, draft_options, draft_layout, draft_topic_id, type_selection, import_file_id, draft_frame)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 			ON CONFLICT(user_id) DO UPDATE SET
 				current_state = excluded.current_state,
 				selected_type_id = excluded.selected_type_id,
@@ -34,8 +34,15 @@ func (r *AdminStateRepository) Save(state *models.AdminState) error {
 				last_bot_message_id = excluded.last_bot_message_id,
 				reply_target_chat_id = excluded.reply_target_chat_id,
 				reply_target_message_id = excluded.reply_target_message_id,
-				draft_user_photo_id = excluded.draft_user_photo_id
-		`, state.UserID, state.CurrentState, state.SelectedTypeID, state.DraftText, state.DraftPhotoID, state.DraftEntities, state.EditingPostID, state.EditingTypeID, state.TempName, state.TempEmoji, state.TempPhotoID, state.TempTemplate, state.LastBotMessageID, state.ReplyTargetChatID, state.ReplyTargetMessageID, state.DraftUserPhotoID)
+				draft_user_photo_id = excluded.draft_user_photo_id,
+				reply_template_id = excluded.reply_template_id,
+				draft_options = excluded.draft_options,
+				draft_layout = excluded.draft_layout,
+				draft_topic_id = excluded.draft_topic_id,
+				type_selection = excluded.type_selection,
+				import_file_id = excluded.import_file_id,
+				draft_frame = excluded.draft_frame
+		`, state.UserID, state.CurrentState, state.SelectedTypeID, state.DraftText, state.DraftPhotoID, state.DraftEntities, state.EditingPostID, state.EditingTypeID, state.TempName, state.TempEmoji, state.TempPhotoID, state.TempTemplate, state.LastBotMessageID, state.ReplyTargetChatID, state.ReplyTargetMessageID, state.DraftUserPhotoID, state.ReplyTemplateID, state.DraftOptions, state.DraftLayout, state.DraftTopicID, state.TypeSelection, state.ImportFileID, state.DraftFrame)
 		return nil, err
 	})
 	return err
@@ -43,12 +50,12 @@ func (r *AdminStateRepository) Save(state *models.AdminState) error {
 
 func (r *AdminStateRepository) Get(userID int64) (*models.AdminState, error) {
 	row := r.queue.DB().QueryRow(`
-		SELECT user_id, current_state, COALESCE(selected_type_id, 0), COALESCE(draft_text, ''), COALESCE(draft_photo_id, ''), COALESCE(draft_entities, ''), COALESCE(editing_post_id, 0), COALESCE(editing_type_id, 0), COALESCE(temp_name, ''), COALESCE(temp_emoji, ''), COALESCE(temp_photo_id, ''), COALESCE(temp_template, ''), COALESCE(last_bot_message_id, 0), COALESCE(reply_target_chat_id, 0), COALESCE(reply_target_message_id, 0), COALESCE(draft_user_photo_id, '')
+		SELECT user_id, current_state, COALESCE(selected_type_id, 0), COALESCE(draft_text, ''), COALESCE(draft_photo_id, ''), COALESCE(draft_entities, ''), COALESCE(editing_post_id, 0), COALESCE(editing_type_id, 0), COALESCE(temp_name, ''), COALESCE(temp_emoji, ''), COALESCE(temp_photo_id, ''), COALESCE(temp_template, ''), COALESCE(last_bot_message_id, 0), COALESCE(reply_target_chat_id, 0), COALESCE(reply_target_message_id, 0), COALESCE(draft_user_photo_id, ''), COALESCE(reply_template_id, 0), COALESCE(draft_options, ''), COALESCE(draft_layout, ''), COALESCE(draft_topic_id, 0), COALESCE(type_selection, ''), COALESCE(import_file_id, ''), COALESCE(draft_frame, '')
 		FROM admin_state WHERE user_id = ?
 	`, userID)
 
 	var state models.AdminState
-	err := row.Scan(&state.UserID, &state.CurrentState, &state.SelectedTypeID, &state.DraftText, &state.DraftPhotoID, &state.DraftEntities, &state.EditingPostID, &state.EditingTypeID, &state.TempName, &state.TempEmoji, &state.TempPhotoID, &state.TempTemplate, &state.LastBotMessageID, &state.ReplyTargetChatID, &state.ReplyTargetMessageID, &state.DraftUserPhotoID)
+	err := row.Scan(&state.UserID, &state.CurrentState, &state.SelectedTypeID, &state.DraftText, &state.DraftPhotoID, &state.DraftEntities, &state.EditingPostID, &state.EditingTypeID, &state.TempName, &state.TempEmoji, &state.TempPhotoID, &state.TempTemplate, &state.LastBotMessageID, &state.ReplyTargetChatID, &state.ReplyTargetMessageID, &state.DraftUserPhotoID, &state.ReplyTemplateID, &state.DraftOptions, &state.DraftLayout, &state.DraftTopicID, &state.TypeSelection, &state.ImportFileID, &state.DraftFrame)
 	if err != nil {
 		return nil, err
 	}
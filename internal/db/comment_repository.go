@@ -0,0 +1,135 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type CommentRepository struct {
+	queue *DBQueue
+}
+
+func NewCommentRepository(queue *DBQueue) *CommentRepository {
+	return &CommentRepository{queue: queue}
+}
+
+// Create stores a comment. Telegram may deliver the same update twice, so a
+// repeated message is ignored and reported with ID 0.
+func (r *CommentRepository) Create(comment *models.Comment) error {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		res, err := db.Exec(`
+			INSERT OR IGNORE INTO comments (chat_id, message_id, thread_id, reply_to_message_id, post_id, reply_id, user_id, text)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, comment.ChatID, comment.MessageID, comment.ThreadID, comment.ReplyToMessageID, comment.PostID, comment.ReplyID, comment.UserID, comment.Text)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return int64(0), err
+		}
+		return res.LastInsertId()
+	})
+	if err != nil {
+		return err
+	}
+	comment.ID = result.(int64)
+	return nil
+}
+
+func (r *CommentRepository) GetByID(id int64) (*models.Comment, error) {
+	row := r.queue.DB().QueryRow(`
+		SELECT id, chat_id, message_id, COALESCE(thread_id, 0), reply_to_message_id, COALESCE(post_id, 0), COALESCE(reply_id, 0), user_id, COALESCE(text, ''), COALESCE(is_read, 0), created_at
+		FROM comments WHERE id = ?
+	`, id)
+
+	var c models.Comment
+	err := row.Scan(
+		&c.ID,
+		&c.ChatID,
+		&c.MessageID,
+		&c.ThreadID,
+		&c.ReplyToMessageID,
+		&c.PostID,
+		&c.ReplyID,
+		&c.UserID,
+		&c.Text,
+		&c.IsRead,
+		&c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetUnread returns unread comments, oldest first.
+func (r *CommentRepository) GetUnread(limit int64) ([]*models.Comment, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, chat_id, message_id, COALESCE(thread_id, 0), reply_to_message_id, COALESCE(post_id, 0), COALESCE(reply_id, 0), user_id, COALESCE(text, ''), COALESCE(is_read, 0), created_at
+		FROM comments
+		WHERE is_read = 0
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*models.Comment
+	for rows.Next() {
+		var c models.Comment
+		if err := rows.Scan(
+			&c.ID,
+			&c.ChatID,
+			&c.MessageID,
+			&c.ThreadID,
+			&c.ReplyToMessageID,
+			&c.PostID,
+			&c.ReplyID,
+			&c.UserID,
+			&c.Text,
+			&c.IsRead,
+			&c.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		comments = append(comments, &c)
+	}
+	return comments, rows.Err()
+}
+
+func (r *CommentRepository) CountUnread() (int64, error) {
+	var count int64
+	err := r.queue.DB().QueryRow(`SELECT COUNT(*) FROM comments WHERE is_read = 0`).Scan(&count)
+	return count, err
+}
+
+func (r *CommentRepository) CountByPost(postID int64) (int64, error) {
+	var count int64
+	err := r.queue.DB().QueryRow(`SELECT COUNT(*) FROM comments WHERE post_id = ?`, postID).Scan(&count)
+	return count, err
+}
+
+func (r *CommentRepository) CountByReply(replyID int64) (int64, error) {
+	var count int64
+	err := r.queue.DB().QueryRow(`SELECT COUNT(*) FROM comments WHERE reply_id = ?`, replyID).Scan(&count)
+	return count, err
+}
+
+func (r *CommentRepository) MarkRead(id int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`UPDATE comments SET is_read = 1 WHERE id = ?`, id)
+		return nil, err
+	})
+	return err
+}
+
+func (r *CommentRepository) MarkAllRead() error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`UPDATE comments SET is_read = 1 WHERE is_read = 0`)
+		return nil, err
+	})
+	return err
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type PostEventRepository struct {
+	queue *DBQueue
+}
+
+func NewPostEventRepository(queue *DBQueue) *PostEventRepository {
+	return &PostEventRepository{queue: queue}
+}
+
+func (r *PostEventRepository) Create(event *models.PostEvent) error {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		res, err := db.Exec(`
+			INSERT INTO post_events (action, admin_id, post_type_id)
+			VALUES (?, ?, ?)
+		`, event.Action, event.AdminID, event.PostTypeID)
+		if err != nil {
+			return nil, err
+		}
+		return res.LastInsertId()
+	})
+	if err != nil {
+		return err
+	}
+	event.ID = result.(int64)
+	return nil
+}
+
+// GetCreatedBetween returns events made from from up to, but not including,
+// to, oldest first.
+func (r *PostEventRepository) GetCreatedBetween(from, to time.Time) ([]*models.PostEvent, error) {
+	const layout = "2006-01-02 15:04:05"
+	rows, err := r.queue.DB().Query(`
+		SELECT id, action, COALESCE(admin_id, 0), COALESCE(post_type_id, 0), created_at
+		FROM post_events
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC, id ASC
+	`, from.UTC().Format(layout), to.UTC().Format(layout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.PostEvent
+	for rows.Next() {
+		var event models.PostEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.Action,
+			&event.AdminID,
+			&event.PostTypeID,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
@@ -2,10 +2,49 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/ad/go-telegram-admin/internal/models"
 )
 
+// postTypeColumns lists the post_types columns in the order scanPostType
+// reads them.
+const postTypeColumns = `id, name, COALESCE(emoji, ''), photo_id, template, COALESCE(template_entities, ''), is_active, COALESCE(delivery_options, ''), COALESCE(photo_url, ''), COALESCE(input_mode, ''), COALESCE(sort_order, 0), COALESCE(category, ''), COALESCE(allowed_admins, ''), COALESCE(image_strategy, ''), COALESCE(image_cursor, 0), COALESCE(header, ''), COALESCE(header_entities, ''), COALESCE(footer, ''), COALESCE(footer_entities, ''), COALESCE(counter_format, ''), COALESCE(counter_next, 1), COALESCE(is_archive, FALSE), created_at`
+
+// scanPostType reads a row selected with postTypeColumns.
+func scanPostType(row interface{ Scan(...any) error }) (*models.PostType, error) {
+	var pt models.PostType
+	err := row.Scan(
+		&pt.ID,
+		&pt.Name,
+		&pt.Emoji,
+		&pt.PhotoID,
+		&pt.Template,
+		&pt.TemplateEntities,
+		&pt.IsActive,
+		&pt.DeliveryOptions,
+		&pt.PhotoURL,
+		&pt.InputMode,
+		&pt.SortOrder,
+		&pt.Category,
+		&pt.AllowedAdmins,
+		&pt.ImageStrategy,
+		&pt.ImageCursor,
+		&pt.Header,
+		&pt.HeaderEntities,
+		&pt.Footer,
+		&pt.FooterEntities,
+		&pt.CounterFormat,
+		&pt.CounterNext,
+		&pt.IsArchive,
+		&pt.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pt, nil
+}
+
 type PostTypeRepository struct {
 	queue *DBQueue
 }
@@ -14,20 +53,51 @@ func NewPostTypeRepository(queue *DBQueue) *PostTypeRepository {
 	return &PostTypeRepository{queue: queue}
 }
 
+// execer is a *sql.DB or *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func insertPostType(ex execer, postType *models.PostType) (int64, error) {
+	res, err := ex.Exec(`
+		INSERT INTO post_types (name, emoji, photo_id, template, template_entities, is_active, delivery_options, photo_url, input_mode, sort_order, category, allowed_admins, image_strategy, header, header_entities, footer, footer_entities, counter_format, is_archive)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, postType.Name, postType.Emoji, postType.PhotoID, postType.Template, postType.TemplateEntities, postType.IsActive, postType.DeliveryOptions, postType.PhotoURL, postType.InputMode, postType.SortOrder, postType.Category, postType.AllowedAdmins, postType.ImageStrategy, postType.Header, postType.HeaderEntities, postType.Footer, postType.FooterEntities, postType.CounterFormat, postType.IsArchive)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func updatePostType(ex execer, postType *models.PostType) error {
+	_, err := ex.Exec(`
+		UPDATE post_types SET
+			name = ?,
+			emoji = ?,
+			photo_id = ?,
+			template = ?,
+			template_entities = ?,
+			is_active = ?,
+			delivery_options = ?,
+			photo_url = ?,
+			input_mode = ?,
+			sort_order = ?,
+			category = ?,
+			allowed_admins = ?,
+			image_strategy = ?,
+			header = ?,
+			header_entities = ?,
+			footer = ?,
+			footer_entities = ?,
+			counter_format = ?
+		WHERE id = ?
+	`, postType.Name, postType.Emoji, postType.PhotoID, postType.Template, postType.TemplateEntities, postType.IsActive, postType.DeliveryOptions, postType.PhotoURL, postType.InputMode, postType.SortOrder, postType.Category, postType.AllowedAdmins, postType.ImageStrategy, postType.Header, postType.HeaderEntities, postType.Footer, postType.FooterEntities, postType.CounterFormat, postType.ID)
+	return err
+}
+
 func (r *PostTypeRepository) Create(postType *models.PostType) error {
 	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
-		res, err := db.Exec(`
-			INSERT INTO post_types (name, emoji, photo_id, template, template_entities, is_active)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`, postType.Name, postType.Emoji, postType.PhotoID, postType.Template, postType.TemplateEntities, postType.IsActive)
-		if err != nil {
-			return nil, err
-		}
-		id, err := res.LastInsertId()
-		if err != nil {
-			return nil, err
-		}
-		return id, nil
+		return insertPostType(db, postType)
 	})
 	if err != nil {
 		return err
@@ -37,33 +107,16 @@ func (r *PostTypeRepository) Create(postType *models.PostType) error {
 }
 
 func (r *PostTypeRepository) GetByID(id int64) (*models.PostType, error) {
-	row := r.queue.DB().QueryRow(`
-		SELECT id, name, COALESCE(emoji, ''), photo_id, template, COALESCE(template_entities, ''), is_active, created_at
-		FROM post_types WHERE id = ?
-	`, id)
-
-	var postType models.PostType
-	err := row.Scan(
-		&postType.ID,
-		&postType.Name,
-		&postType.Emoji,
-		&postType.PhotoID,
-		&postType.Template,
-		&postType.TemplateEntities,
-		&postType.IsActive,
-		&postType.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &postType, nil
+	row := r.queue.DB().QueryRow(`SELECT `+postTypeColumns+` FROM post_types WHERE id = ?`, id)
+	return scanPostType(row)
 }
 
 func (r *PostTypeRepository) GetAll() ([]*models.PostType, error) {
 	rows, err := r.queue.DB().Query(`
-		SELECT id, name, COALESCE(emoji, ''), photo_id, template, COALESCE(template_entities, ''), is_active, created_at
+		SELECT ` + postTypeColumns + `
 		FROM post_types
-		ORDER BY created_at DESC
+		WHERE COALESCE(is_archive, FALSE) = FALSE
+		ORDER BY COALESCE(sort_order, 0), created_at DESC
 	`)
 	if err != nil {
 		return nil, err
@@ -72,30 +125,21 @@ func (r *PostTypeRepository) GetAll() ([]*models.PostType, error) {
 
 	var postTypes []*models.PostType
 	for rows.Next() {
-		var pt models.PostType
-		if err := rows.Scan(
-			&pt.ID,
-			&pt.Name,
-			&pt.Emoji,
-			&pt.PhotoID,
-			&pt.Template,
-			&pt.TemplateEntities,
-			&pt.IsActive,
-			&pt.CreatedAt,
-		); err != nil {
+		pt, err := scanPostType(rows)
+		if err != nil {
 			return nil, err
 		}
-		postTypes = append(postTypes, &pt)
+		postTypes = append(postTypes, pt)
 	}
 	return postTypes, rows.Err()
 }
 
 func (r *PostTypeRepository) GetActive() ([]*models.PostType, error) {
 	rows, err := r.queue.DB().Query(`
-		SELECT id, name, COALESCE(emoji, ''), photo_id, template, COALESCE(template_entities, ''), is_active, created_at
+		SELECT ` + postTypeColumns + `
 		FROM post_types
 		WHERE is_active = TRUE
-		ORDER BY created_at DESC
+		ORDER BY COALESCE(sort_order, 0), created_at DESC
 	`)
 	if err != nil {
 		return nil, err
@@ -104,49 +148,287 @@ func (r *PostTypeRepository) GetActive() ([]*models.PostType, error) {
 
 	var postTypes []*models.PostType
 	for rows.Next() {
-		var pt models.PostType
-		if err := rows.Scan(
-			&pt.ID,
-			&pt.Name,
-			&pt.Emoji,
-			&pt.PhotoID,
-			&pt.Template,
-			&pt.TemplateEntities,
-			&pt.IsActive,
-			&pt.CreatedAt,
-		); err != nil {
+		pt, err := scanPostType(rows)
+		if err != nil {
 			return nil, err
 		}
-		postTypes = append(postTypes, &pt)
+		postTypes = append(postTypes, pt)
 	}
 	return postTypes, rows.Err()
 }
 
 func (r *PostTypeRepository) Update(postType *models.PostType) error {
 	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
-		_, err := db.Exec(`
-			UPDATE post_types SET
-				name = ?,
-				emoji = ?,
-				photo_id = ?,
-				template = ?,
-				template_entities = ?,
-				is_active = ?
-			WHERE id = ?
-		`, postType.Name, postType.Emoji, postType.PhotoID, postType.Template, postType.TemplateEntities, postType.IsActive, postType.ID)
-		return nil, err
+		return nil, updatePostType(db, postType)
 	})
 	return err
 }
 
+// ImportedType is a type written by SaveImport: created when PostType.ID is
+// 0, updated otherwise. Pool replaces the type's additional images. Version
+// is recorded after PrevVersion when set.
+type ImportedType struct {
+	PostType    *models.PostType
+	Pool        []string
+	PrevVersion *models.PostTypeVersion
+	Version     *models.PostTypeVersion
+}
+
+// SaveImport writes imported types in one transaction, so a failed import
+// leaves the types as they were. IDs of created types are set on success.
+func (r *PostTypeRepository) SaveImport(types []*ImportedType) error {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		tx, err := db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+
+		ids := make([]int64, len(types))
+		for i, t := range types {
+			ids[i] = t.PostType.ID
+			if ids[i] == 0 {
+				if ids[i], err = insertPostType(tx, t.PostType); err != nil {
+					return nil, fmt.Errorf("failed to create post type %q: %w", t.PostType.Name, err)
+				}
+			} else if err := updatePostType(tx, t.PostType); err != nil {
+				return nil, fmt.Errorf("failed to update post type %q: %w", t.PostType.Name, err)
+			}
+			if t.Version != nil {
+				t.Version.PostTypeID = ids[i]
+				if _, err := recordTypeVersion(tx, t.PrevVersion, t.Version); err != nil {
+					return nil, fmt.Errorf("failed to record version of %q: %w", t.PostType.Name, err)
+				}
+			}
+			if _, err := tx.Exec(`DELETE FROM post_type_images WHERE post_type_id = ?`, ids[i]); err != nil {
+				return nil, err
+			}
+			for _, photoID := range t.Pool {
+				if _, err := tx.Exec(`INSERT INTO post_type_images (post_type_id, photo_id) VALUES (?, ?)`, ids[i], photoID); err != nil {
+					return nil, fmt.Errorf("failed to save images of %q: %w", t.PostType.Name, err)
+				}
+			}
+		}
+		return ids, tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+	for i, id := range result.([]int64) {
+		types[i].PostType.ID = id
+	}
+	return nil
+}
+
 func (r *PostTypeRepository) Delete(id int64) error {
 	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		if _, err := db.Exec(`DELETE FROM post_type_images WHERE post_type_id = ?`, id); err != nil {
+			return nil, err
+		}
+		if _, err := db.Exec(`DELETE FROM post_type_versions WHERE post_type_id = ?`, id); err != nil {
+			return nil, err
+		}
 		_, err := db.Exec(`DELETE FROM post_types WHERE id = ?`, id)
 		return nil, err
 	})
 	return err
 }
 
+// Move shifts the type by delta positions in the manual order. Positions
+// of all types are renumbered so that types created before any reordering,
+// which share sort_order 0, get distinct positions.
+func (r *PostTypeRepository) Move(id int64, delta int) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		tx, err := db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+
+		rows, err := tx.Query(`
+			SELECT id FROM post_types
+			WHERE COALESCE(is_archive, FALSE) = FALSE
+			ORDER BY COALESCE(sort_order, 0), created_at DESC
+		`)
+		if err != nil {
+			return nil, err
+		}
+		var ids []int64
+		for rows.Next() {
+			var typeID int64
+			if err := rows.Scan(&typeID); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ids = append(ids, typeID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		from := -1
+		for i, typeID := range ids {
+			if typeID == id {
+				from = i
+			}
+		}
+		if from < 0 {
+			return nil, sql.ErrNoRows
+		}
+		to := min(max(from+delta, 0), len(ids)-1)
+		ids = append(ids[:from], ids[from+1:]...)
+		ids = append(ids[:to], append([]int64{id}, ids[to:]...)...)
+
+		for i, typeID := range ids {
+			if _, err := tx.Exec(`UPDATE post_types SET sort_order = ? WHERE id = ?`, i+1, typeID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, tx.Commit()
+	})
+	return err
+}
+
+// GetRecentByAuthor returns active types of the author's latest posts, most
+// recently used first.
+func (r *PostTypeRepository) GetRecentByAuthor(authorID int64, limit int) ([]*models.PostType, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT `+postTypeColumns+`
+		FROM post_types pt
+		JOIN (
+			SELECT post_type_id, MAX(id) AS last_post_id
+			FROM published_posts
+			WHERE author_id = ?
+			GROUP BY post_type_id
+		) recent ON recent.post_type_id = pt.id
+		WHERE pt.is_active = TRUE
+		ORDER BY recent.last_post_id DESC
+		LIMIT ?
+	`, authorID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var postTypes []*models.PostType
+	for rows.Next() {
+		pt, err := scanPostType(rows)
+		if err != nil {
+			return nil, err
+		}
+		postTypes = append(postTypes, pt)
+	}
+	return postTypes, rows.Err()
+}
+
+// CountPosts returns how many published posts use the type.
+func (r *PostTypeRepository) CountPosts(id int64) (int64, error) {
+	var count int64
+	err := r.queue.DB().QueryRow(`SELECT COUNT(*) FROM published_posts WHERE post_type_id = ?`, id).Scan(&count)
+	return count, err
+}
+
+// DeleteMovingPosts deletes the type after moving its posts and the drafts
+// using it to targetID. With targetID 0 posts are moved to the archive type,
+// which is created on first use, and drafts lose their type: the archive
+// type is inactive, so no new post can use it. Everything runs in one
+// transaction; the number of moved posts is returned.
+func (r *PostTypeRepository) DeleteMovingPosts(id, targetID int64) (int64, error) {
+	draftTargetID := targetID
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		tx, err := db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+
+		var moved int64
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM published_posts WHERE post_type_id = ?`, id).Scan(&moved); err != nil {
+			return nil, err
+		}
+		if targetID == 0 && moved > 0 {
+			err := tx.QueryRow(`SELECT id FROM post_types WHERE is_archive = TRUE ORDER BY id LIMIT 1`).Scan(&targetID)
+			if err == sql.ErrNoRows {
+				res, err := tx.Exec(`
+					INSERT INTO post_types (name, emoji, photo_id, template, is_active, is_archive)
+					VALUES (?, ?, '', '', FALSE, TRUE)
+				`, models.ArchiveTypeName, models.ArchiveTypeEmoji)
+				if err != nil {
+					return nil, err
+				}
+				if targetID, err = res.LastInsertId(); err != nil {
+					return nil, err
+				}
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		if targetID == id {
+			return nil, fmt.Errorf("cannot move posts of type %d to itself", id)
+		}
+
+		if _, err := tx.Exec(`UPDATE published_posts SET post_type_id = ?, type_version = 0 WHERE post_type_id = ?`, targetID, id); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE admin_state SET selected_type_id = ? WHERE selected_type_id = ?`, draftTargetID, id); err != nil {
+			return nil, err
+		}
+		res, err := tx.Exec(`DELETE FROM post_types WHERE id = ? AND COALESCE(is_archive, FALSE) = FALSE`, id)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 0 {
+			return nil, sql.ErrNoRows
+		}
+		if _, err := tx.Exec(`DELETE FROM post_type_images WHERE post_type_id = ?`, id); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM post_type_versions WHERE post_type_id = ?`, id); err != nil {
+			return nil, err
+		}
+		return moved, tx.Commit()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+// TakeNumber returns the type's next post number and advances the counter.
+// The queue runs it alone, so two posts never get the same number. It
+// returns 0 when the type has no counter.
+func (r *PostTypeRepository) TakeNumber(id int64) (int64, error) {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		var number int64
+		err := db.QueryRow(`
+			UPDATE post_types SET counter_next = COALESCE(counter_next, 1) + 1
+			WHERE id = ? AND COALESCE(counter_format, '') != ''
+			RETURNING counter_next - 1
+		`, id).Scan(&number)
+		if err == sql.ErrNoRows {
+			return int64(0), nil
+		}
+		return number, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+// SetCounterNext sets the number the type's next post gets. Update leaves
+// the counter alone so that editing a type cannot move it back.
+func (r *PostTypeRepository) SetCounterNext(id, next int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`UPDATE post_types SET counter_next = ? WHERE id = ?`, next, id)
+		return nil, err
+	})
+	return err
+}
+
 func (r *PostTypeRepository) SetActive(id int64, active bool) error {
 	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
 		_, err := db.Exec(`UPDATE post_types SET is_active = ? WHERE id = ?`, active, id)
@@ -154,3 +436,69 @@ func (r *PostTypeRepository) SetActive(id int64, active bool) error {
 	})
 	return err
 }
+
+// AdvanceImageCursor moves the type's round-robin image position forward.
+func (r *PostTypeRepository) AdvanceImageCursor(id int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`UPDATE post_types SET image_cursor = COALESCE(image_cursor, 0) + 1 WHERE id = ?`, id)
+		return nil, err
+	})
+	return err
+}
+
+func (r *PostTypeRepository) AddImage(typeID int64, photoID string) (*models.PostTypeImage, error) {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		res, err := db.Exec(`INSERT INTO post_type_images (post_type_id, photo_id) VALUES (?, ?)`, typeID, photoID)
+		if err != nil {
+			return nil, err
+		}
+		return res.LastInsertId()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.PostTypeImage{ID: result.(int64), PostTypeID: typeID, PhotoID: photoID}, nil
+}
+
+// GetImages returns the type's image pool in the order the images were added.
+func (r *PostTypeRepository) GetImages(typeID int64) ([]*models.PostTypeImage, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, post_type_id, photo_id, created_at
+		FROM post_type_images WHERE post_type_id = ?
+		ORDER BY id
+	`, typeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []*models.PostTypeImage
+	for rows.Next() {
+		var image models.PostTypeImage
+		if err := rows.Scan(&image.ID, &image.PostTypeID, &image.PhotoID, &image.CreatedAt); err != nil {
+			return nil, err
+		}
+		images = append(images, &image)
+	}
+	return images, rows.Err()
+}
+
+func (r *PostTypeRepository) GetImage(id int64) (*models.PostTypeImage, error) {
+	var image models.PostTypeImage
+	err := r.queue.DB().QueryRow(`
+		SELECT id, post_type_id, photo_id, created_at
+		FROM post_type_images WHERE id = ?
+	`, id).Scan(&image.ID, &image.PostTypeID, &image.PhotoID, &image.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *PostTypeRepository) DeleteImage(id int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`DELETE FROM post_type_images WHERE id = ?`, id)
+		return nil, err
+	})
+	return err
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"sync"
 	"testing"
 
 	"github.com/ad/go-telegram-admin/internal/models"
@@ -168,3 +169,335 @@ func TestProperty14_PostTypeDeactivation(t *testing.T) {
 		}
 	})
 }
+
+func TestPostTypeDeleteMovingPosts(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := NewDBQueueForTest(testDB)
+	repo := NewPostTypeRepository(queue)
+	postRepo := NewPublishedPostRepository(queue)
+
+	var types []*models.PostType
+	for _, name := range []string{"Новости", "Анонсы", "События"} {
+		pt := &models.PostType{Name: name, Template: "шаблон", IsActive: true}
+		if err := repo.Create(pt); err != nil {
+			t.Fatalf("Failed to create type: %v", err)
+		}
+		types = append(types, pt)
+	}
+	for i, pt := range []*models.PostType{types[0], types[0], types[1]} {
+		post := &models.PublishedPost{PostTypeID: pt.ID, ChatID: -100, TopicID: 1, MessageID: int64(i + 1), Text: "пост"}
+		if err := postRepo.Create(post); err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+	}
+
+	moved, err := repo.DeleteMovingPosts(types[0].ID, types[2].ID)
+	if err != nil || moved != 2 {
+		t.Fatalf("DeleteMovingPosts() = %d, %v, want 2", moved, err)
+	}
+	if count, _ := repo.CountPosts(types[2].ID); count != 2 {
+		t.Errorf("Expected 2 posts moved to the target type, got %d", count)
+	}
+	if _, err := repo.GetByID(types[0].ID); err != sql.ErrNoRows {
+		t.Errorf("Expected deleted type to be gone, got %v", err)
+	}
+
+	stateRepo := NewAdminStateRepository(queue)
+	if err := stateRepo.Save(&models.AdminState{UserID: 1, CurrentState: "new_post_confirm", SelectedTypeID: types[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err = repo.DeleteMovingPosts(types[1].ID, 0)
+	if err != nil || moved != 1 {
+		t.Fatalf("DeleteMovingPosts() to archive = %d, %v, want 1", moved, err)
+	}
+	post, err := postRepo.GetByMessageID(-100, 3)
+	if err != nil {
+		t.Fatalf("Failed to get post: %v", err)
+	}
+	archive, err := repo.GetByID(post.PostTypeID)
+	if err != nil || !archive.IsArchive || archive.IsActive {
+		t.Fatalf("Expected post in an inactive archive type, got %+v, %v", archive, err)
+	}
+	if state, err := stateRepo.Get(1); err != nil || state.SelectedTypeID != 0 {
+		t.Errorf("Expected the draft to lose its type, got %+v, %v", state, err)
+	}
+
+	all, err := repo.GetAll()
+	if err != nil || len(all) != 1 || all[0].ID != types[2].ID {
+		t.Errorf("Expected only the remaining type to be listed, got %d types, %v", len(all), err)
+	}
+	if _, err := repo.DeleteMovingPosts(archive.ID, types[2].ID); err == nil {
+		t.Error("Expected the archive type not to be deletable")
+	}
+	if count, _ := repo.CountPosts(archive.ID); count != 1 {
+		t.Errorf("Expected failed deletion to be rolled back, archive has %d posts", count)
+	}
+}
+
+func TestPostTypeMoveAndRecent(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := NewDBQueueForTest(testDB)
+	repo := NewPostTypeRepository(queue)
+	postRepo := NewPublishedPostRepository(queue)
+
+	for _, name := range []string{"Новости", "Анонсы", "События"} {
+		if err := repo.Create(&models.PostType{Name: name, Template: "шаблон", IsActive: true}); err != nil {
+			t.Fatalf("Failed to create type: %v", err)
+		}
+	}
+	names := func() []string {
+		all, err := repo.GetAll()
+		if err != nil {
+			t.Fatalf("Failed to get types: %v", err)
+		}
+		var result []string
+		for _, pt := range all {
+			result = append(result, pt.Name)
+		}
+		return result
+	}
+
+	all, _ := repo.GetAll()
+	first, last := all[0], all[len(all)-1]
+	if err := repo.Move(last.ID, -1); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if got := names(); got[1] != last.Name {
+		t.Errorf("Expected %q to move up to the middle, got %v", last.Name, got)
+	}
+	if err := repo.Move(first.ID, -1); err != nil {
+		t.Fatalf("Move() at the top error = %v", err)
+	}
+	if got := names(); got[0] != first.Name {
+		t.Errorf("Expected %q to stay on top, got %v", first.Name, got)
+	}
+
+	for i, pt := range []*models.PostType{first, last, first} {
+		post := &models.PublishedPost{PostTypeID: pt.ID, ChatID: -100, MessageID: int64(i + 1), Text: "пост", AuthorID: 7}
+		if err := postRepo.Create(post); err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+	}
+	recent, err := repo.GetRecentByAuthor(7, 3)
+	if err != nil {
+		t.Fatalf("GetRecentByAuthor() error = %v", err)
+	}
+	if len(recent) != 2 || recent[0].ID != first.ID || recent[1].ID != last.ID {
+		t.Errorf("Expected the two used types, most recent first, got %d types", len(recent))
+	}
+	if recent, _ := repo.GetRecentByAuthor(8, 3); len(recent) != 0 {
+		t.Errorf("Expected no recent types for another author, got %d", len(recent))
+	}
+}
+
+func TestPostTypeImagePool(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := NewDBQueueForTest(testDB)
+	repo := NewPostTypeRepository(queue)
+
+	pt := &models.PostType{Name: "Новости", Template: "шаблон", IsActive: true, PhotoID: "main", ImageStrategy: models.ImageStrategyRoundRobin}
+	if err := repo.Create(pt); err != nil {
+		t.Fatalf("Failed to create type: %v", err)
+	}
+	first, err := repo.AddImage(pt.ID, "one")
+	if err != nil {
+		t.Fatalf("AddImage() error = %v", err)
+	}
+	if _, err := repo.AddImage(pt.ID, "two"); err != nil {
+		t.Fatalf("AddImage() error = %v", err)
+	}
+
+	images, err := repo.GetImages(pt.ID)
+	if err != nil {
+		t.Fatalf("GetImages() error = %v", err)
+	}
+	if len(images) != 2 || images[0].PhotoID != "one" || images[1].PhotoID != "two" {
+		t.Fatalf("Expected images in the order they were added, got %d", len(images))
+	}
+
+	for range 2 {
+		if err := repo.AdvanceImageCursor(pt.ID); err != nil {
+			t.Fatalf("AdvanceImageCursor() error = %v", err)
+		}
+	}
+	// Editing the type must not reset the cursor.
+	pt.Name = "Новости дня"
+	if err := repo.Update(pt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, _ := repo.GetByID(pt.ID)
+	if got.ImageCursor != 2 || got.ImageStrategy != models.ImageStrategyRoundRobin {
+		t.Errorf("Expected cursor 2 and round robin, got %d and %q", got.ImageCursor, got.ImageStrategy)
+	}
+
+	if err := repo.DeleteImage(first.ID); err != nil {
+		t.Fatalf("DeleteImage() error = %v", err)
+	}
+	if _, err := repo.GetImage(first.ID); err == nil {
+		t.Error("Expected the deleted image to be gone")
+	}
+	if err := repo.Delete(pt.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if images, _ := repo.GetImages(pt.ID); len(images) != 0 {
+		t.Errorf("Expected the pool to be deleted with the type, got %d images", len(images))
+	}
+}
+
+func TestPostTypeTakeNumber(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := NewDBQueueForTest(testDB)
+	repo := NewPostTypeRepository(queue)
+	postRepo := NewPublishedPostRepository(queue)
+
+	pt := &models.PostType{Name: "Вакансии", Template: "шаблон", IsActive: true}
+	if err := repo.Create(pt); err != nil {
+		t.Fatalf("Failed to create type: %v", err)
+	}
+	if n, err := repo.TakeNumber(pt.ID); err != nil || n != 0 {
+		t.Fatalf("TakeNumber() without a counter = %d, %v; want 0", n, err)
+	}
+
+	pt.CounterFormat = "№{n}"
+	if err := repo.Update(pt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := repo.SetCounterNext(pt.ID, 154); err != nil {
+		t.Fatalf("SetCounterNext() error = %v", err)
+	}
+
+	const posts = 20
+	numbers := make(chan int64, posts)
+	var wg sync.WaitGroup
+	for range posts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := repo.TakeNumber(pt.ID)
+			if err != nil {
+				t.Errorf("TakeNumber() error = %v", err)
+			}
+			numbers <- n
+		}()
+	}
+	wg.Wait()
+	close(numbers)
+	seen := make(map[int64]bool)
+	for n := range numbers {
+		if n < 154 || n >= 154+posts || seen[n] {
+			t.Errorf("Unexpected or repeated number %d", n)
+		}
+		seen[n] = true
+	}
+
+	// A stale copy of the type must not move the counter back.
+	if err := repo.Update(pt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, _ := repo.GetByID(pt.ID)
+	if got.CounterNext != 154+posts || got.FormatNumber(got.CounterNext) != "№174" {
+		t.Errorf("Expected next number 174, got %d", got.CounterNext)
+	}
+
+	post := &models.PublishedPost{PostTypeID: pt.ID, ChatID: -100, MessageID: 1, Text: "пост", Number: 154}
+	if err := postRepo.Create(post); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	found, err := postRepo.GetByNumber(154)
+	if err != nil || len(found) != 1 || found[0].ID != post.ID {
+		t.Errorf("GetByNumber(154) = %d posts, %v", len(found), err)
+	}
+}
+
+func TestPostTypeSaveImport(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := NewDBQueueForTest(testDB)
+	repo := NewPostTypeRepository(queue)
+	versionRepo := NewPostTypeVersionRepository(queue)
+
+	existing := &models.PostType{Name: "Новости", Template: "старый", IsActive: true}
+	if err := repo.Create(existing); err != nil {
+		t.Fatalf("Failed to create type: %v", err)
+	}
+	if _, err := repo.AddImage(existing.ID, "old-photo"); err != nil {
+		t.Fatalf("AddImage() error = %v", err)
+	}
+
+	prev := models.NewPostTypeVersion(existing)
+	existing.Template = "новый"
+	created := &models.PostType{Name: "Анонсы", Template: "анонс", IsActive: true}
+	err = repo.SaveImport([]*ImportedType{
+		{PostType: existing, PrevVersion: prev, Version: models.NewPostTypeVersion(existing)},
+		{PostType: created, Pool: []string{"photo-1", "photo-2"}},
+	})
+	if err != nil {
+		t.Fatalf("SaveImport() error = %v", err)
+	}
+	if created.ID == 0 {
+		t.Error("SaveImport() did not set the ID of the created type")
+	}
+	if got, err := repo.GetByID(existing.ID); err != nil || got.Template != "новый" {
+		t.Errorf("Replaced type = %+v, %v", got, err)
+	}
+	if version, err := versionRepo.Current(existing.ID); err != nil || version != 2 {
+		t.Errorf("Current() = %d, %v, want 2", version, err)
+	}
+	if images, err := repo.GetImages(existing.ID); err != nil || len(images) != 0 {
+		t.Errorf("Images of the replaced type = %d, %v, want the pool replaced", len(images), err)
+	}
+	if images, err := repo.GetImages(created.ID); err != nil || len(images) != 2 {
+		t.Errorf("Images of the created type = %d, %v, want 2", len(images), err)
+	}
+
+	// A failure rolls back the types written before it.
+	if _, err := testDB.Exec(`DROP TABLE post_type_images`); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveImport([]*ImportedType{{PostType: &models.PostType{Name: "Резюме", Template: "t"}}}); err == nil {
+		t.Fatal("SaveImport() without the images table succeeded")
+	}
+	if types, err := repo.GetAll(); err != nil || len(types) != 2 {
+		t.Errorf("GetAll() after a failed import = %d types, %v; want 2", len(types), err)
+	}
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type PostTypeVersionRepository struct {
+	queue *DBQueue
+}
+
+func NewPostTypeVersionRepository(queue *DBQueue) *PostTypeVersionRepository {
+	return &PostTypeVersionRepository{queue: queue}
+}
+
+// Record saves a change of a type's look as a new version and returns its
+// number. The first change of a type also saves the look before it as
+// version 1, so the history starts with what older posts were published
+// with.
+func (r *PostTypeVersionRepository) Record(prev, next *models.PostTypeVersion) (int, error) {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		tx, err := db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+
+		last, err := recordTypeVersion(tx, prev, next)
+		if err != nil {
+			return nil, err
+		}
+		return last, tx.Commit()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int), nil
+}
+
+// recordTypeVersion is Record within tx.
+func recordTypeVersion(tx *sql.Tx, prev, next *models.PostTypeVersion) (int, error) {
+	var last int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM post_type_versions WHERE post_type_id = ?`, next.PostTypeID).Scan(&last); err != nil {
+		return 0, err
+	}
+	versions := []*models.PostTypeVersion{next}
+	if last == 0 {
+		versions = []*models.PostTypeVersion{prev, next}
+	}
+	for _, v := range versions {
+		last++
+		if _, err := tx.Exec(`
+			INSERT INTO post_type_versions (post_type_id, version, photo_id, template, template_entities, header, header_entities, footer, footer_entities, changed_by)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, next.PostTypeID, last, v.PhotoID, v.Template, v.TemplateEntities, v.Header, v.HeaderEntities, v.Footer, v.FooterEntities, v.ChangedBy); err != nil {
+			return 0, err
+		}
+	}
+	return last, nil
+}
+
+// Current returns the latest version of the type, 0 when it has none.
+func (r *PostTypeVersionRepository) Current(postTypeID int64) (int, error) {
+	var version int
+	err := r.queue.DB().QueryRow(`SELECT COALESCE(MAX(version), 0) FROM post_type_versions WHERE post_type_id = ?`, postTypeID).Scan(&version)
+	return version, err
+}
+
+// GetByType returns the type's versions, newest first.
+func (r *PostTypeVersionRepository) GetByType(postTypeID int64) ([]*models.PostTypeVersion, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, post_type_id, version, COALESCE(photo_id, ''), COALESCE(template, ''), COALESCE(template_entities, ''), COALESCE(header, ''), COALESCE(header_entities, ''), COALESCE(footer, ''), COALESCE(footer_entities, ''), COALESCE(changed_by, 0), created_at
+		FROM post_type_versions
+		WHERE post_type_id = ?
+		ORDER BY version DESC
+	`, postTypeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*models.PostTypeVersion
+	for rows.Next() {
+		var v models.PostTypeVersion
+		if err := rows.Scan(
+			&v.ID,
+			&v.PostTypeID,
+			&v.Version,
+			&v.PhotoID,
+			&v.Template,
+			&v.TemplateEntities,
+			&v.Header,
+			&v.HeaderEntities,
+			&v.Footer,
+			&v.FooterEntities,
+			&v.ChangedBy,
+			&v.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		versions = append(versions, &v)
+	}
+	return versions, rows.Err()
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestPostTypeVersionRepository(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := NewDBQueueForTest(testDB)
+	typeRepo := NewPostTypeRepository(queue)
+	postRepo := NewPublishedPostRepository(queue)
+	repo := NewPostTypeVersionRepository(queue)
+
+	postType := &models.PostType{Name: "Новости", Template: "шаблон", PhotoID: "photo-1", IsActive: true}
+	if err := typeRepo.Create(postType); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := repo.Current(postType.ID); err != nil || version != 0 {
+		t.Fatalf("Current() without versions = %d, %v", version, err)
+	}
+
+	prev := models.NewPostTypeVersion(postType)
+	postType.PhotoID = "photo-2"
+	next := models.NewPostTypeVersion(postType)
+	next.ChangedBy = 7
+	if version, err := repo.Record(prev, next); err != nil || version != 2 {
+		t.Fatalf("first Record() = %d, %v, want 2", version, err)
+	}
+	prev, next = next, models.NewPostTypeVersion(postType)
+	next.Header = "Шапка"
+	if version, err := repo.Record(prev, next); err != nil || version != 3 {
+		t.Fatalf("second Record() = %d, %v, want 3", version, err)
+	}
+
+	versions, err := repo.GetByType(postType.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[0].Version != 3 || versions[2].PhotoID != "photo-1" || versions[1].ChangedBy != 7 {
+		t.Fatalf("GetByType() = %+v", versions)
+	}
+	if changes := versions[1].Changes(versions[2]); len(changes) != 1 || changes[0] != "изображение" {
+		t.Errorf("Changes() = %v", changes)
+	}
+
+	for i := range 3 {
+		post := &models.PublishedPost{PostTypeID: postType.ID, ChatID: -100, MessageID: int64(i + 1), Text: "пост", TypeVersion: int64(i)}
+		if err := postRepo.Create(post); err != nil {
+			t.Fatal(err)
+		}
+	}
+	posts, err := postRepo.GetByType(postType.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 3 || posts[0].MessageID != 3 || posts[0].TypeVersion != 2 {
+		t.Fatalf("GetByType() = %+v", posts)
+	}
+
+	if err := typeRepo.Delete(postType.ID); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := repo.Current(postType.ID); err != nil || version != 0 {
+		t.Errorf("Current() after type deletion = %d, %v", version, err)
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/ad/go-telegram-admin/internal/models"
 )
@@ -17,9 +18,9 @@ func NewPublishedPostRepository(queue *DBQueue) *PublishedPostRepository {
 func (r *PublishedPostRepository) Create(post *models.PublishedPost) error {
 	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
 		res, err := db.Exec(`
-			INSERT INTO published_posts (post_type_id, chat_id, topic_id, message_id, text, photo_id, entities, user_photo_id, user_photo_message_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`, post.PostTypeID, post.ChatID, post.TopicID, post.MessageID, post.Text, post.PhotoID, post.Entities, post.UserPhotoID, post.UserPhotoMessageID)
+			INSERT INTO published_posts (post_type_id, chat_id, topic_id, message_id, text, photo_id, entities, user_photo_id, user_photo_message_id, author_id, delivery_options, preview_url, frame, number, type_version)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, post.PostTypeID, post.ChatID, post.TopicID, post.MessageID, post.Text, post.PhotoID, post.Entities, post.UserPhotoID, post.UserPhotoMessageID, post.AuthorID, post.DeliveryOptions, post.PreviewURL, post.Frame, post.Number, post.TypeVersion)
 		if err != nil {
 			return nil, err
 		}
@@ -38,7 +39,7 @@ func (r *PublishedPostRepository) Create(post *models.PublishedPost) error {
 
 func (r *PublishedPostRepository) GetByID(id int64) (*models.PublishedPost, error) {
 	row := r.queue.DB().QueryRow(`
-		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), created_at
+		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), COALESCE(author_id, 0), COALESCE(delivery_options, ''), COALESCE(preview_url, ''), COALESCE(frame, ''), COALESCE(number, 0), COALESCE(type_version, 0), created_at
 		FROM published_posts WHERE id = ?
 	`, id)
 
@@ -54,6 +55,12 @@ func (r *PublishedPostRepository) GetByID(id int64) (*models.PublishedPost, erro
 		&post.Entities,
 		&post.UserPhotoID,
 		&post.UserPhotoMessageID,
+		&post.AuthorID,
+		&post.DeliveryOptions,
+		&post.PreviewURL,
+		&post.Frame,
+		&post.Number,
+		&post.TypeVersion,
 		&post.CreatedAt,
 	)
 	if err != nil {
@@ -64,7 +71,7 @@ func (r *PublishedPostRepository) GetByID(id int64) (*models.PublishedPost, erro
 
 func (r *PublishedPostRepository) GetByMessageID(chatID, messageID int64) (*models.PublishedPost, error) {
 	row := r.queue.DB().QueryRow(`
-		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), created_at
+		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), COALESCE(author_id, 0), COALESCE(delivery_options, ''), COALESCE(preview_url, ''), COALESCE(frame, ''), COALESCE(number, 0), COALESCE(type_version, 0), created_at
 		FROM published_posts WHERE chat_id = ? AND message_id = ?
 	`, chatID, messageID)
 
@@ -80,6 +87,12 @@ func (r *PublishedPostRepository) GetByMessageID(chatID, messageID int64) (*mode
 		&post.Entities,
 		&post.UserPhotoID,
 		&post.UserPhotoMessageID,
+		&post.AuthorID,
+		&post.DeliveryOptions,
+		&post.PreviewURL,
+		&post.Frame,
+		&post.Number,
+		&post.TypeVersion,
 		&post.CreatedAt,
 	)
 	if err != nil {
@@ -90,7 +103,7 @@ func (r *PublishedPostRepository) GetByMessageID(chatID, messageID int64) (*mode
 
 func (r *PublishedPostRepository) GetAll() ([]*models.PublishedPost, error) {
 	rows, err := r.queue.DB().Query(`
-		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), created_at
+		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), COALESCE(author_id, 0), COALESCE(delivery_options, ''), COALESCE(preview_url, ''), COALESCE(frame, ''), COALESCE(number, 0), COALESCE(type_version, 0), created_at
 		FROM published_posts
 		ORDER BY created_at DESC
 	`)
@@ -113,6 +126,56 @@ func (r *PublishedPostRepository) GetAll() ([]*models.PublishedPost, error) {
 			&post.Entities,
 			&post.UserPhotoID,
 			&post.UserPhotoMessageID,
+			&post.AuthorID,
+			&post.DeliveryOptions,
+			&post.PreviewURL,
+			&post.Frame,
+			&post.Number,
+			&post.TypeVersion,
+			&post.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		posts = append(posts, &post)
+	}
+	return posts, rows.Err()
+}
+
+// GetCreatedBetween returns posts of all chats created from from up to, but
+// not including, to, oldest first.
+func (r *PublishedPostRepository) GetCreatedBetween(from, to time.Time) ([]*models.PublishedPost, error) {
+	const layout = "2006-01-02 15:04:05"
+	rows, err := r.queue.DB().Query(`
+		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), COALESCE(author_id, 0), COALESCE(delivery_options, ''), COALESCE(preview_url, ''), COALESCE(frame, ''), COALESCE(number, 0), COALESCE(type_version, 0), created_at
+		FROM published_posts
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC
+	`, from.UTC().Format(layout), to.UTC().Format(layout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []*models.PublishedPost
+	for rows.Next() {
+		var post models.PublishedPost
+		if err := rows.Scan(
+			&post.ID,
+			&post.PostTypeID,
+			&post.ChatID,
+			&post.TopicID,
+			&post.MessageID,
+			&post.Text,
+			&post.PhotoID,
+			&post.Entities,
+			&post.UserPhotoID,
+			&post.UserPhotoMessageID,
+			&post.AuthorID,
+			&post.DeliveryOptions,
+			&post.PreviewURL,
+			&post.Frame,
+			&post.Number,
+			&post.TypeVersion,
 			&post.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -134,9 +197,13 @@ func (r *PublishedPostRepository) Update(post *models.PublishedPost) error {
 				photo_id = ?,
 				entities = ?,
 				user_photo_id = ?,
-				user_photo_message_id = ?
+				user_photo_message_id = ?,
+				delivery_options = ?,
+				preview_url = ?,
+				frame = ?,
+				type_version = ?
 			WHERE id = ?
-		`, post.PostTypeID, post.ChatID, post.TopicID, post.MessageID, post.Text, post.PhotoID, post.Entities, post.UserPhotoID, post.UserPhotoMessageID, post.ID)
+		`, post.PostTypeID, post.ChatID, post.TopicID, post.MessageID, post.Text, post.PhotoID, post.Entities, post.UserPhotoID, post.UserPhotoMessageID, post.DeliveryOptions, post.PreviewURL, post.Frame, post.TypeVersion, post.ID)
 		return nil, err
 	})
 	return err
@@ -144,12 +211,55 @@ func (r *PublishedPostRepository) Update(post *models.PublishedPost) error {
 
 func (r *PublishedPostRepository) Delete(id int64) error {
 	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		if _, err := db.Exec(`DELETE FROM post_follow_ups WHERE post_id = ?`, id); err != nil {
+			return nil, err
+		}
 		_, err := db.Exec(`DELETE FROM published_posts WHERE id = ?`, id)
 		return nil, err
 	})
 	return err
 }
 
+// SetFollowUps replaces the follow-up messages that carry the rest of a
+// post's text, in order.
+func (r *PublishedPostRepository) SetFollowUps(postID int64, messageIDs []int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		if _, err := db.Exec(`DELETE FROM post_follow_ups WHERE post_id = ?`, postID); err != nil {
+			return nil, err
+		}
+		for i, messageID := range messageIDs {
+			if _, err := db.Exec(`
+				INSERT INTO post_follow_ups (post_id, position, message_id)
+				VALUES (?, ?, ?)
+			`, postID, i, messageID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (r *PublishedPostRepository) GetFollowUps(postID int64) ([]int64, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT message_id FROM post_follow_ups WHERE post_id = ? ORDER BY position
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messageIDs []int64
+	for rows.Next() {
+		var messageID int64
+		if err := rows.Scan(&messageID); err != nil {
+			return nil, err
+		}
+		messageIDs = append(messageIDs, messageID)
+	}
+	return messageIDs, rows.Err()
+}
+
 func (r *PublishedPostRepository) Count() (int64, error) {
 	var count int64
 	err := r.queue.DB().QueryRow(`SELECT COUNT(*) FROM published_posts`).Scan(&count)
@@ -158,7 +268,7 @@ func (r *PublishedPostRepository) Count() (int64, error) {
 
 func (r *PublishedPostRepository) GetPaginated(limit, offset int64) ([]*models.PublishedPost, error) {
 	rows, err := r.queue.DB().Query(`
-		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), created_at
+		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), COALESCE(author_id, 0), COALESCE(delivery_options, ''), COALESCE(preview_url, ''), COALESCE(frame, ''), COALESCE(number, 0), COALESCE(type_version, 0), created_at
 		FROM published_posts
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
@@ -182,6 +292,97 @@ func (r *PublishedPostRepository) GetPaginated(limit, offset int64) ([]*models.P
 			&post.Entities,
 			&post.UserPhotoID,
 			&post.UserPhotoMessageID,
+			&post.AuthorID,
+			&post.DeliveryOptions,
+			&post.PreviewURL,
+			&post.Frame,
+			&post.Number,
+			&post.TypeVersion,
+			&post.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		posts = append(posts, &post)
+	}
+	return posts, rows.Err()
+}
+
+// GetByNumber returns the posts with the number, newest first. Numbers are
+// counted per type, so posts of different types may share one.
+func (r *PublishedPostRepository) GetByNumber(number int64) ([]*models.PublishedPost, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), COALESCE(author_id, 0), COALESCE(delivery_options, ''), COALESCE(preview_url, ''), COALESCE(frame, ''), COALESCE(number, 0), COALESCE(type_version, 0), created_at
+		FROM published_posts
+		WHERE number = ?
+		ORDER BY created_at DESC
+	`, number)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []*models.PublishedPost
+	for rows.Next() {
+		var post models.PublishedPost
+		if err := rows.Scan(
+			&post.ID,
+			&post.PostTypeID,
+			&post.ChatID,
+			&post.TopicID,
+			&post.MessageID,
+			&post.Text,
+			&post.PhotoID,
+			&post.Entities,
+			&post.UserPhotoID,
+			&post.UserPhotoMessageID,
+			&post.AuthorID,
+			&post.DeliveryOptions,
+			&post.PreviewURL,
+			&post.Frame,
+			&post.Number,
+			&post.TypeVersion,
+			&post.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		posts = append(posts, &post)
+	}
+	return posts, rows.Err()
+}
+
+// GetByType returns the posts of the type, newest first.
+func (r *PublishedPostRepository) GetByType(postTypeID int64) ([]*models.PublishedPost, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, post_type_id, chat_id, topic_id, message_id, text, photo_id, COALESCE(entities, ''), COALESCE(user_photo_id, ''), COALESCE(user_photo_message_id, 0), COALESCE(author_id, 0), COALESCE(delivery_options, ''), COALESCE(preview_url, ''), COALESCE(frame, ''), COALESCE(number, 0), COALESCE(type_version, 0), created_at
+		FROM published_posts
+		WHERE post_type_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, postTypeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []*models.PublishedPost
+	for rows.Next() {
+		var post models.PublishedPost
+		if err := rows.Scan(
+			&post.ID,
+			&post.PostTypeID,
+			&post.ChatID,
+			&post.TopicID,
+			&post.MessageID,
+			&post.Text,
+			&post.PhotoID,
+			&post.Entities,
+			&post.UserPhotoID,
+			&post.UserPhotoMessageID,
+			&post.AuthorID,
+			&post.DeliveryOptions,
+			&post.PreviewURL,
+			&post.Frame,
+			&post.Number,
+			&post.TypeVersion,
 			&post.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+func TestPublishedPostFollowUps(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+	repo := NewPublishedPostRepository(NewDBQueueForTest(testDB))
+
+	post := &models.PublishedPost{PostTypeID: 1, ChatID: -100, TopicID: 1, MessageID: 10, Text: "пост", PhotoID: "photo"}
+	if err := repo.Create(post); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if err := repo.SetFollowUps(post.ID, []int64{12, 11}); err != nil {
+		t.Fatalf("SetFollowUps failed: %v", err)
+	}
+	got, err := repo.GetFollowUps(post.ID)
+	if err != nil || len(got) != 2 || got[0] != 12 || got[1] != 11 {
+		t.Fatalf("Expected follow-ups [12 11] in order, got %v (err %v)", got, err)
+	}
+
+	if err := repo.SetFollowUps(post.ID, []int64{13}); err != nil {
+		t.Fatalf("SetFollowUps failed: %v", err)
+	}
+	if got, _ := repo.GetFollowUps(post.ID); len(got) != 1 || got[0] != 13 {
+		t.Errorf("Expected follow-ups to be replaced, got %v", got)
+	}
+
+	if err := repo.Delete(post.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got, _ := repo.GetFollowUps(post.ID); len(got) != 0 {
+		t.Errorf("Expected follow-ups to be deleted with the post, got %v", got)
+	}
+}
+
+func TestPublishedPostFrameKeptOnUpdate(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+	repo := NewPublishedPostRepository(NewDBQueueForTest(testDB))
+
+	frame := models.PostFrame{Header: "📢 Анонс", Footer: "#анонс\n— Анна"}.String()
+	post := &models.PublishedPost{PostTypeID: 1, ChatID: -100, TopicID: 1, MessageID: 10, Text: "пост", Frame: frame}
+	if err := repo.Create(post); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	post.Text = "новый текст"
+	if err := repo.Update(post); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, err := repo.GetByID(post.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Text != "новый текст" || got.Frame != frame {
+		t.Errorf("Expected the new body in the same frame, got %q in %q", got.Text, got.Frame)
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/ad/go-telegram-admin/internal/models"
 )
@@ -17,9 +18,9 @@ func NewReplyRepository(queue *DBQueue) *ReplyRepository {
 func (r *ReplyRepository) Create(reply *models.Reply) error {
 	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
 		res, err := db.Exec(`
-			INSERT INTO replies (chat_id, reply_to_message_id, message_id, text, photo_id, entities)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`, reply.ChatID, reply.ReplyToMessageID, reply.MessageID, reply.Text, reply.PhotoID, reply.Entities)
+			INSERT INTO replies (chat_id, reply_to_message_id, message_id, text, photo_id, entities, author_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, reply.ChatID, reply.ReplyToMessageID, reply.MessageID, reply.Text, reply.PhotoID, reply.Entities, reply.AuthorID)
 		if err != nil {
 			return nil, err
 		}
@@ -38,7 +39,7 @@ func (r *ReplyRepository) Create(reply *models.Reply) error {
 
 func (r *ReplyRepository) GetByID(id int64) (*models.Reply, error) {
 	row := r.queue.DB().QueryRow(`
-		SELECT id, chat_id, reply_to_message_id, message_id, text, COALESCE(photo_id, ''), COALESCE(entities, ''), created_at
+		SELECT id, chat_id, reply_to_message_id, message_id, text, COALESCE(photo_id, ''), COALESCE(entities, ''), COALESCE(author_id, 0), created_at
 		FROM replies WHERE id = ?
 	`, id)
 
@@ -51,6 +52,31 @@ func (r *ReplyRepository) GetByID(id int64) (*models.Reply, error) {
 		&reply.Text,
 		&reply.PhotoID,
 		&reply.Entities,
+		&reply.AuthorID,
+		&reply.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+func (r *ReplyRepository) GetByMessageID(chatID, messageID int64) (*models.Reply, error) {
+	row := r.queue.DB().QueryRow(`
+		SELECT id, chat_id, reply_to_message_id, message_id, text, COALESCE(photo_id, ''), COALESCE(entities, ''), COALESCE(author_id, 0), created_at
+		FROM replies WHERE chat_id = ? AND message_id = ?
+	`, chatID, messageID)
+
+	var reply models.Reply
+	err := row.Scan(
+		&reply.ID,
+		&reply.ChatID,
+		&reply.ReplyToMessageID,
+		&reply.MessageID,
+		&reply.Text,
+		&reply.PhotoID,
+		&reply.Entities,
+		&reply.AuthorID,
 		&reply.CreatedAt,
 	)
 	if err != nil {
@@ -61,7 +87,7 @@ func (r *ReplyRepository) GetByID(id int64) (*models.Reply, error) {
 
 func (r *ReplyRepository) GetAll() ([]*models.Reply, error) {
 	rows, err := r.queue.DB().Query(`
-		SELECT id, chat_id, reply_to_message_id, message_id, text, COALESCE(photo_id, ''), COALESCE(entities, ''), created_at
+		SELECT id, chat_id, reply_to_message_id, message_id, text, COALESCE(photo_id, ''), COALESCE(entities, ''), COALESCE(author_id, 0), created_at
 		FROM replies
 		ORDER BY created_at DESC
 	`)
@@ -81,6 +107,7 @@ func (r *ReplyRepository) GetAll() ([]*models.Reply, error) {
 			&reply.Text,
 			&reply.PhotoID,
 			&reply.Entities,
+			&reply.AuthorID,
 			&reply.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -98,7 +125,7 @@ func (r *ReplyRepository) Count() (int64, error) {
 
 func (r *ReplyRepository) GetPaginated(limit, offset int64) ([]*models.Reply, error) {
 	rows, err := r.queue.DB().Query(`
-		SELECT id, chat_id, reply_to_message_id, message_id, text, COALESCE(photo_id, ''), COALESCE(entities, ''), created_at
+		SELECT id, chat_id, reply_to_message_id, message_id, text, COALESCE(photo_id, ''), COALESCE(entities, ''), COALESCE(author_id, 0), created_at
 		FROM replies
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
@@ -119,6 +146,43 @@ func (r *ReplyRepository) GetPaginated(limit, offset int64) ([]*models.Reply, er
 			&reply.Text,
 			&reply.PhotoID,
 			&reply.Entities,
+			&reply.AuthorID,
+			&reply.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		replies = append(replies, &reply)
+	}
+	return replies, rows.Err()
+}
+
+// GetCreatedBetween returns replies created from from up to, but not
+// including, to, oldest first.
+func (r *ReplyRepository) GetCreatedBetween(from, to time.Time) ([]*models.Reply, error) {
+	const layout = "2006-01-02 15:04:05"
+	rows, err := r.queue.DB().Query(`
+		SELECT id, chat_id, reply_to_message_id, message_id, text, COALESCE(photo_id, ''), COALESCE(entities, ''), COALESCE(author_id, 0), created_at
+		FROM replies
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC
+	`, from.UTC().Format(layout), to.UTC().Format(layout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var replies []*models.Reply
+	for rows.Next() {
+		var reply models.Reply
+		if err := rows.Scan(
+			&reply.ID,
+			&reply.ChatID,
+			&reply.ReplyToMessageID,
+			&reply.MessageID,
+			&reply.Text,
+			&reply.PhotoID,
+			&reply.Entities,
+			&reply.AuthorID,
 			&reply.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -132,11 +196,12 @@ func (r *ReplyRepository) Update(reply *models.Reply) error {
 	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
 		_, err := db.Exec(`
 			UPDATE replies SET
+				message_id = ?,
 				text = ?,
 				photo_id = ?,
 				entities = ?
 			WHERE id = ?
-		`, reply.Text, reply.PhotoID, reply.Entities, reply.ID)
+		`, reply.MessageID, reply.Text, reply.PhotoID, reply.Entities, reply.ID)
 		return nil, err
 	})
 	return err
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+func TestReplyRepositoryGetByMessageID(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+	repo := NewReplyRepository(NewDBQueueForTest(testDB))
+
+	reply := &models.Reply{ChatID: -100, ReplyToMessageID: 10, MessageID: 11, Text: "ответ", PhotoID: "photo"}
+	if err := repo.Create(reply); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Re-sending a reply without its photo moves it to a new message.
+	reply.MessageID = 12
+	reply.PhotoID = ""
+	if err := repo.Update(reply); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if _, err := repo.GetByMessageID(-100, 11); err != sql.ErrNoRows {
+		t.Errorf("Expected the old message ID to be gone, got %v", err)
+	}
+	got, err := repo.GetByMessageID(-100, 12)
+	if err != nil {
+		t.Fatalf("GetByMessageID failed: %v", err)
+	}
+	if got.ID != reply.ID || got.PhotoID != "" || got.ReplyToMessageID != 10 {
+		t.Errorf("Unexpected reply %+v", got)
+	}
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type ReplyTemplateRepository struct {
+	queue *DBQueue
+}
+
+func NewReplyTemplateRepository(queue *DBQueue) *ReplyTemplateRepository {
+	return &ReplyTemplateRepository{queue: queue}
+}
+
+func (r *ReplyTemplateRepository) Create(tpl *models.ReplyTemplate) error {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		res, err := db.Exec(`
+			INSERT INTO reply_templates (name, text, entities, photo_id)
+			VALUES (?, ?, ?, ?)
+		`, tpl.Name, tpl.Text, tpl.Entities, tpl.PhotoID)
+		if err != nil {
+			return nil, err
+		}
+		return res.LastInsertId()
+	})
+	if err != nil {
+		return err
+	}
+	tpl.ID = result.(int64)
+	return nil
+}
+
+func (r *ReplyTemplateRepository) GetByID(id int64) (*models.ReplyTemplate, error) {
+	row := r.queue.DB().QueryRow(`
+		SELECT id, name, text, COALESCE(entities, ''), COALESCE(photo_id, ''), COALESCE(usage_count, 0), created_at
+		FROM reply_templates WHERE id = ?
+	`, id)
+
+	var tpl models.ReplyTemplate
+	err := row.Scan(
+		&tpl.ID,
+		&tpl.Name,
+		&tpl.Text,
+		&tpl.Entities,
+		&tpl.PhotoID,
+		&tpl.UsageCount,
+		&tpl.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// GetAll returns templates with the most used ones first.
+func (r *ReplyTemplateRepository) GetAll() ([]*models.ReplyTemplate, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, name, text, COALESCE(entities, ''), COALESCE(photo_id, ''), COALESCE(usage_count, 0), created_at
+		FROM reply_templates
+		ORDER BY usage_count DESC, name ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.ReplyTemplate
+	for rows.Next() {
+		var tpl models.ReplyTemplate
+		if err := rows.Scan(
+			&tpl.ID,
+			&tpl.Name,
+			&tpl.Text,
+			&tpl.Entities,
+			&tpl.PhotoID,
+			&tpl.UsageCount,
+			&tpl.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		templates = append(templates, &tpl)
+	}
+	return templates, rows.Err()
+}
+
+func (r *ReplyTemplateRepository) Update(tpl *models.ReplyTemplate) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`
+			UPDATE reply_templates SET name = ?, text = ?, entities = ?, photo_id = ?
+			WHERE id = ?
+		`, tpl.Name, tpl.Text, tpl.Entities, tpl.PhotoID, tpl.ID)
+		return nil, err
+	})
+	return err
+}
+
+func (r *ReplyTemplateRepository) IncrementUsage(id int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`UPDATE reply_templates SET usage_count = usage_count + 1 WHERE id = ?`, id)
+		return nil, err
+	})
+	return err
+}
+
+func (r *ReplyTemplateRepository) Delete(id int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`DELETE FROM reply_templates WHERE id = ?`, id)
+		return nil, err
+	})
+	return err
+}
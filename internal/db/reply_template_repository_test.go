@@ -0,0 +1,100 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+func setupReplyTemplateRepo(t *testing.T) *ReplyTemplateRepository {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	t.Cleanup(func() { testDB.Close() })
+
+	if err := InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+	return NewReplyTemplateRepository(NewDBQueueForTest(testDB))
+}
+
+func TestReplyTemplateRepositoryOrdersByUsage(t *testing.T) {
+	repo := setupReplyTemplateRepo(t)
+
+	rules := &models.ReplyTemplate{Name: "Правила", Text: "{name}, прочитайте правила"}
+	search := &models.ReplyTemplate{Name: "Поиск", Text: "Воспользуйтесь поиском", PhotoID: "photo"}
+	for _, tpl := range []*models.ReplyTemplate{rules, search} {
+		if err := repo.Create(tpl); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	if err := repo.IncrementUsage(search.ID); err != nil {
+		t.Fatalf("IncrementUsage failed: %v", err)
+	}
+
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != search.ID || all[0].UsageCount != 1 || all[0].PhotoID != "photo" {
+		t.Fatalf("Expected the used template first, got %+v", all)
+	}
+
+	rules.Text = "Новый текст"
+	if err := repo.Update(rules); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, err := repo.GetByID(rules.ID)
+	if err != nil || got.Text != "Новый текст" {
+		t.Fatalf("Expected updated text, got %+v, %v", got, err)
+	}
+
+	if err := repo.Delete(rules.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(rules.ID); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows after delete, got %v", err)
+	}
+}
+
+func TestMessageAuthorRoundTrip(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+	repo := NewUserRepository(NewDBQueueForTest(testDB))
+
+	if err := repo.SaveMessageAuthor(-100, 5, 42); err != nil {
+		t.Fatalf("SaveMessageAuthor failed: %v", err)
+	}
+	userID, err := repo.GetMessageAuthor(-100, 5)
+	if err != nil || userID != 42 {
+		t.Errorf("Expected author 42, got %d, %v", userID, err)
+	}
+	if _, err := repo.GetMessageAuthor(-100, 6); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for unknown message, got %v", err)
+	}
+
+	if _, err := testDB.Exec(`UPDATE message_authors SET created_at = datetime('now', '-2 days')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveMessageAuthor(-100, 7, 43); err != nil {
+		t.Fatal(err)
+	}
+	n, err := repo.DeleteMessageAuthorsBefore(time.Now().Add(-24 * time.Hour))
+	if err != nil || n != 1 {
+		t.Errorf("DeleteMessageAuthorsBefore() = %d, %v, want 1", n, err)
+	}
+	if _, err := repo.GetMessageAuthor(-100, 7); err != nil {
+		t.Errorf("Expected a recent author to stay, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type SandboxMessageRepository struct {
+	queue *DBQueue
+}
+
+func NewSandboxMessageRepository(queue *DBQueue) *SandboxMessageRepository {
+	return &SandboxMessageRepository{queue: queue}
+}
+
+func (r *SandboxMessageRepository) Add(chatID int64, messageIDs []int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		for _, messageID := range messageIDs {
+			_, err := db.Exec(`
+				INSERT OR IGNORE INTO sandbox_messages (chat_id, message_id)
+				VALUES (?, ?)
+			`, chatID, messageID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (r *SandboxMessageRepository) GetAll() ([]*models.SandboxMessage, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT chat_id, message_id, created_at
+		FROM sandbox_messages
+		ORDER BY chat_id, message_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.SandboxMessage
+	for rows.Next() {
+		var m models.SandboxMessage
+		if err := rows.Scan(&m.ChatID, &m.MessageID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &m)
+	}
+	return messages, rows.Err()
+}
+
+func (r *SandboxMessageRepository) Count() (int, error) {
+	var count int
+	err := r.queue.DB().QueryRow(`SELECT COUNT(*) FROM sandbox_messages`).Scan(&count)
+	return count, err
+}
+
+func (r *SandboxMessageRepository) Delete(chatID, messageID int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`DELETE FROM sandbox_messages WHERE chat_id = ? AND message_id = ?`, chatID, messageID)
+		return nil, err
+	})
+	return err
+}
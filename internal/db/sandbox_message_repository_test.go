@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+func TestSandboxMessageRepository(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer testDB.Close()
+
+	if err := InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+	queue := NewDBQueueForTest(testDB)
+	repo := NewSandboxMessageRepository(queue)
+
+	if err := repo.Add(-100, []int64{10, 11, 12}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	// Recording the same message twice is harmless.
+	if err := repo.Add(-100, []int64{12}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	count, err := repo.Count()
+	if err != nil || count != 3 {
+		t.Fatalf("Expected 3 messages, got %d (err %v)", count, err)
+	}
+
+	if err := repo.Delete(-100, 11); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	messages, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(messages) != 2 || messages[0].MessageID != 10 || messages[1].MessageID != 12 {
+		t.Fatalf("Unexpected messages: %+v", messages)
+	}
+}
+
+func TestAdminConfigSandboxRoundTrip(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer testDB.Close()
+
+	if err := InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+	repo := NewAdminConfigRepository(NewDBQueueForTest(testDB))
+
+	config := &models.AdminConfig{AdminIDs: []int64{1}, ForumChatID: -100, TopicID: 5, SandboxChatID: -200, SandboxTopicID: 7}
+	if err := repo.Save(config); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := repo.Get()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if loaded.SandboxChatID != -200 || loaded.SandboxTopicID != 7 {
+		t.Errorf("Expected sandbox -200/7, got %d/%d", loaded.SandboxChatID, loaded.SandboxTopicID)
+	}
+}
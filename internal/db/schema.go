@@ -65,9 +65,151 @@ CREATE TABLE IF NOT EXISTS replies (
     UNIQUE(chat_id, message_id)
 );
 
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY,
+    first_name TEXT DEFAULT '',
+    last_name TEXT DEFAULT '',
+    username TEXT DEFAULT '',
+    language_code TEXT DEFAULT '',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS admin_pins (
+    user_id INTEGER PRIMARY KEY,
+    pin_hash TEXT NOT NULL,
+    salt TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS admin_signatures (
+    user_id INTEGER PRIMARY KEY,
+    text TEXT NOT NULL,
+    entities TEXT DEFAULT '',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_type_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_type_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    photo_id TEXT DEFAULT '',
+    template TEXT DEFAULT '',
+    template_entities TEXT DEFAULT '',
+    header TEXT DEFAULT '',
+    header_entities TEXT DEFAULT '',
+    footer TEXT DEFAULT '',
+    footer_entities TEXT DEFAULT '',
+    changed_by INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(post_type_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS reply_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    text TEXT NOT NULL DEFAULT '',
+    entities TEXT DEFAULT '',
+    photo_id TEXT DEFAULT '',
+    usage_count INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS message_authors (
+    chat_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    thread_id INTEGER DEFAULT 0,
+    reply_to_message_id INTEGER NOT NULL,
+    post_id INTEGER DEFAULT 0,
+    reply_id INTEGER DEFAULT 0,
+    user_id INTEGER NOT NULL,
+    text TEXT DEFAULT '',
+    is_read INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chat_id, message_id)
+);
+
+CREATE TABLE IF NOT EXISTS tickets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    assigned_to INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ticket_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    direction TEXT NOT NULL,
+    admin_id INTEGER DEFAULT 0,
+    media_type TEXT NOT NULL DEFAULT 'text',
+    text TEXT DEFAULT '',
+    file_id TEXT DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ticket_message_links (
+    chat_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    ticket_id INTEGER NOT NULL,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE TABLE IF NOT EXISTS post_follow_ups (
+    post_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    PRIMARY KEY (post_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS sandbox_messages (
+    chat_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE TABLE IF NOT EXISTS post_type_images (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_type_id INTEGER NOT NULL,
+    photo_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action TEXT NOT NULL,
+    admin_id INTEGER DEFAULT 0,
+    post_type_id INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS uploaded_images (
+    hash TEXT PRIMARY KEY,
+    photo_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE INDEX IF NOT EXISTS idx_published_posts_message ON published_posts(chat_id, message_id);
 CREATE INDEX IF NOT EXISTS idx_post_types_active ON post_types(is_active);
+CREATE INDEX IF NOT EXISTS idx_post_type_images_type ON post_type_images(post_type_id);
 CREATE INDEX IF NOT EXISTS idx_replies_message ON replies(chat_id, message_id);
+CREATE INDEX IF NOT EXISTS idx_comments_post ON comments(post_id);
+CREATE INDEX IF NOT EXISTS idx_comments_reply ON comments(reply_id);
+CREATE INDEX IF NOT EXISTS idx_comments_unread ON comments(is_read);
+CREATE INDEX IF NOT EXISTS idx_tickets_user ON tickets(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_ticket_messages_ticket ON ticket_messages(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_post_events_created ON post_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_message_authors_created ON message_authors(created_at);
 `
 
 const migrations = `
@@ -75,7 +217,36 @@ ALTER TABLE admin_state ADD COLUMN reply_target_chat_id INTEGER DEFAULT 0;
 ALTER TABLE admin_state ADD COLUMN reply_target_message_id INTEGER DEFAULT 0;
 ALTER TABLE published_posts ADD COLUMN user_photo_id TEXT DEFAULT '';
 ALTER TABLE published_posts ADD COLUMN user_photo_message_id INTEGER DEFAULT 0;
-ALTER TABLE admin_state ADD COLUMN draft_user_photo_id TEXT DEFAULT ''
+ALTER TABLE admin_state ADD COLUMN draft_user_photo_id TEXT DEFAULT '';
+ALTER TABLE published_posts ADD COLUMN author_id INTEGER DEFAULT 0;
+ALTER TABLE replies ADD COLUMN author_id INTEGER DEFAULT 0;
+ALTER TABLE admin_state ADD COLUMN reply_template_id INTEGER DEFAULT 0;
+ALTER TABLE post_types ADD COLUMN delivery_options TEXT DEFAULT '';
+ALTER TABLE published_posts ADD COLUMN delivery_options TEXT DEFAULT '';
+ALTER TABLE admin_state ADD COLUMN draft_options TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN photo_url TEXT DEFAULT '';
+ALTER TABLE published_posts ADD COLUMN preview_url TEXT DEFAULT '';
+ALTER TABLE admin_state ADD COLUMN draft_layout TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN input_mode TEXT DEFAULT '';
+ALTER TABLE admin_state ADD COLUMN draft_topic_id INTEGER DEFAULT 0;
+ALTER TABLE post_types ADD COLUMN is_archive BOOLEAN DEFAULT FALSE;
+ALTER TABLE post_types ADD COLUMN sort_order INTEGER DEFAULT 0;
+ALTER TABLE post_types ADD COLUMN category TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN allowed_admins TEXT DEFAULT '';
+ALTER TABLE admin_state ADD COLUMN type_selection TEXT DEFAULT '';
+ALTER TABLE admin_state ADD COLUMN import_file_id TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN image_strategy TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN image_cursor INTEGER DEFAULT 0;
+ALTER TABLE post_types ADD COLUMN header TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN header_entities TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN footer TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN footer_entities TEXT DEFAULT '';
+ALTER TABLE published_posts ADD COLUMN frame TEXT DEFAULT '';
+ALTER TABLE admin_state ADD COLUMN draft_frame TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN counter_format TEXT DEFAULT '';
+ALTER TABLE post_types ADD COLUMN counter_next INTEGER DEFAULT 1;
+ALTER TABLE published_posts ADD COLUMN number INTEGER DEFAULT 0;
+ALTER TABLE published_posts ADD COLUMN type_version INTEGER DEFAULT 0
 `
 
 func InitSchema(db *sql.DB) error {
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type TicketRepository struct {
+	queue *DBQueue
+}
+
+func NewTicketRepository(queue *DBQueue) *TicketRepository {
+	return &TicketRepository{queue: queue}
+}
+
+func (r *TicketRepository) Create(ticket *models.Ticket) error {
+	if ticket.Status == "" {
+		ticket.Status = models.TicketStatusOpen
+	}
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		res, err := db.Exec(`
+			INSERT INTO tickets (user_id, status, assigned_to)
+			VALUES (?, ?, ?)
+		`, ticket.UserID, ticket.Status, ticket.AssignedTo)
+		if err != nil {
+			return nil, err
+		}
+		return res.LastInsertId()
+	})
+	if err != nil {
+		return err
+	}
+	ticket.ID = result.(int64)
+	return nil
+}
+
+func (r *TicketRepository) GetByID(id int64) (*models.Ticket, error) {
+	row := r.queue.DB().QueryRow(`
+		SELECT id, user_id, status, COALESCE(assigned_to, 0), created_at, updated_at
+		FROM tickets WHERE id = ?
+	`, id)
+
+	var ticket models.Ticket
+	err := row.Scan(
+		&ticket.ID,
+		&ticket.UserID,
+		&ticket.Status,
+		&ticket.AssignedTo,
+		&ticket.CreatedAt,
+		&ticket.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// GetOpenByUser returns the user's latest open ticket.
+func (r *TicketRepository) GetOpenByUser(userID int64) (*models.Ticket, error) {
+	row := r.queue.DB().QueryRow(`
+		SELECT id, user_id, status, COALESCE(assigned_to, 0), created_at, updated_at
+		FROM tickets WHERE user_id = ? AND status = ?
+		ORDER BY id DESC LIMIT 1
+	`, userID, models.TicketStatusOpen)
+
+	var ticket models.Ticket
+	err := row.Scan(
+		&ticket.ID,
+		&ticket.UserID,
+		&ticket.Status,
+		&ticket.AssignedTo,
+		&ticket.CreatedAt,
+		&ticket.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// GetPaginated returns tickets with the given status, most recently updated
+// first.
+func (r *TicketRepository) GetPaginated(status string, limit, offset int64) ([]*models.Ticket, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, user_id, status, COALESCE(assigned_to, 0), created_at, updated_at
+		FROM tickets
+		WHERE status = ?
+		ORDER BY updated_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []*models.Ticket
+	for rows.Next() {
+		var ticket models.Ticket
+		if err := rows.Scan(
+			&ticket.ID,
+			&ticket.UserID,
+			&ticket.Status,
+			&ticket.AssignedTo,
+			&ticket.CreatedAt,
+			&ticket.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, &ticket)
+	}
+	return tickets, rows.Err()
+}
+
+func (r *TicketRepository) CountByStatus(status string) (int64, error) {
+	var count int64
+	err := r.queue.DB().QueryRow(`SELECT COUNT(*) FROM tickets WHERE status = ?`, status).Scan(&count)
+	return count, err
+}
+
+func (r *TicketRepository) SetStatus(id int64, status string) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`UPDATE tickets SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, status, id)
+		return nil, err
+	})
+	return err
+}
+
+func (r *TicketRepository) Assign(id, adminID int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`UPDATE tickets SET assigned_to = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, adminID, id)
+		return nil, err
+	})
+	return err
+}
+
+// AddMessage stores a ticket message and bumps the ticket's updated_at.
+func (r *TicketRepository) AddMessage(msg *models.TicketMessage) error {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		res, err := db.Exec(`
+			INSERT INTO ticket_messages (ticket_id, direction, admin_id, media_type, text, file_id)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, msg.TicketID, msg.Direction, msg.AdminID, msg.MediaType, msg.Text, msg.FileID)
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := db.Exec(`UPDATE tickets SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, msg.TicketID); err != nil {
+			return nil, err
+		}
+		return id, nil
+	})
+	if err != nil {
+		return err
+	}
+	msg.ID = result.(int64)
+	return nil
+}
+
+// GetMessages returns the last limit messages of a ticket in chronological
+// order.
+func (r *TicketRepository) GetMessages(ticketID, limit int64) ([]*models.TicketMessage, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, ticket_id, direction, COALESCE(admin_id, 0), media_type, COALESCE(text, ''), COALESCE(file_id, ''), created_at
+		FROM (
+			SELECT * FROM ticket_messages WHERE ticket_id = ? ORDER BY id DESC LIMIT ?
+		)
+		ORDER BY id ASC
+	`, ticketID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.TicketMessage
+	for rows.Next() {
+		var msg models.TicketMessage
+		if err := rows.Scan(
+			&msg.ID,
+			&msg.TicketID,
+			&msg.Direction,
+			&msg.AdminID,
+			&msg.MediaType,
+			&msg.Text,
+			&msg.FileID,
+			&msg.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, rows.Err()
+}
+
+// LinkMessage remembers that a message in an admin chat belongs to a ticket
+// so that replying to it answers the ticket.
+func (r *TicketRepository) LinkMessage(chatID, messageID, ticketID int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`
+			INSERT OR REPLACE INTO ticket_message_links (chat_id, message_id, ticket_id)
+			VALUES (?, ?, ?)
+		`, chatID, messageID, ticketID)
+		return nil, err
+	})
+	return err
+}
+
+func (r *TicketRepository) GetLinkedTicketID(chatID, messageID int64) (int64, error) {
+	var ticketID int64
+	err := r.queue.DB().QueryRow(`
+		SELECT ticket_id FROM ticket_message_links WHERE chat_id = ? AND message_id = ?
+	`, chatID, messageID).Scan(&ticketID)
+	return ticketID, err
+}
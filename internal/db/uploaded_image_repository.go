@@ -0,0 +1,38 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// UploadedImageRepository remembers the file IDs of images uploaded from
+// local files, by the SHA-256 of the file, so an unchanged file is not
+// uploaded again.
+type UploadedImageRepository struct {
+	queue *DBQueue
+}
+
+func NewUploadedImageRepository(queue *DBQueue) *UploadedImageRepository {
+	return &UploadedImageRepository{queue: queue}
+}
+
+// Get returns the file ID of the image with the hash, "" when it was never
+// uploaded.
+func (r *UploadedImageRepository) Get(hash string) (string, error) {
+	var photoID string
+	err := r.queue.DB().QueryRow(`SELECT photo_id FROM uploaded_images WHERE hash = ?`, hash).Scan(&photoID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return photoID, err
+}
+
+func (r *UploadedImageRepository) Set(hash, photoID string) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`
+			INSERT INTO uploaded_images (hash, photo_id) VALUES (?, ?)
+			ON CONFLICT(hash) DO UPDATE SET photo_id = excluded.photo_id, created_at = CURRENT_TIMESTAMP
+		`, hash, photoID)
+		return nil, err
+	})
+	return err
+}
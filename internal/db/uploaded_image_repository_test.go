@@ -0,0 +1,30 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestUploadedImageRepository(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+	if err := InitSchema(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewUploadedImageRepository(NewDBQueueForTest(testDB))
+	if photoID, err := repo.Get("abc"); err != nil || photoID != "" {
+		t.Fatalf("Get() of an unknown hash = %q, %v, want empty", photoID, err)
+	}
+	for _, photoID := range []string{"photo-1", "photo-2"} {
+		if err := repo.Set("abc", photoID); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	if photoID, err := repo.Get("abc"); err != nil || photoID != "photo-2" {
+		t.Errorf("Get() = %q, %v, want photo-2", photoID, err)
+	}
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+type UserRepository struct {
+	queue *DBQueue
+}
+
+func NewUserRepository(queue *DBQueue) *UserRepository {
+	return &UserRepository{queue: queue}
+}
+
+func (r *UserRepository) Upsert(user *models.User) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`
+			INSERT INTO users (id, first_name, last_name, username, language_code, updated_at)
+			VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(id) DO UPDATE SET
+				first_name = excluded.first_name,
+				last_name = excluded.last_name,
+				username = excluded.username,
+				language_code = CASE WHEN excluded.language_code != '' THEN excluded.language_code ELSE users.language_code END,
+				updated_at = CURRENT_TIMESTAMP
+		`, user.ID, user.FirstName, user.LastName, user.Username, user.LanguageCode)
+		return nil, err
+	})
+	return err
+}
+
+func (r *UserRepository) GetByID(id int64) (*models.User, error) {
+	row := r.queue.DB().QueryRow(`
+		SELECT id, COALESCE(first_name, ''), COALESCE(last_name, ''), COALESCE(username, ''), COALESCE(language_code, ''), updated_at
+		FROM users WHERE id = ?
+	`, id)
+
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.Username,
+		&user.LanguageCode,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) GetAll() ([]*models.User, error) {
+	rows, err := r.queue.DB().Query(`
+		SELECT id, COALESCE(first_name, ''), COALESCE(last_name, ''), COALESCE(username, ''), COALESCE(language_code, ''), updated_at
+		FROM users
+		ORDER BY updated_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(
+			&user.ID,
+			&user.FirstName,
+			&user.LastName,
+			&user.Username,
+			&user.LanguageCode,
+			&user.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+// SaveMessageAuthor remembers who sent a group message so replies to it can
+// address the author by name.
+func (r *UserRepository) SaveMessageAuthor(chatID, messageID, userID int64) error {
+	_, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		_, err := db.Exec(`
+			INSERT OR REPLACE INTO message_authors (chat_id, message_id, user_id)
+			VALUES (?, ?, ?)
+		`, chatID, messageID, userID)
+		return nil, err
+	})
+	return err
+}
+
+// DeleteMessageAuthorsBefore forgets authors of messages stored before t and
+// returns how many were removed.
+func (r *UserRepository) DeleteMessageAuthorsBefore(t time.Time) (int64, error) {
+	result, err := r.queue.Execute(func(db *sql.DB) (interface{}, error) {
+		res, err := db.Exec(`DELETE FROM message_authors WHERE created_at < ?`, t.UTC().Format("2006-01-02 15:04:05"))
+		if err != nil {
+			return nil, err
+		}
+		return res.RowsAffected()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+func (r *UserRepository) GetMessageAuthor(chatID, messageID int64) (int64, error) {
+	var userID int64
+	err := r.queue.DB().QueryRow(`
+		SELECT user_id FROM message_authors WHERE chat_id = ? AND message_id = ?
+	`, chatID, messageID).Scan(&userID)
+	return userID, err
+}
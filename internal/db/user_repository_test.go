@@ -0,0 +1,63 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	_ "modernc.org/sqlite"
+	"pgregory.net/rapid"
+)
+
+func TestUserRepositoryUpsertRoundTrip(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		testDB, err := sql.Open("sqlite", ":memory:")
+		if err != nil {
+			rt.Fatal(err)
+		}
+		defer testDB.Close()
+
+		if err := InitSchema(testDB); err != nil {
+			rt.Fatal(err)
+		}
+
+		repo := NewUserRepository(NewDBQueueForTest(testDB))
+
+		user := &models.User{
+			ID:           rapid.Int64Range(1, 1000000).Draw(rt, "id"),
+			FirstName:    rapid.String().Draw(rt, "firstName"),
+			LastName:     rapid.String().Draw(rt, "lastName"),
+			Username:     rapid.StringMatching(`[a-z0-9_]{0,16}`).Draw(rt, "username"),
+			LanguageCode: "ru",
+		}
+		if err := repo.Upsert(user); err != nil {
+			rt.Fatal(err)
+		}
+
+		// A later update without a language code keeps the stored one.
+		user.FirstName = rapid.String().Draw(rt, "newFirstName")
+		user.LanguageCode = ""
+		if err := repo.Upsert(user); err != nil {
+			rt.Fatal(err)
+		}
+
+		got, err := repo.GetByID(user.ID)
+		if err != nil {
+			rt.Fatal(err)
+		}
+		if got.FirstName != user.FirstName || got.LastName != user.LastName || got.Username != user.Username {
+			rt.Fatalf("Expected %+v, got %+v", user, got)
+		}
+		if got.LanguageCode != "ru" {
+			rt.Fatalf("Expected language code to be preserved, got %q", got.LanguageCode)
+		}
+
+		all, err := repo.GetAll()
+		if err != nil {
+			rt.Fatal(err)
+		}
+		if len(all) != 1 {
+			rt.Fatalf("Expected 1 user, got %d", len(all))
+		}
+	})
+}
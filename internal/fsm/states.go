@@ -8,6 +8,12 @@ package fsm
 //   StateAdminMenu -> StateNewPostSelectType (via /new command)
 //   StateNewPostSelectType -> StateNewPostEnterText (via type selection)
 //   StateNewPostEnterText -> StateNewPostConfirm (via text input)
+//   StateNewPostEnterText -> StateNewPostChooseLayout (via text too long for a photo caption)
+//   StateNewPostChooseLayout -> StateNewPostConfirm (via layout selection)
+//   StateNewPostConfirm -> StateNewPostConfirm (via test publication to the sandbox or type change)
+//   StateNewPostConfirm -> StateNewPostEditText/StateNewPostEnterTopic (via draft text or topic change)
+//   StateNewPostEditText/StateNewPostEnterTopic -> StateNewPostConfirm (via input or back)
+//   StateAdminMenu -> StateNewPostConfirm (via post details -> duplicate)
 //   StateNewPostConfirm -> StateAdminMenu (via confirmation or /cancel)
 //
 // Post Editing Flow:
@@ -15,10 +21,23 @@ package fsm
 //   StateEditPostEnterLink -> StateEditPostEnterText (via valid link)
 //   StateEditPostEnterText -> StateAdminMenu (via text input or /cancel)
 //
+// Post Search Flow:
+//   StateAdminMenu -> StatePostSearch (via post list -> search by number)
+//   StatePostSearch -> StateEditPostSelectEdit (via number of a single post) or StateAdminMenu (via /cancel)
+//
 // Post Deletion Flow:
 //   StateAdminMenu -> StateDeletePostEnterLink (via /delete command)
 //   StateDeletePostEnterLink -> StateAdminMenu (via link input or /cancel)
 //
+// Reply Editing Flow:
+//   StateAdminMenu -> StateEditReplyEnterLink (via /editreply without a link)
+//   StateEditReplyEnterLink -> StateEditReplyEnterText (via valid link)
+//   StateEditReplyEnterText -> StateAdminMenu (via text/photo input, photo removal or /cancel)
+//
+// Reply Deletion Flow:
+//   StateAdminMenu -> StateDeleteReplyEnterLink (via /deletereply without a link)
+//   StateDeleteReplyEnterLink -> StateAdminMenu (via link input and confirmation or /cancel)
+//
 // Type Creation Flow:
 //   StateAdminMenu -> StateNewTypeEnterName (via settings -> new type)
 //   StateNewTypeEnterName -> StateNewTypeEnterImage (via name input)
@@ -27,13 +46,36 @@ package fsm
 //
 // Type Management Flow:
 //   StateAdminMenu -> StateManageTypes (via settings -> manage types)
-//   StateManageTypes -> StateEditTypeName/StateEditTypeImage/StateEditTypeTemplate (via type selection)
+//   StateManageTypes -> StateEditTypeName/StateEditTypeImage/StateEditTypeTemplate/StateEditTypePhotoURL/StateEditTypeCategory/StateEditTypeHeader/StateEditTypeFooter/StateEditTypeCounterFormat/StateEditTypeCounterNext (via type selection)
 //   StateEditType* -> StateManageTypes (via input or /cancel)
+//   StateManageTypes -> StateExportTypes (via export; type selection is kept in the state)
+//   StateExportTypes -> StateManageTypes (via export or back)
+//   StateManageTypes -> StateImportTypes (via import)
+//   StateImportTypes -> StateImportTypesConflict (via ZIP upload with taken names)
+//   StateImportTypes/StateImportTypesConflict -> StateAdminMenu (via import or /cancel)
+//   StateManageTypes -> StateAddTypeImage (via image pool -> add; stays there for every photo)
+//   StateAddTypeImage -> StateManageTypes (via "done" or /cancel)
+//   StateManageTypes -> StateRerenderRange (via versions -> apply to posts -> range)
+//   StateRerenderRange -> StateAdminMenu (via range input or /cancel)
 //
 // Access Settings Flow:
 //   StateAdminMenu -> StateAccessSettings (via settings -> access settings)
-//   StateAccessSettings -> StateEditAdminIDs/StateEditForumID/StateEditTopicID (via setting selection)
+//   StateAccessSettings -> StateEditAdminIDs/StateEditForumID/StateEditTopicID/StateEditSandbox (via setting selection)
+//   StateAccessSettings -> StateAddAdminID (via admin list -> add admin)
 //   StateEdit* -> StateAccessSettings (via input or /cancel)
+//   StateAdminMenu -> StateSetPIN (via settings -> PIN -> set PIN)
+//   StateAdminMenu -> StateEditSignature (via settings -> signature -> edit)
+//   StateEditSignature -> StateAdminMenu (via input or /cancel)
+//
+// Statistics Flow:
+//   StateAdminMenu -> StateStatsRange (via settings -> statistics -> custom range)
+//   StateStatsRange -> StateAdminMenu (via range input or /cancel)
+//
+// Reply Template Flow:
+//   StateAdminMenu -> StateNewReplyTemplateName (via settings -> reply templates -> new)
+//   StateNewReplyTemplateName -> StateNewReplyTemplateText (via name input)
+//   StateNewReplyTemplateText -> StateAdminMenu (via text input or /cancel)
+//   StateReplyEnterText -> StateReplyConfirm (via template selection)
 //
 // Cancel Command:
 //   Any state -> StateAdminMenu (via /cancel command)
@@ -85,12 +127,17 @@ const (
 	StateReplyEnterText     = "reply_enter_text"
 	StateReplyConfirm       = "reply_confirm"
 	StateEditReplyEnterText = "edit_reply_enter_text"
+	StateEditReplyEnterLink   = "edit_reply_enter_link"
+	StateDeleteReplyEnterLink = "delete_reply_enter_link"
 
 	// Forum Post Manager States
 	StateNewPostSelectType    = "new_post_select_type"
 	StateNewPostEnterText     = "new_post_enter_text"
 	StateNewPostEnterPhoto    = "new_post_enter_photo"
 	StateNewPostConfirm       = "new_post_confirm"
+	StateNewPostChooseLayout  = "new_post_choose_layout"
+	StateNewPostEditText      = "new_post_edit_text"
+	StateNewPostEnterTopic    = "new_post_enter_topic"
 	StateEditPostEnterLink    = "edit_post_enter_link"
 	StateEditPostSelectEdit      = "edit_post_select_edit"
 	StateEditPostEnterText       = "edit_post_enter_text"
@@ -107,8 +154,31 @@ const (
 	StateEditTypeEmoji        = "edit_type_emoji"
 	StateEditTypeImage        = "edit_type_image"
 	StateEditTypeTemplate     = "edit_type_template"
+	StateEditTypePhotoURL     = "edit_type_photo_url"
+	StateEditTypeCategory     = "edit_type_category"
+	StateExportTypes          = "export_types"
+	StateImportTypes          = "import_types"
+	StateImportTypesConflict  = "import_types_conflict"
+	StateAddTypeImage         = "add_type_image"
+	StateEditTypeHeader       = "edit_type_header"
+	StateEditTypeFooter       = "edit_type_footer"
+	StateEditSignature        = "edit_signature"
+	StateEditTypeCounterFormat = "edit_type_counter_format"
+	StateEditTypeCounterNext   = "edit_type_counter_next"
+	StatePostSearch            = "post_search"
+	StateRerenderRange         = "rerender_range"
+	StateStatsRange            = "stats_range"
 	StateAccessSettings       = "access_settings"
 	StateEditAdminIDs         = "edit_admin_ids"
+	StateAddAdminID           = "add_admin_id"
 	StateEditForumID          = "edit_forum_id"
 	StateEditTopicID          = "edit_topic_id"
+	StateEditSandbox          = "edit_sandbox"
+	StateSetPIN               = "set_pin"
+
+	// Reply Template States
+	StateNewReplyTemplateName  = "new_reply_template_name"
+	StateNewReplyTemplateText  = "new_reply_template_text"
+	StateEditReplyTemplateName = "edit_reply_template_name"
+	StateEditReplyTemplateText = "edit_reply_template_text"
 )
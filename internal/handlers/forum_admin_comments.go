@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+const (
+	commentQueuePageSize  = 20
+	commentPreviewLength  = 30
+	commentNotifyMaxRunes = 1000
+)
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+// HandleComment stores forum replies to the bot's messages and notifies
+// admins. It returns true when the message was a comment.
+func (h *ForumAdminHandler) HandleComment(ctx context.Context, msg *tgmodels.Message) bool {
+	if msg.Chat.Type == tgmodels.ChatTypePrivate {
+		return false
+	}
+
+	comment, err := h.commentManager.Capture(msg)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to capture comment: %v", err)
+		return false
+	}
+	if comment == nil {
+		return false
+	}
+
+	log.Printf("[FORUM_ADMIN] Comment %d from user %d on message %d", comment.ID, comment.UserID, comment.ReplyToMessageID)
+	h.notifyAdminsAboutComment(ctx, comment)
+	return true
+}
+
+func (h *ForumAdminHandler) commentTargetLabel(comment *models.Comment) string {
+	if comment.PostID != 0 {
+		return fmt.Sprintf("посту #%d", comment.PostID)
+	}
+	return fmt.Sprintf("ответу #%d", comment.ReplyID)
+}
+
+// commentMessage renders a comment with a mention of its author.
+func (h *ForumAdminHandler) commentMessage(header string, comment *models.Comment) (string, []tgmodels.MessageEntity) {
+	text, mention := h.userManager.Mention(header+"\nОт: ", comment.UserID)
+	text += fmt.Sprintf("\nДата: %s\n\n%s", comment.CreatedAt.Format("02.01.2006 15:04"), truncateRunes(comment.Text, commentNotifyMaxRunes))
+	return text, []tgmodels.MessageEntity{mention}
+}
+
+// commentKeyboard builds buttons for a comment notification or, with inQueue,
+// for the comment opened from the unread queue.
+func commentKeyboard(comment *models.Comment, inQueue bool) *tgmodels.InlineKeyboardMarkup {
+	firstRow := []tgmodels.InlineKeyboardButton{
+		{Text: "↩️ Ответить", CallbackData: fmt.Sprintf("comment_reply:%d", comment.ID)},
+	}
+	if link := services.MessageLink(comment.ChatID, comment.ThreadID, comment.MessageID); link != "" {
+		firstRow = append(firstRow, tgmodels.InlineKeyboardButton{Text: "🔗 Открыть", URL: link})
+	}
+	rows := [][]tgmodels.InlineKeyboardButton{firstRow}
+	readCallback := "comment_read:"
+	if inQueue {
+		readCallback = "comment_read_queue:"
+	}
+	if !comment.IsRead {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: "✅ Прочитано", CallbackData: fmt.Sprintf("%s%d", readCallback, comment.ID)},
+		})
+	}
+	if inQueue {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "admin_comments"}})
+	}
+	return &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func (h *ForumAdminHandler) notifyAdminsAboutComment(ctx context.Context, comment *models.Comment) {
+	admins, err := h.settingsManager.GetAdmins()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get admins: %v", err)
+		return
+	}
+
+	// The stored row has the database timestamp.
+	if stored, err := h.commentRepo.GetByID(comment.ID); err == nil {
+		comment = stored
+	}
+
+	text, entities := h.commentMessage("💬 Новый комментарий к "+h.commentTargetLabel(comment), comment)
+	keyboard := commentKeyboard(comment, false)
+	for _, adminID := range admins {
+		_, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      adminID,
+			Text:        text,
+			Entities:    entities,
+			ReplyMarkup: keyboard,
+		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to notify admin %d about comment %d: %v", adminID, comment.ID, err)
+		}
+	}
+}
+
+func (h *ForumAdminHandler) showCommentQueue(ctx context.Context, chatID int64, messageID int) {
+	comments, err := h.commentRepo.GetUnread(commentQueuePageSize)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get unread comments: %v", err)
+		return
+	}
+	total, err := h.commentRepo.CountUnread()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to count unread comments: %v", err)
+		return
+	}
+
+	text := "Непрочитанных комментариев нет"
+	if total > 0 {
+		text = fmt.Sprintf("📥 Непрочитанные комментарии: %d", total)
+		if total > int64(len(comments)) {
+			text += fmt.Sprintf("\nПоказаны первые %d", len(comments))
+		}
+	}
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, c := range comments {
+		label := fmt.Sprintf("%s — %s: %s",
+			c.CreatedAt.Format("02.01 15:04"),
+			h.userManager.DisplayName(c.UserID),
+			truncateRunes(strings.TrimSpace(c.Text), commentPreviewLength))
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: label, CallbackData: fmt.Sprintf("comment_details:%d", c.ID)},
+		})
+	}
+	if total > 0 {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "✅ Прочитать все", CallbackData: "comments_read_all"}})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "post_list_back"}})
+	keyboard := &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
+
+	if messageID > 0 {
+		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			ReplyMarkup: keyboard,
+		})
+		if err == nil {
+			return
+		}
+	}
+	_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to show comment queue: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) showCommentDetails(ctx context.Context, chatID int64, messageID int, commentID int64) {
+	comment, err := h.commentRepo.GetByID(commentID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get comment %d: %v", commentID, err)
+		h.showCommentQueue(ctx, chatID, messageID)
+		return
+	}
+
+	text, entities := h.commentMessage("💬 Комментарий к "+h.commentTargetLabel(comment), comment)
+	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		Entities:    entities,
+		ReplyMarkup: commentKeyboard(comment, true),
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to show comment: %v", err)
+	}
+}
+
+// handleCommentRead marks a comment as read. In a notification only the
+// buttons change; in the queue the queue is shown again.
+func (h *ForumAdminHandler) handleCommentRead(ctx context.Context, chatID int64, messageID int, commentID int64, inQueue bool) {
+	if err := h.commentRepo.MarkRead(commentID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to mark comment %d as read: %v", commentID, err)
+		return
+	}
+
+	if inQueue {
+		h.showCommentQueue(ctx, chatID, messageID)
+		return
+	}
+
+	comment, err := h.commentRepo.GetByID(commentID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get comment %d: %v", commentID, err)
+		return
+	}
+	_, err = h.bot.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		ReplyMarkup: commentKeyboard(comment, false),
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update comment buttons: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleCommentsReadAll(ctx context.Context, userID, chatID int64, messageID int) {
+	if err := h.commentRepo.MarkAllRead(); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to mark comments as read: %v", err)
+		return
+	}
+	log.Printf("[FORUM_ADMIN] All comments marked as read by user %d", userID)
+	h.showCommentQueue(ctx, chatID, messageID)
+}
+
+// handleCommentReply starts the reply flow with the comment as the target.
+func (h *ForumAdminHandler) handleCommentReply(ctx context.Context, userID, chatID int64, commentID int64) {
+	comment, err := h.commentRepo.GetByID(commentID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get comment %d: %v", commentID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Комментарий не найден"})
+		return
+	}
+
+	if err := h.commentRepo.MarkRead(comment.ID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to mark comment %d as read: %v", comment.ID, err)
+	}
+
+	state := &models.AdminState{
+		UserID:               userID,
+		CurrentState:         fsm.StateReplyEnterText,
+		ReplyTargetChatID:    comment.ChatID,
+		ReplyTargetMessageID: comment.MessageID,
+		TempName:             strconv.FormatInt(comment.ThreadID, 10),
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	h.sendReplyTextPrompt(ctx, chatID, state)
+}
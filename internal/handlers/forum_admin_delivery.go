@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// Post kinds a delivery option applies to.
+const (
+	deliveryAny = iota
+	deliveryMedia
+	deliveryText
+)
+
+type deliveryOption struct {
+	key   string
+	label string
+	kind  int
+	field func(*models.DeliveryOptions) *bool
+}
+
+var deliveryOptions = []deliveryOption{
+	{"silent", "🔕 Без звука", deliveryAny, func(o *models.DeliveryOptions) *bool { return &o.Silent }},
+	{"protected", "🔒 Без пересылки", deliveryAny, func(o *models.DeliveryOptions) *bool { return &o.Protected }},
+	{"spoiler", "🫥 Спойлер", deliveryMedia, func(o *models.DeliveryOptions) *bool { return &o.Spoiler }},
+	{"caption_above", "⬆️ Подпись сверху", deliveryMedia, func(o *models.DeliveryOptions) *bool { return &o.CaptionAboveMedia }},
+	{"no_preview", "🚫 Без превью", deliveryText, func(o *models.DeliveryOptions) *bool { return &o.NoLinkPreview }},
+	{"large_preview", "🔍 Крупное превью", deliveryText, func(o *models.DeliveryOptions) *bool { return &o.LargePreview }},
+	{"preview_above", "⬆️ Превью сверху", deliveryText, func(o *models.DeliveryOptions) *bool { return &o.PreviewAboveText }},
+}
+
+func (o deliveryOption) appliesTo(kind int) bool {
+	return o.kind == deliveryAny || kind == deliveryAny || o.kind == kind
+}
+
+func postKind(hasMedia bool) int {
+	if hasMedia {
+		return deliveryMedia
+	}
+	return deliveryText
+}
+
+// toggleDeliveryOption flips the option with the given key. It returns false
+// for an unknown key.
+func toggleDeliveryOption(opts *models.DeliveryOptions, key string) bool {
+	for _, o := range deliveryOptions {
+		if o.key == key {
+			v := o.field(opts)
+			*v = !*v
+			return true
+		}
+	}
+	return false
+}
+
+// deliveryOptionRows renders option toggles two per row. Each button's
+// callback is callbackPrefix followed by the option key.
+func deliveryOptionRows(opts models.DeliveryOptions, kind int, callbackPrefix string) [][]tgmodels.InlineKeyboardButton {
+	var rows [][]tgmodels.InlineKeyboardButton
+	var row []tgmodels.InlineKeyboardButton
+	for _, o := range deliveryOptions {
+		if !o.appliesTo(kind) {
+			continue
+		}
+		mark := "▫️ "
+		if *o.field(&opts) {
+			mark = "✅ "
+		}
+		row = append(row, tgmodels.InlineKeyboardButton{Text: mark + o.label, CallbackData: callbackPrefix + o.key})
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// deliveryOptionsSummary lists the enabled options, or "по умолчанию".
+func deliveryOptionsSummary(opts models.DeliveryOptions, kind int) string {
+	var labels []string
+	for _, o := range deliveryOptions {
+		if o.appliesTo(kind) && *o.field(&opts) {
+			labels = append(labels, o.label)
+		}
+	}
+	if len(labels) == 0 {
+		return "по умолчанию"
+	}
+	return strings.Join(labels, ", ")
+}
+
+func linkPreviewOptions(opts models.DeliveryOptions) *tgmodels.LinkPreviewOptions {
+	if !opts.NoLinkPreview && !opts.LargePreview && !opts.PreviewAboveText {
+		return nil
+	}
+	if opts.NoLinkPreview {
+		return &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()}
+	}
+	preview := &tgmodels.LinkPreviewOptions{}
+	if opts.LargePreview {
+		preview.PreferLargeMedia = bot.True()
+	}
+	if opts.PreviewAboveText {
+		preview.ShowAboveText = bot.True()
+	}
+	return preview
+}
+
+// captionPhotoID returns the photo of the message that carries the post's
+// caption.
+func captionPhotoID(post *models.PublishedPost) string {
+	if post.PhotoID != "" {
+		return post.PhotoID
+	}
+	return post.UserPhotoID
+}
+
+// postConfirmKeyboard builds the keyboard of the new post preview. withTest
+// adds the button publishing the draft to the sandbox.
+func postConfirmKeyboard(state *models.AdminState, confirmLabel string, withTest, withOtherImage bool) *tgmodels.InlineKeyboardMarkup {
+	addPhotoLabel := "📸 Добавить фото"
+	if state.DraftUserPhotoID != "" {
+		addPhotoLabel = "📸 Изменить фото"
+	}
+
+	hasMedia := (state.DraftPhotoID != "" && state.DraftLayout != models.PostLayoutPreview) || state.DraftUserPhotoID != ""
+	rows := [][]tgmodels.InlineKeyboardButton{
+		{{Text: confirmLabel, CallbackData: "confirm_post"}},
+		{{Text: addPhotoLabel, CallbackData: "post_add_photo"}},
+	}
+	if withOtherImage {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "🎲 Другое изображение", CallbackData: "post_other_image"}})
+	}
+	if withTest {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "🧪 Отправить тест", CallbackData: "post_test"}})
+	}
+	topicLabel := "📍 Топик: по умолчанию"
+	if state.DraftTopicID != 0 {
+		topicLabel = fmt.Sprintf("📍 Топик: %d", state.DraftTopicID)
+	}
+	rows = append(rows,
+		[]tgmodels.InlineKeyboardButton{
+			{Text: "✏️ Текст", CallbackData: "draft_edit_text"},
+			{Text: "🔄 Тип", CallbackData: "draft_change_type"},
+		},
+		[]tgmodels.InlineKeyboardButton{{Text: topicLabel, CallbackData: "draft_topic"}},
+	)
+	rows = append(rows, deliveryOptionRows(models.ParseDeliveryOptions(state.DraftOptions), postKind(hasMedia), "post_opt:")...)
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "❌ Отмена", CallbackData: "cancel"}})
+	return &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func (h *ForumAdminHandler) handlePostOptionToggle(ctx context.Context, userID, chatID int64, messageID int, key string) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateNewPostConfirm {
+		log.Printf("[FORUM_ADMIN] Invalid state for post option toggle: %v", err)
+		return
+	}
+
+	opts := models.ParseDeliveryOptions(state.DraftOptions)
+	if !toggleDeliveryOption(&opts, key) {
+		return
+	}
+	state.DraftOptions = opts.String()
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	confirmLabel := "✅ Подтвердить"
+	if state.DraftUserPhotoID != "" {
+		confirmLabel = "✅ Опубликовать"
+	}
+	_, err = h.bot.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		ReplyMarkup: postConfirmKeyboard(state, confirmLabel, h.sandboxEnabled(), h.canSwapDraftImage(state)),
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post options: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) showTypeDeliveryOptions(ctx context.Context, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	rows := deliveryOptionRows(models.ParseDeliveryOptions(postType.DeliveryOptions), deliveryAny, fmt.Sprintf("type_opt:%d:", typeID))
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: fmt.Sprintf("manage_type:%d", typeID)}})
+
+	text := fmt.Sprintf("Параметры отправки для типа \"%s\"\n\nЭти значения выбраны по умолчанию для новых постов; перед публикацией их можно изменить. Спойлер и подпись сверху действуют для постов с фото, настройки превью — для текстовых.", postType.Name)
+	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to show type options: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleTypeOptionToggle(ctx context.Context, userID, chatID int64, messageID int, typeID int64, key string) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	opts := models.ParseDeliveryOptions(postType.DeliveryOptions)
+	if !toggleDeliveryOption(&opts, key) {
+		return
+	}
+	postType.DeliveryOptions = opts.String()
+	if err := h.postTypeRepo.Update(postType); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+		return
+	}
+
+	log.Printf("[FORUM_ADMIN] Option %s of type %d toggled by user %d", key, typeID, userID)
+	h.showTypeDeliveryOptions(ctx, chatID, messageID, typeID)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+func TestDeliveryOptionRows(t *testing.T) {
+	opts := models.DeliveryOptions{Silent: true}
+
+	count := func(rows [][]tgmodels.InlineKeyboardButton) int {
+		n := 0
+		for _, row := range rows {
+			n += len(row)
+		}
+		return n
+	}
+	if got := count(deliveryOptionRows(opts, deliveryMedia, "post_opt:")); got != 4 {
+		t.Errorf("Expected 4 options for media posts, got %d", got)
+	}
+	if got := count(deliveryOptionRows(opts, deliveryText, "post_opt:")); got != 5 {
+		t.Errorf("Expected 5 options for text posts, got %d", got)
+	}
+	if got := count(deliveryOptionRows(opts, deliveryAny, "type_opt:1:")); got != len(deliveryOptions) {
+		t.Errorf("Expected all options for types, got %d", got)
+	}
+
+	first := deliveryOptionRows(opts, deliveryText, "post_opt:")[0][0]
+	if first.Text != "✅ 🔕 Без звука" || first.CallbackData != "post_opt:silent" {
+		t.Errorf("Unexpected first button: %+v", first)
+	}
+}
+
+func TestToggleDeliveryOption(t *testing.T) {
+	var opts models.DeliveryOptions
+	if !toggleDeliveryOption(&opts, "spoiler") || !opts.Spoiler {
+		t.Fatal("Expected spoiler to be enabled")
+	}
+	if !toggleDeliveryOption(&opts, "spoiler") || opts.Spoiler {
+		t.Fatal("Expected spoiler to be disabled")
+	}
+	if toggleDeliveryOption(&opts, "unknown") {
+		t.Error("Expected unknown option to be rejected")
+	}
+}
+
+func TestLinkPreviewOptions(t *testing.T) {
+	if linkPreviewOptions(models.DeliveryOptions{Silent: true}) != nil {
+		t.Error("Expected no preview options by default")
+	}
+
+	preview := linkPreviewOptions(models.DeliveryOptions{NoLinkPreview: true, LargePreview: true})
+	if preview == nil || preview.IsDisabled == nil || !*preview.IsDisabled || preview.PreferLargeMedia != nil {
+		t.Errorf("Expected disabled preview only, got %+v", preview)
+	}
+
+	preview = linkPreviewOptions(models.DeliveryOptions{LargePreview: true, PreviewAboveText: true})
+	if preview == nil || preview.IsDisabled != nil || !*preview.PreferLargeMedia || !*preview.ShowAboveText {
+		t.Errorf("Expected large preview above text, got %+v", preview)
+	}
+}
+
+func TestPostConfirmKeyboardTopic(t *testing.T) {
+	find := func(kb *tgmodels.InlineKeyboardMarkup, data string) string {
+		for _, row := range kb.InlineKeyboard {
+			for _, b := range row {
+				if b.CallbackData == data {
+					return b.Text
+				}
+			}
+		}
+		return ""
+	}
+
+	state := &models.AdminState{DraftText: "текст"}
+	if got := find(postConfirmKeyboard(state, "✅ Подтвердить", false, false), "draft_topic"); got != "📍 Топик: по умолчанию" {
+		t.Errorf("Unexpected default topic button %q", got)
+	}
+	state.DraftTopicID = 42
+	kb := postConfirmKeyboard(state, "✅ Подтвердить", false, false)
+	if got := find(kb, "draft_topic"); got != "📍 Топик: 42" {
+		t.Errorf("Unexpected topic button %q", got)
+	}
+	if find(kb, "draft_edit_text") == "" || find(kb, "draft_change_type") == "" {
+		t.Error("Expected text and type buttons on the draft keyboard")
+	}
+	if find(kb, "post_other_image") != "" {
+		t.Error("Expected no image button without a pool")
+	}
+	if find(postConfirmKeyboard(state, "✅ Подтвердить", false, true), "post_other_image") == "" {
+		t.Error("Expected an image button when the pool has other images")
+	}
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// handlePostDuplicate starts a new post from a copy of a published one.
+func (h *ForumAdminHandler) handlePostDuplicate(ctx context.Context, userID, chatID int64, messageID int, postID int64) {
+	post, err := h.publishedPostRepo.GetByID(postID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post %d: %v", postID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Ошибка получения поста"})
+		return
+	}
+	if !h.checkPostAccess(ctx, userID, chatID, post) {
+		return
+	}
+
+	state := &models.AdminState{
+		UserID:           userID,
+		CurrentState:     fsm.StateNewPostConfirm,
+		SelectedTypeID:   post.PostTypeID,
+		DraftText:        post.Text,
+		DraftEntities:    post.Entities,
+		DraftPhotoID:     post.PhotoID,
+		DraftUserPhotoID: post.UserPhotoID,
+		DraftOptions:     post.DeliveryOptions,
+	}
+	if post.PreviewURL != "" {
+		state.DraftLayout = models.PostLayoutPreview
+	}
+	// The copy gets the current header and footer of the type and the
+	// signature of the admin who publishes it.
+	if postType, err := h.postTypeRepo.GetByID(post.PostTypeID); err == nil {
+		state.DraftFrame = h.newPostFrame(userID, postType)
+	}
+	if config, err := h.adminConfigRepo.Get(); err == nil && post.ChatID == config.ForumChatID && post.TopicID != config.TopicID {
+		state.DraftTopicID = post.TopicID
+	}
+
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.showDraft(ctx, chatID, state)
+
+	log.Printf("[FORUM_ADMIN] Post %d duplicated into a draft by user %d", postID, userID)
+}
+
+// showDraft shows the preview of the new post with the confirm keyboard, or
+// the layout choice when the text no longer fits the photo caption and no
+// layout has been chosen yet.
+func (h *ForumAdminHandler) showDraft(ctx context.Context, chatID int64, state *models.AdminState) {
+	postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Ошибка получения типа поста"})
+		return
+	}
+
+	hasPhoto := state.DraftPhotoID != "" || state.DraftUserPhotoID != ""
+	state.CurrentState = fsm.StateNewPostConfirm
+	switch {
+	case !hasPhoto || draftLength(state) <= services.CaptionLimit:
+		state.DraftLayout = ""
+	case state.DraftUserPhotoID != "":
+		state.DraftLayout = models.PostLayoutSplit
+	case state.DraftLayout == "" || (state.DraftLayout == models.PostLayoutPreview && postType.PhotoURL == ""):
+		state.DraftLayout = ""
+		state.CurrentState = fsm.StateNewPostChooseLayout
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Ошибка сохранения состояния"})
+		return
+	}
+
+	if state.CurrentState == fsm.StateNewPostChooseLayout {
+		h.showPostLayoutChoice(ctx, chatID, state, postType)
+		return
+	}
+	if n := draftOverflow(state, postType); n > 0 {
+		// A type without an image or a longer header makes the text too
+		// long after the draft was written.
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   messageTooLongText(n) + " Сократите текст поста.",
+			ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "✏️ Изменить текст", CallbackData: "draft_edit_text"}},
+				{{Text: "❌ Отмена", CallbackData: "cancel"}},
+			}},
+		})
+		return
+	}
+
+	confirmLabel := "✅ Подтвердить"
+	if state.DraftUserPhotoID != "" {
+		confirmLabel = "✅ Опубликовать"
+	}
+	h.sendPostPreview(ctx, chatID, state, postType, postConfirmKeyboard(state, confirmLabel, h.sandboxEnabled(), h.canSwapDraftImage(state)))
+}
+
+// draftState returns the admin's state if a new post is at the confirm step.
+func (h *ForumAdminHandler) draftState(userID int64) *models.AdminState {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateNewPostConfirm {
+		log.Printf("[FORUM_ADMIN] No draft at the confirm step for user %d: %v", userID, err)
+		return nil
+	}
+	return state
+}
+
+func (h *ForumAdminHandler) handleDraftEditTextStart(ctx context.Context, userID, chatID int64, messageID int) {
+	state := h.draftState(userID)
+	if state == nil {
+		return
+	}
+	postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	state.CurrentState = fsm.StateNewPostEditText
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+
+	prefix := "Текущий текст поста:\n\n"
+	if postType.InputMode != models.InputModeEntities {
+		prefix = fmt.Sprintf("Текущий текст поста в формате %s:\n\n", inputModeLabel(postType.InputMode))
+	}
+	source, sourceEntities := markupSource(postType.InputMode, state.DraftText, state.DraftEntities)
+	text, entities := withPrefix(prefix, services.TextPart{Text: source, Entities: sourceEntities})
+
+	sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:   chatID,
+		Text:     text + "\n\nОтправьте новый текст. Фото, тип и параметры отправки сохранятся.",
+		Entities: entities,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "← Назад", CallbackData: "draft_back"}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send draft text prompt: %v", err)
+	} else {
+		state.LastBotMessageID = sentMsg.ID
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleDraftTextInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	if msg.Text == "" {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Пожалуйста, отправьте текст поста"})
+		return
+	}
+	postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка получения типа поста"})
+		return
+	}
+	text, entities, ok := h.parseInput(ctx, msg, postType.InputMode)
+	if !ok {
+		return
+	}
+
+	draft := *state
+	draft.DraftText = text
+	draft.DraftEntities = ""
+	if len(entities) > 0 {
+		entitiesJSON, _ := json.Marshal(entities)
+		draft.DraftEntities = string(entitiesJSON)
+	}
+	if n := draftOverflow(&draft, postType); n > 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: messageTooLongText(n) + " Отправьте более короткий текст."})
+		return
+	}
+	h.deletePromptMessage(ctx, msg.Chat.ID, &draft)
+	h.showDraft(ctx, msg.Chat.ID, &draft)
+
+	log.Printf("[FORUM_ADMIN] Draft text replaced by user %d", msg.From.ID)
+}
+
+func (h *ForumAdminHandler) handleDraftChangeType(ctx context.Context, userID, chatID int64, messageID int) {
+	state := h.draftState(userID)
+	if state == nil {
+		return
+	}
+	activeTypes, err := h.postTypeRepo.GetActive()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get active types: %v", err)
+		return
+	}
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, pt := range filterAllowedTypes(activeTypes, userID) {
+		label := pt.Name
+		if pt.Emoji != "" {
+			label = pt.Emoji + " " + pt.Name
+		}
+		if pt.ID == state.SelectedTypeID {
+			label = "✅ " + label
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: label, CallbackData: fmt.Sprintf("draft_type:%d", pt.ID)}})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "draft_back"}})
+
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        "Выберите новый тип поста. Текст и параметры отправки сохранятся, изображение будет взято из нового типа.",
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send draft type choice: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleDraftRetype(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	state := h.draftState(userID)
+	if state == nil {
+		return
+	}
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil || !postType.IsActive {
+		log.Printf("[FORUM_ADMIN] Post type %d is not available: %v", typeID, err)
+		return
+	}
+	if !h.checkTypeAccess(ctx, userID, chatID, typeID) {
+		return
+	}
+
+	if state.SelectedTypeID != typeID {
+		state.SelectedTypeID = typeID
+		state.DraftPhotoID = h.pickDraftImage(postType)
+		state.DraftFrame = h.newPostFrame(userID, postType)
+		state.DraftLayout = ""
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.showDraft(ctx, chatID, state)
+
+	log.Printf("[FORUM_ADMIN] Draft of user %d moved to type %d", userID, typeID)
+}
+
+func (h *ForumAdminHandler) handleDraftTopicStart(ctx context.Context, userID, chatID int64, messageID int) {
+	state := h.draftState(userID)
+	if state == nil {
+		return
+	}
+	config, err := h.adminConfigRepo.Get()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get config: %v", err)
+		return
+	}
+
+	state.CurrentState = fsm.StateNewPostEnterTopic
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("Отправьте ID топика, в который опубликовать пост, или «0» для основного топика (%d).", config.TopicID),
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "← Назад", CallbackData: "draft_back"}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send topic prompt: %v", err)
+	} else {
+		state.LastBotMessageID = sentMsg.ID
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleDraftTopicInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	topicID, err := strconv.ParseInt(strings.TrimSpace(msg.Text), 10, 64)
+	if err != nil || topicID < 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Неверный формат ID"})
+		return
+	}
+
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+	state.DraftTopicID = topicID
+	h.showDraft(ctx, msg.Chat.ID, state)
+
+	log.Printf("[FORUM_ADMIN] Draft topic of user %d set to %d", msg.From.ID, topicID)
+}
+
+// handleDraftBack returns from a draft edit step to the preview.
+func (h *ForumAdminHandler) handleDraftBack(ctx context.Context, userID, chatID int64, messageID int) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil {
+		return
+	}
+	switch state.CurrentState {
+	case fsm.StateNewPostConfirm, fsm.StateNewPostEditText, fsm.StateNewPostEnterTopic:
+	default:
+		return
+	}
+
+	state.LastBotMessageID = 0
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.showDraft(ctx, chatID, state)
+}
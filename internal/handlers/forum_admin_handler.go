@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"log"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ad/go-telegram-admin/internal/db"
 	"github.com/ad/go-telegram-admin/internal/fsm"
@@ -16,18 +19,6 @@ import (
 	tgmodels "github.com/go-telegram/bot/models"
 )
 
-func utf16Length(s string) int {
-	length := 0
-	for _, r := range s {
-		if r <= 0xFFFF {
-			length++
-		} else {
-			length += 2
-		}
-	}
-	return length
-}
-
 type ForumAdminHandler struct {
 	bot               *bot.Bot
 	authMiddleware    *services.AdminAuthMiddleware
@@ -40,6 +31,26 @@ type ForumAdminHandler struct {
 	postTypeManager   *services.PostTypeManager
 	settingsManager   *services.SettingsManager
 	backupManager     *services.BackupManager
+	userManager       *services.UserManager
+	confirmationGuard *services.ConfirmationGuard
+	replyTemplateRepo *db.ReplyTemplateRepository
+	commentRepo       *db.CommentRepository
+	commentManager    *services.CommentManager
+	ticketRepo        *db.TicketRepository
+	supportInbox      *services.SupportInbox
+
+	sandboxMessageRepo *db.SandboxMessageRepository
+	chatResolver       *services.ChatResolver
+	typeBundleManager  *services.TypeBundleManager
+	adminSignatureRepo *db.AdminSignatureRepository
+	typeVersionRepo    *db.PostTypeVersionRepository
+	postEventRepo      *db.PostEventRepository
+	// statsLocation is the time zone of days and hours in the statistics.
+	statsLocation *time.Location
+
+	// rerenderJobs stops re-rendering of posts, by type ID.
+	rerenderMu   sync.Mutex
+	rerenderJobs map[int64]context.CancelFunc
 }
 
 func NewForumAdminHandler(
@@ -54,6 +65,20 @@ func NewForumAdminHandler(
 	postTypeManager *services.PostTypeManager,
 	settingsManager *services.SettingsManager,
 	backupManager *services.BackupManager,
+	userManager *services.UserManager,
+	confirmationGuard *services.ConfirmationGuard,
+	replyTemplateRepo *db.ReplyTemplateRepository,
+	commentRepo *db.CommentRepository,
+	commentManager *services.CommentManager,
+	ticketRepo *db.TicketRepository,
+	supportInbox *services.SupportInbox,
+	sandboxMessageRepo *db.SandboxMessageRepository,
+	chatResolver *services.ChatResolver,
+	typeBundleManager *services.TypeBundleManager,
+	adminSignatureRepo *db.AdminSignatureRepository,
+	typeVersionRepo *db.PostTypeVersionRepository,
+	postEventRepo *db.PostEventRepository,
+	statsLocation *time.Location,
 ) *ForumAdminHandler {
 	return &ForumAdminHandler{
 		bot:               b,
@@ -67,6 +92,23 @@ func NewForumAdminHandler(
 		postTypeManager:   postTypeManager,
 		settingsManager:   settingsManager,
 		backupManager:     backupManager,
+		userManager:       userManager,
+		confirmationGuard: confirmationGuard,
+		replyTemplateRepo: replyTemplateRepo,
+		commentRepo:       commentRepo,
+		commentManager:    commentManager,
+		ticketRepo:        ticketRepo,
+		supportInbox:      supportInbox,
+
+		sandboxMessageRepo: sandboxMessageRepo,
+		chatResolver:       chatResolver,
+		typeBundleManager:  typeBundleManager,
+		adminSignatureRepo: adminSignatureRepo,
+		typeVersionRepo:    typeVersionRepo,
+		postEventRepo:      postEventRepo,
+		statsLocation:      statsLocation,
+
+		rerenderJobs: make(map[int64]context.CancelFunc),
 	}
 }
 
@@ -75,19 +117,34 @@ func (h *ForumAdminHandler) HandleCommand(ctx context.Context, msg *tgmodels.Mes
 		return false
 	}
 
+	if command, args, _ := strings.Cut(msg.Text, " "); command == "/editreply" || command == "/deletereply" {
+		lookupState := fsm.StateEditReplyEnterLink
+		if command == "/deletereply" {
+			lookupState = fsm.StateDeleteReplyEnterLink
+		}
+		h.handleReplyByLinkCommand(ctx, msg.From.ID, msg.Chat.ID, lookupState, strings.TrimSpace(args))
+		return true
+	}
+
 	switch msg.Text {
 	case "/start", "/admin":
 		h.showAdminMenu(ctx, msg.Chat.ID, 0)
 		return true
 	case "/new":
-		h.handleNewCommand(ctx, msg.Chat.ID, 0)
+		h.handleNewCommand(ctx, msg.From.ID, msg.Chat.ID, 0)
 		return true
 	case "/edit":
 		h.handleEditCommand(ctx, msg.From.ID, msg.Chat.ID, 0)
 		return true
 	case "/delete":
+		if !h.confirmSensitive(ctx, msg.From.ID, msg.Chat.ID, "admin_delete_post") {
+			return true
+		}
 		h.handleDeleteCommand(ctx, msg.From.ID, msg.Chat.ID, 0)
 		return true
+	case "/pin":
+		h.showSecurityMenu(ctx, msg.From.ID, msg.Chat.ID, 0)
+		return true
 	case "/cancel":
 		h.handleCancelCommand(ctx, msg.From.ID, msg.Chat.ID)
 		return true
@@ -101,18 +158,30 @@ func (h *ForumAdminHandler) HandleMessage(ctx context.Context, msg *tgmodels.Mes
 		return false
 	}
 
+	h.confirmationGuard.Touch(msg.From.ID)
+	if action, ok := h.confirmationGuard.Pending(msg.From.ID); ok {
+		h.handlePINInput(ctx, msg, action)
+		return true
+	}
+
 	state, err := h.adminStateRepo.Get(msg.From.ID)
 	if err != nil || state == nil {
 		return false
 	}
 
 	switch state.CurrentState {
-	case fsm.StateNewPostEnterText:
+	case fsm.StateNewPostEnterText, fsm.StateNewPostChooseLayout:
 		h.handlePostTextInput(ctx, msg, state)
 		return true
 	case fsm.StateNewPostEnterPhoto:
 		h.handleNewPostPhotoInput(ctx, msg, state)
 		return true
+	case fsm.StateNewPostEditText:
+		h.handleDraftTextInput(ctx, msg, state)
+		return true
+	case fsm.StateNewPostEnterTopic:
+		h.handleDraftTopicInput(ctx, msg, state)
+		return true
 	case fsm.StateEditPostEnterLink:
 		h.handleEditPostLinkInput(ctx, msg, state)
 		return true
@@ -149,15 +218,60 @@ func (h *ForumAdminHandler) HandleMessage(ctx context.Context, msg *tgmodels.Mes
 	case fsm.StateEditTypeTemplate:
 		h.handleEditTypeTemplateInput(ctx, msg, state)
 		return true
+	case fsm.StateEditTypePhotoURL:
+		h.handleEditTypePhotoURLInput(ctx, msg, state)
+		return true
+	case fsm.StateEditTypeCategory:
+		h.handleEditTypeCategoryInput(ctx, msg, state)
+		return true
+	case fsm.StateImportTypes:
+		h.handleImportTypesInput(ctx, msg, state)
+		return true
+	case fsm.StateAddTypeImage:
+		h.handleTypeImageAddInput(ctx, msg, state)
+		return true
+	case fsm.StateEditTypeHeader, fsm.StateEditTypeFooter:
+		h.handleEditTypeFrameInput(ctx, msg, state)
+		return true
+	case fsm.StateEditSignature:
+		h.handleSignatureInput(ctx, msg, state)
+		return true
+	case fsm.StateEditTypeCounterFormat, fsm.StateEditTypeCounterNext:
+		h.handleTypeCounterInput(ctx, msg, state)
+		return true
+	case fsm.StatePostSearch:
+		h.handlePostSearchInput(ctx, msg, state)
+		return true
+	case fsm.StateRerenderRange:
+		h.handleRerenderRangeInput(ctx, msg, state)
+		return true
+	case fsm.StateStatsRange:
+		h.handleStatsRangeInput(ctx, msg, state)
+		return true
 	case fsm.StateEditAdminIDs:
 		h.handleEditAdminIDsInput(ctx, msg, state)
 		return true
+	case fsm.StateAddAdminID:
+		h.handleAddAdminInput(ctx, msg, state)
+		return true
 	case fsm.StateEditForumID:
 		h.handleEditForumIDInput(ctx, msg, state)
 		return true
 	case fsm.StateEditTopicID:
 		h.handleEditTopicIDInput(ctx, msg, state)
 		return true
+	case fsm.StateEditSandbox:
+		h.handleEditSandboxInput(ctx, msg, state)
+		return true
+	case fsm.StateSetPIN:
+		h.handleSetPINInput(ctx, msg, state)
+		return true
+	case fsm.StateNewReplyTemplateName, fsm.StateEditReplyTemplateName:
+		h.handleReplyTemplateNameInput(ctx, msg, state)
+		return true
+	case fsm.StateNewReplyTemplateText, fsm.StateEditReplyTemplateText:
+		h.handleReplyTemplateTextInput(ctx, msg, state)
+		return true
 	case fsm.StateReplyEnterLink:
 		h.handleReplyLinkInput(ctx, msg, state)
 		return true
@@ -167,6 +281,9 @@ func (h *ForumAdminHandler) HandleMessage(ctx context.Context, msg *tgmodels.Mes
 	case fsm.StateEditReplyEnterText:
 		h.handleEditReplyTextInput(ctx, msg, state)
 		return true
+	case fsm.StateEditReplyEnterLink, fsm.StateDeleteReplyEnterLink:
+		h.handleReplyLookupLinkInput(ctx, msg, state)
+		return true
 	default:
 		return false
 	}
@@ -192,6 +309,11 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 
 	log.Printf("[FORUM_ADMIN] Callback received: %s", data)
 
+	h.confirmationGuard.Touch(callback.From.ID)
+	if !h.confirmSensitive(ctx, callback.From.ID, chatID, data) {
+		return true
+	}
+
 	if data == "cancel" {
 		h.handleCancelCallback(ctx, callback.From.ID, chatID, messageID)
 		return true
@@ -213,7 +335,17 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 	}
 
 	if data == "admin_new_post" {
-		h.handleNewCommand(ctx, chatID, messageID)
+		h.handleNewCommand(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "new_post_category:") {
+		index, err := strconv.Atoi(strings.TrimPrefix(data, "new_post_category:"))
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse category index: %v", err)
+			return false
+		}
+		h.handleNewPostCategory(ctx, callback.From.ID, chatID, messageID, index)
 		return true
 	}
 
@@ -247,11 +379,108 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 		return true
 	}
 
+	if data == "settings_security" {
+		h.showSecurityMenu(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "settings_stats" {
+		h.showStats(ctx, chatID, messageID, statsDefaultRange, "")
+		return true
+	}
+
+	if strings.HasPrefix(data, "stats:") {
+		spec, period, _ := strings.Cut(strings.TrimPrefix(data, "stats:"), ":")
+		h.showStats(ctx, chatID, messageID, spec, period)
+		return true
+	}
+
+	if strings.HasPrefix(data, "stats_csv:") {
+		spec, period, _ := strings.Cut(strings.TrimPrefix(data, "stats_csv:"), ":")
+		h.handleStatsCSV(ctx, chatID, spec, period)
+		return true
+	}
+
+	if data == "stats_range" {
+		h.handleStatsRangeStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "settings_signature" {
+		h.showSignatureMenu(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "signature_edit" {
+		h.handleSignatureEditStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "signature_delete" {
+		h.handleSignatureDelete(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "security_set_pin" {
+		h.handleSetPINStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "security_remove_pin" {
+		h.handleRemovePIN(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "approve_action:") {
+		h.handleApprovalCallback(ctx, callback.From.ID, chatID, messageID, strings.TrimPrefix(data, "approve_action:"), true)
+		return true
+	}
+
+	if strings.HasPrefix(data, "reject_action:") {
+		h.handleApprovalCallback(ctx, callback.From.ID, chatID, messageID, strings.TrimPrefix(data, "reject_action:"), false)
+		return true
+	}
+
 	if data == "access_edit_admins" {
 		h.handleEditAdminIDsStart(ctx, callback.From.ID, chatID, messageID)
 		return true
 	}
 
+	if data == "access_admins" {
+		h.showAdminList(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "access_add_admin" {
+		h.handleAddAdminStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "access_refresh_admins" {
+		h.handleRefreshAdminProfiles(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "access_remove_admin_confirm:") {
+		adminID, err := strconv.ParseInt(strings.TrimPrefix(data, "access_remove_admin_confirm:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse admin ID: %v", err)
+			return false
+		}
+		h.handleRemoveAdmin(ctx, callback.From.ID, chatID, messageID, adminID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "access_remove_admin:") {
+		adminID, err := strconv.ParseInt(strings.TrimPrefix(data, "access_remove_admin:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse admin ID: %v", err)
+			return false
+		}
+		h.showRemoveAdminConfirm(ctx, chatID, messageID, adminID)
+		return true
+	}
+
 	if data == "access_edit_forum" {
 		h.handleEditForumIDStart(ctx, callback.From.ID, chatID, messageID)
 		return true
@@ -262,11 +491,71 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 		return true
 	}
 
+	if data == "access_edit_sandbox" {
+		h.handleEditSandboxStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "sandbox_clean" {
+		h.handleSandboxClean(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "post_other_image" {
+		h.handlePostOtherImage(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "post_test" {
+		h.handlePostTest(ctx, callback.From.ID, chatID)
+		return true
+	}
+
+	if data == "draft_edit_text" {
+		h.handleDraftEditTextStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "draft_change_type" {
+		h.handleDraftChangeType(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "draft_type:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "draft_type:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleDraftRetype(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if data == "draft_topic" {
+		h.handleDraftTopicStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "draft_back" {
+		h.handleDraftBack(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
 	if data == "confirm_post" {
 		h.handlePostConfirmation(ctx, callback.From.ID, chatID, messageID)
 		return true
 	}
 
+	if strings.HasPrefix(data, "post_layout:") {
+		h.handlePostLayoutChoice(ctx, callback.From.ID, chatID, messageID, strings.TrimPrefix(data, "post_layout:"))
+		return true
+	}
+
+	if strings.HasPrefix(data, "post_opt:") {
+		h.handlePostOptionToggle(ctx, callback.From.ID, chatID, messageID, strings.TrimPrefix(data, "post_opt:"))
+		return true
+	}
+
 	if data == "post_add_photo" {
 		state, err := h.adminStateRepo.Get(callback.From.ID)
 		if err != nil || state == nil {
@@ -309,25 +598,16 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 			log.Printf("[FORUM_ADMIN] Failed to get post: %v", err)
 			return false
 		}
+		if !h.checkPostAccess(ctx, callback.From.ID, chatID, post) {
+			return true
+		}
 		state.CurrentState = fsm.StateEditPostEnterText
 		if err := h.adminStateRepo.Save(state); err != nil {
 			log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
 			return false
 		}
 
-		previewText := fmt.Sprintf("Текущий текст поста:\n\n%s\n\nОтправьте новый текст.", post.Text)
-		var previewEntities []tgmodels.MessageEntity
-		if post.Entities != "" {
-			var ents []tgmodels.MessageEntity
-			if jsonErr := json.Unmarshal([]byte(post.Entities), &ents); jsonErr == nil {
-				prefix := "Текущий текст поста:\n\n"
-				offset := utf16Length(prefix)
-				for _, e := range ents {
-					e.Offset += offset
-					previewEntities = append(previewEntities, e)
-				}
-			}
-		}
+		previewText, previewEntities := h.editTextPrompt(post)
 
 		keyboard := &tgmodels.InlineKeyboardMarkup{
 			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
@@ -357,6 +637,9 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 		if err != nil || state == nil {
 			return false
 		}
+		if post, err := h.publishedPostRepo.GetByID(state.EditingPostID); err == nil && !h.checkPostAccess(ctx, callback.From.ID, chatID, post) {
+			return true
+		}
 		switch data {
 		case "edit_post_type_photo":
 			state.CurrentState = fsm.StateEditPostEnterTypePhoto
@@ -399,6 +682,9 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 			log.Printf("[FORUM_ADMIN] Failed to get post: %v", err)
 			return false
 		}
+		if !h.checkPostAccess(ctx, callback.From.ID, chatID, post) {
+			return true
+		}
 		if post.UserPhotoMessageID != 0 {
 			h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
 				ChatID:    post.ChatID,
@@ -409,6 +695,7 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 			if updateErr := h.publishedPostRepo.Update(post); updateErr != nil {
 				log.Printf("[FORUM_ADMIN] Failed to update post after user photo delete: %v", updateErr)
 			}
+			h.recordPostEvent(models.PostEventPostEdit, callback.From.ID, post.PostTypeID)
 		}
 		h.adminStateRepo.Clear(callback.From.ID)
 		if messageID > 0 {
@@ -470,42 +757,520 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 		typeIDStr := strings.TrimPrefix(data, "edit_type_image:")
 		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
 		if err != nil {
-			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleEditTypeImageStart(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "edit_type_template:") {
+		typeIDStr := strings.TrimPrefix(data, "edit_type_template:")
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleEditTypeTemplateStart(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "edit_type_photo_url:") {
+		typeIDStr := strings.TrimPrefix(data, "edit_type_photo_url:")
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleEditTypePhotoURLStart(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_options:") {
+		typeIDStr := strings.TrimPrefix(data, "type_options:")
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.showTypeDeliveryOptions(ctx, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_input_mode:") {
+		typeIDStr := strings.TrimPrefix(data, "type_input_mode:")
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeInputModeToggle(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_opt:") {
+		// format: type_opt:{typeID}:{option}
+		typeIDStr, key, ok := strings.Cut(strings.TrimPrefix(data, "type_opt:"), ":")
+		if !ok {
+			return false
+		}
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeOptionToggle(ctx, callback.From.ID, chatID, messageID, typeID, key)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_move:") {
+		// format: type_move:{typeID}:{delta}
+		typeIDStr, deltaStr, ok := strings.Cut(strings.TrimPrefix(data, "type_move:"), ":")
+		if !ok {
+			return false
+		}
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		delta, err := strconv.Atoi(deltaStr)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse move delta: %v", err)
+			return false
+		}
+		h.handleTypeMove(ctx, callback.From.ID, chatID, messageID, typeID, delta)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_access:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_access:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeAccess(ctx, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_access_toggle:") {
+		// format: type_access_toggle:{typeID}:{adminID}
+		typeIDStr, adminIDStr, ok := strings.Cut(strings.TrimPrefix(data, "type_access_toggle:"), ":")
+		if !ok {
+			return false
+		}
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		adminID, err := strconv.ParseInt(adminIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse admin ID: %v", err)
+			return false
+		}
+		h.handleTypeAccessToggle(ctx, callback.From.ID, chatID, messageID, typeID, adminID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_access_clear:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_access_clear:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeAccessToggle(ctx, callback.From.ID, chatID, messageID, typeID, 0)
+		return true
+	}
+
+	if strings.HasPrefix(data, "edit_type_category:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "edit_type_category:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleEditTypeCategoryStart(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "edit_type_header:") || strings.HasPrefix(data, "edit_type_footer:") {
+		footer := strings.HasPrefix(data, "edit_type_footer:")
+		typeID, err := strconv.ParseInt(data[strings.Index(data, ":")+1:], 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleEditTypeFrameStart(ctx, callback.From.ID, chatID, messageID, typeID, footer)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_versions:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_versions:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeVersions(ctx, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_rerender:") || strings.HasPrefix(data, "type_rerender_run:") {
+		// format: type_rerender:{typeID}:{scope}
+		run := strings.HasPrefix(data, "type_rerender_run:")
+		idStr, scope, _ := strings.Cut(data[strings.Index(data, ":")+1:], ":")
+		typeID, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		if run {
+			h.handleRerenderRun(ctx, callback.From.ID, chatID, messageID, typeID, scope)
+		} else {
+			h.handleRerender(ctx, chatID, messageID, typeID, scope)
+		}
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_rerender_range:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_rerender_range:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleRerenderRangeStart(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_rerender_stop:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_rerender_stop:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleRerenderStop(ctx, chatID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_counter:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_counter:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeCounter(ctx, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_counter_format:") || strings.HasPrefix(data, "type_counter_next:") {
+		next := strings.HasPrefix(data, "type_counter_next:")
+		typeID, err := strconv.ParseInt(data[strings.Index(data, ":")+1:], 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeCounterEditStart(ctx, callback.From.ID, chatID, messageID, typeID, next)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_counter_off:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_counter_off:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeCounterOff(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_images:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_images:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		if state, err := h.adminStateRepo.Get(callback.From.ID); err == nil && state.CurrentState == fsm.StateAddTypeImage {
+			h.adminStateRepo.Clear(callback.From.ID)
+		}
+		h.handleTypeImages(ctx, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_image_strategy:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_image_strategy:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeImageStrategy(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_image_add:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_image_add:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleTypeImageAddStart(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_image_show:") {
+		imageID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_image_show:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse image ID: %v", err)
+			return false
+		}
+		h.handleTypeImageShow(ctx, chatID, imageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "type_image_delete:") {
+		imageID, err := strconv.ParseInt(strings.TrimPrefix(data, "type_image_delete:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse image ID: %v", err)
+			return false
+		}
+		h.handleTypeImageDelete(ctx, callback.From.ID, chatID, messageID, imageID)
+		return true
+	}
+
+	if data == "types_export" {
+		h.handleExportTypesStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "types_export_toggle:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "types_export_toggle:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleExportTypesToggle(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if data == "types_export_all" {
+		h.handleExportTypesToggle(ctx, callback.From.ID, chatID, messageID, 0)
+		return true
+	}
+
+	if data == "types_export_send" {
+		h.handleExportTypesSend(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "types_import" {
+		h.handleImportTypesStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "types_import:") {
+		h.handleImportTypesResolve(ctx, callback.From.ID, chatID, messageID, strings.TrimPrefix(data, "types_import:"))
+		return true
+	}
+
+	if data == "types_two_columns" {
+		h.handleTypeColumnsToggle(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "delete_type:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "delete_type:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleDeleteTypeStart(ctx, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "delete_type_move:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "delete_type_move:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleDeleteTypeChooseTarget(ctx, chatID, messageID, typeID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "delete_type_to:") {
+		// format: delete_type_to:{typeID}:{targetTypeID}
+		typeIDStr, targetIDStr, ok := strings.Cut(strings.TrimPrefix(data, "delete_type_to:"), ":")
+		if !ok {
+			return false
+		}
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		targetID, err := strconv.ParseInt(targetIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse target type ID: %v", err)
+			return false
+		}
+		h.handleDeleteType(ctx, callback.From.ID, chatID, messageID, typeID, targetID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "delete_type_archive:") {
+		typeID, err := strconv.ParseInt(strings.TrimPrefix(data, "delete_type_archive:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleDeleteType(ctx, callback.From.ID, chatID, messageID, typeID, 0)
+		return true
+	}
+
+	if strings.HasPrefix(data, "toggle_type_active:") {
+		typeIDStr := strings.TrimPrefix(data, "toggle_type_active:")
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			return false
+		}
+		h.handleToggleTypeActive(ctx, callback.From.ID, chatID, messageID, typeID)
+		return true
+	}
+
+	if data == "admin_reply" {
+		h.handleReplyStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "confirm_reply" {
+		h.handleReplyConfirmation(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "reply_tpl_edit_draft" {
+		h.handleReplyTemplateEditDraft(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "settings_reply_templates" {
+		h.showReplyTemplates(ctx, chatID, messageID)
+		return true
+	}
+
+	if data == "reply_tpl_new" {
+		h.handleNewReplyTemplateStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "reply_tpl") {
+		// format: reply_tpl[_action]:{templateID}
+		idx := strings.LastIndex(data, ":")
+		if idx < 0 {
+			return false
+		}
+		templateID, err := strconv.ParseInt(data[idx+1:], 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse template ID: %v", err)
+			return false
+		}
+		switch data[:idx] {
+		case "reply_tpl":
+			h.handleReplyTemplateSelect(ctx, callback.From.ID, chatID, messageID, templateID)
+		case "reply_tpl_manage":
+			h.showReplyTemplateDetails(ctx, chatID, messageID, templateID)
+		case "reply_tpl_edit_text":
+			h.handleEditReplyTemplateTextStart(ctx, callback.From.ID, chatID, messageID, templateID)
+		case "reply_tpl_edit_name":
+			h.handleEditReplyTemplateNameStart(ctx, callback.From.ID, chatID, messageID, templateID)
+		case "reply_tpl_delete":
+			h.showDeleteReplyTemplateConfirm(ctx, chatID, messageID, templateID)
+		case "reply_tpl_delete_confirm":
+			h.handleDeleteReplyTemplate(ctx, callback.From.ID, chatID, messageID, templateID)
+		default:
+			return false
+		}
+		return true
+	}
+
+	if data == "edit_reply_remove_photo" {
+		h.handleEditReplyRemovePhoto(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "admin_comments" {
+		h.showCommentQueue(ctx, chatID, messageID)
+		return true
+	}
+
+	if data == "comments_read_all" {
+		h.handleCommentsReadAll(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
+	if data == "admin_tickets" {
+		h.showTicketList(ctx, chatID, messageID, models.TicketStatusOpen, 0)
+		return true
+	}
+
+	if strings.HasPrefix(data, "tickets:") {
+		// format: tickets:{status}:{page}
+		status, pageStr, ok := strings.Cut(strings.TrimPrefix(data, "tickets:"), ":")
+		if !ok {
+			return false
+		}
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse ticket list page: %v", err)
 			return false
 		}
-		h.handleEditTypeImageStart(ctx, callback.From.ID, chatID, messageID, typeID)
+		h.showTicketList(ctx, chatID, messageID, status, page)
 		return true
 	}
 
-	if strings.HasPrefix(data, "edit_type_template:") {
-		typeIDStr := strings.TrimPrefix(data, "edit_type_template:")
-		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+	if strings.HasPrefix(data, "ticket_") {
+		// format: ticket_{action}:{ticketID}
+		action, idStr, ok := strings.Cut(data, ":")
+		if !ok {
+			return false
+		}
+		ticketID, err := strconv.ParseInt(idStr, 10, 64)
 		if err != nil {
-			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			log.Printf("[FORUM_ADMIN] Failed to parse ticket ID: %v", err)
+			return false
+		}
+		switch action {
+		case "ticket_view":
+			h.showTicket(ctx, chatID, messageID, ticketID)
+		case "ticket_assign", "ticket_close", "ticket_reopen":
+			h.handleTicketAction(ctx, callback.From.ID, chatID, messageID, action, ticketID)
+		default:
 			return false
 		}
-		h.handleEditTypeTemplateStart(ctx, callback.From.ID, chatID, messageID, typeID)
 		return true
 	}
 
-	if strings.HasPrefix(data, "toggle_type_active:") {
-		typeIDStr := strings.TrimPrefix(data, "toggle_type_active:")
-		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+	if strings.HasPrefix(data, "comment_") {
+		// format: comment_{action}:{commentID}
+		action, idStr, ok := strings.Cut(data, ":")
+		if !ok {
+			return false
+		}
+		commentID, err := strconv.ParseInt(idStr, 10, 64)
 		if err != nil {
-			log.Printf("[FORUM_ADMIN] Failed to parse type ID: %v", err)
+			log.Printf("[FORUM_ADMIN] Failed to parse comment ID: %v", err)
+			return false
+		}
+		switch action {
+		case "comment_details":
+			h.showCommentDetails(ctx, chatID, messageID, commentID)
+		case "comment_read":
+			h.handleCommentRead(ctx, chatID, messageID, commentID, false)
+		case "comment_read_queue":
+			h.handleCommentRead(ctx, chatID, messageID, commentID, true)
+		case "comment_reply":
+			h.handleCommentReply(ctx, callback.From.ID, chatID, commentID)
+		default:
 			return false
 		}
-		h.handleToggleTypeActive(ctx, callback.From.ID, chatID, messageID, typeID)
-		return true
-	}
-
-	if data == "admin_reply" {
-		h.handleReplyStart(ctx, callback.From.ID, chatID, messageID)
-		return true
-	}
-
-	if data == "confirm_reply" {
-		h.handleReplyConfirmation(ctx, callback.From.ID, chatID, messageID)
 		return true
 	}
 
@@ -604,6 +1369,11 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 		return true
 	}
 
+	if data == "post_search" {
+		h.handlePostSearchStart(ctx, callback.From.ID, chatID, messageID)
+		return true
+	}
+
 	if strings.HasPrefix(data, "post_list_page:") {
 		pageStr := strings.TrimPrefix(data, "post_list_page:")
 		page, err := strconv.Atoi(pageStr)
@@ -615,6 +1385,31 @@ func (h *ForumAdminHandler) HandleCallback(ctx context.Context, callback *tgmode
 		return true
 	}
 
+	if strings.HasPrefix(data, "post_duplicate:") {
+		postID, err := strconv.ParseInt(strings.TrimPrefix(data, "post_duplicate:"), 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse post ID: %v", err)
+			return false
+		}
+		h.handlePostDuplicate(ctx, callback.From.ID, chatID, messageID, postID)
+		return true
+	}
+
+	if strings.HasPrefix(data, "post_source:") {
+		// format: post_source:{postID}:{mode}
+		postIDStr, mode, ok := strings.Cut(strings.TrimPrefix(data, "post_source:"), ":")
+		if !ok {
+			return false
+		}
+		postID, err := strconv.ParseInt(postIDStr, 10, 64)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to parse post ID: %v", err)
+			return false
+		}
+		h.handlePostSource(ctx, chatID, postID, mode)
+		return true
+	}
+
 	if strings.HasPrefix(data, "post_details:") {
 		// format: post_details:{postID}:{page}
 		parts := strings.SplitN(strings.TrimPrefix(data, "post_details:"), ":", 2)
@@ -698,6 +1493,9 @@ func (h *ForumAdminHandler) handleTypeSelection(ctx context.Context, userID, cha
 		})
 		return
 	}
+	if !h.checkTypeAccess(ctx, userID, chatID, typeID) {
+		return
+	}
 
 	err = h.adminStateRepo.Save(&models.AdminState{
 		UserID:         userID,
@@ -724,18 +1522,21 @@ func (h *ForumAdminHandler) handleTypeSelection(ctx context.Context, userID, cha
 	}
 
 	templatePrefix := fmt.Sprintf("Шаблон для типа \"%s\":\n\n", postType.Name)
-	templateText := templatePrefix + postType.Template + "\n\nОтправьте текст поста."
+	template, entities := markupSource(postType.InputMode, postType.Template, postType.TemplateEntities)
+	templateText := templatePrefix + template + "\n\nОтправьте текст поста."
+	if postType.InputMode != models.InputModeEntities {
+		templateText += fmt.Sprintf(" Текст принимается в формате %s.", inputModeLabel(postType.InputMode))
+	}
+	if postType.PhotoID != "" {
+		templateText += fmt.Sprintf(" В подпись к фото помещается %d символов; более длинный текст можно будет опубликовать с продолжением отдельным сообщением.", services.CaptionLimit)
+	}
 
 	var templateEntities []tgmodels.MessageEntity
-	if postType.TemplateEntities != "" {
-		var entities []tgmodels.MessageEntity
-		json.Unmarshal([]byte(postType.TemplateEntities), &entities)
-		offsetAdjustment := utf16Length(templatePrefix)
-		for _, entity := range entities {
-			adjustedEntity := entity
-			adjustedEntity.Offset += offsetAdjustment
-			templateEntities = append(templateEntities, adjustedEntity)
-		}
+	offsetAdjustment := services.UTF16Length(templatePrefix)
+	for _, entity := range entities {
+		adjustedEntity := entity
+		adjustedEntity.Offset += offsetAdjustment
+		templateEntities = append(templateEntities, adjustedEntity)
 	}
 
 	keyboard := &tgmodels.InlineKeyboardMarkup{
@@ -747,7 +1548,7 @@ func (h *ForumAdminHandler) handleTypeSelection(ctx context.Context, userID, cha
 	}
 
 	var sentMsg *tgmodels.Message
-	if postType.PhotoID != "" {
+	if postType.PhotoID != "" && services.UTF16Length(templateText) <= services.CaptionLimit {
 		sentMsg, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
 			ChatID:          chatID,
 			Photo:           &tgmodels.InputFileString{Data: postType.PhotoID},
@@ -778,6 +1579,11 @@ func (h *ForumAdminHandler) handleTypeSelection(ctx context.Context, userID, cha
 }
 
 func (h *ForumAdminHandler) showAdminMenu(ctx context.Context, chatID int64, messageID int) {
+	commentsLabel := "📥 Комментарии"
+	if unread, err := h.commentRepo.CountUnread(); err == nil && unread > 0 {
+		commentsLabel = fmt.Sprintf("📥 Комментарии (%d)", unread)
+	}
+
 	keyboard := &tgmodels.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
 			{
@@ -799,10 +1605,22 @@ func (h *ForumAdminHandler) showAdminMenu(ctx context.Context, chatID int64, mes
 				{Text: "📨 Список ответов", CallbackData: "admin_reply_list"},
 			},
 			{
-				{Text: "⚙️ Настройки", CallbackData: "admin_settings"},
+				{Text: commentsLabel, CallbackData: "admin_comments"},
 			},
 		},
 	}
+	if h.supportInbox != nil {
+		ticketsLabel := "🎫 Обращения"
+		if open, err := h.ticketRepo.CountByStatus(models.TicketStatusOpen); err == nil && open > 0 {
+			ticketsLabel = fmt.Sprintf("🎫 Обращения (%d)", open)
+		}
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
+			{Text: ticketsLabel, CallbackData: "admin_tickets"},
+		})
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
+		{Text: "⚙️ Настройки", CallbackData: "admin_settings"},
+	})
 
 	text := "Админ-панель управления постами"
 
@@ -840,6 +1658,18 @@ func (h *ForumAdminHandler) showSettingsMenu(ctx context.Context, chatID int64,
 			{
 				{Text: "🔐 Настройки доступа", CallbackData: "settings_access"},
 			},
+			{
+				{Text: "📝 Шаблоны ответов", CallbackData: "settings_reply_templates"},
+			},
+			{
+				{Text: "🔑 PIN-код", CallbackData: "settings_security"},
+			},
+			{
+				{Text: "✍️ Подпись", CallbackData: "settings_signature"},
+			},
+			{
+				{Text: "📊 Статистика", CallbackData: "settings_stats"},
+			},
 			{
 				{Text: "💾 Бэкап", CallbackData: "settings_backup"},
 			},
@@ -873,7 +1703,7 @@ func (h *ForumAdminHandler) showSettingsMenu(ctx context.Context, chatID int64,
 	}
 }
 
-func (h *ForumAdminHandler) handleNewCommand(ctx context.Context, chatID int64, messageID int) {
+func (h *ForumAdminHandler) handleNewCommand(ctx context.Context, userID, chatID int64, messageID int) {
 	log.Printf("[FORUM_ADMIN] /new command for chat %d", chatID)
 
 	activeTypes, err := h.postTypeRepo.GetActive()
@@ -894,47 +1724,33 @@ func (h *ForumAdminHandler) handleNewCommand(ctx context.Context, chatID int64,
 		return
 	}
 
-	keyboard := &tgmodels.InlineKeyboardMarkup{
-		InlineKeyboard: make([][]tgmodels.InlineKeyboardButton, 0, len(activeTypes)),
+	activeTypes = filterAllowedTypes(activeTypes, userID)
+	if len(activeTypes) == 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "🔒 Все активные типы постов доступны только назначенным администраторам.",
+		})
+		return
 	}
 
-	for _, pt := range activeTypes {
-		buttonText := pt.Name
-		if pt.Emoji != "" {
-			buttonText = pt.Emoji + " " + pt.Name
-		}
-		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
-			{
-				Text:         buttonText,
-				CallbackData: fmt.Sprintf("select_type:%d", pt.ID),
-			},
-		})
+	recent, err := h.postTypeRepo.GetRecentByAuthor(userID, recentTypesLimit)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get recent types: %v", err)
 	}
+	recent = filterAllowedTypes(recent, userID)
 
+	keyboard := &tgmodels.InlineKeyboardMarkup{
+		InlineKeyboard: typePickerRows(activeTypes, recent, h.typePickerTwoColumns()),
+	}
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
 		{Text: "← Назад", CallbackData: "cancel"},
 	})
 
 	text := "Выберите тип поста:"
-
-	if messageID > 0 {
-		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
-			ChatID:      chatID,
-			MessageID:   messageID,
-			Text:        text,
-			ReplyMarkup: keyboard,
-		})
-	} else {
-		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:      chatID,
-			Text:        text,
-			ReplyMarkup: keyboard,
-		})
-	}
-
-	if err != nil {
-		log.Printf("[FORUM_ADMIN] Failed to send type selection: %v", err)
+	if len(recent) > 0 {
+		text = "Выберите тип поста (🕘 — недавно использованные вами):"
 	}
+	h.editOrSendMenu(ctx, chatID, messageID, text, keyboard)
 }
 
 func (h *ForumAdminHandler) handleEditCommand(ctx context.Context, userID, chatID int64, messageID int) {
@@ -953,7 +1769,7 @@ func (h *ForumAdminHandler) handleEditCommand(ctx context.Context, userID, chatI
 		return
 	}
 
-	text := "Отправьте ссылку на пост, который хотите отредактировать."
+	text := "Отправьте ссылку на пост, который хотите отредактировать, или его номер."
 
 	keyboard := &tgmodels.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
@@ -1006,7 +1822,7 @@ func (h *ForumAdminHandler) handleDeleteCommand(ctx context.Context, userID, cha
 		return
 	}
 
-	text := "Отправьте ссылку на пост, который хотите удалить."
+	text := "Отправьте ссылку на пост, который хотите удалить, или его номер."
 
 	keyboard := &tgmodels.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
@@ -1046,6 +1862,7 @@ func (h *ForumAdminHandler) handleDeleteCommand(ctx context.Context, userID, cha
 func (h *ForumAdminHandler) handleCancelCommand(ctx context.Context, userID, chatID int64) {
 	log.Printf("[FORUM_ADMIN] /cancel command for user %d, chat %d", userID, chatID)
 
+	h.confirmationGuard.ClearPending(userID)
 	err := h.adminStateRepo.Clear(userID)
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to clear state: %v", err)
@@ -1055,6 +1872,7 @@ func (h *ForumAdminHandler) handleCancelCommand(ctx context.Context, userID, cha
 }
 
 func (h *ForumAdminHandler) handleCancelCallback(ctx context.Context, userID, chatID int64, messageID int) {
+	h.confirmationGuard.ClearPending(userID)
 	err := h.adminStateRepo.Clear(userID)
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to clear state: %v", err)
@@ -1083,17 +1901,6 @@ func (h *ForumAdminHandler) handlePostTextInput(ctx context.Context, msg *tgmode
 		return
 	}
 
-	if state.LastBotMessageID > 0 {
-		_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
-			ChatID:    msg.Chat.ID,
-			MessageID: state.LastBotMessageID,
-		})
-		if err != nil {
-			log.Printf("[FORUM_ADMIN] Failed to delete template message: %v", err)
-		}
-		state.LastBotMessageID = 0
-	}
-
 	postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
@@ -1104,14 +1911,42 @@ func (h *ForumAdminHandler) handlePostTextInput(ctx context.Context, msg *tgmode
 		return
 	}
 
-	state.DraftText = msg.Text
-	state.DraftPhotoID = postType.PhotoID
-	if len(msg.Entities) > 0 {
-		entitiesJSON, _ := json.Marshal(msg.Entities)
+	text, entities, ok := h.parseInput(ctx, msg, postType.InputMode)
+	if !ok {
+		return
+	}
+
+	state.DraftText = text
+	state.DraftPhotoID = h.pickDraftImage(postType)
+	state.DraftFrame = h.newPostFrame(msg.From.ID, postType)
+	state.DraftOptions = postType.DeliveryOptions
+	state.DraftLayout = ""
+	state.DraftEntities = ""
+	if len(entities) > 0 {
+		entitiesJSON, _ := json.Marshal(entities)
 		state.DraftEntities = string(entitiesJSON)
 		// log.Printf("[FORUM_ADMIN] Received entities: %s", string(entitiesJSON))
 	}
+	if n := draftOverflow(state, postType); n > 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: messageTooLongText(n) + " Отправьте более короткий текст."})
+		log.Printf("[FORUM_ADMIN] Post text of user %d does not fit in a message: %d", msg.From.ID, n)
+		return
+	}
+
+	if state.LastBotMessageID > 0 {
+		_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    msg.Chat.ID,
+			MessageID: state.LastBotMessageID,
+		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to delete template message: %v", err)
+		}
+		state.LastBotMessageID = 0
+	}
 	state.CurrentState = fsm.StateNewPostConfirm
+	if state.DraftPhotoID != "" && draftLength(state) > services.CaptionLimit {
+		state.CurrentState = fsm.StateNewPostChooseLayout
+	}
 	err = h.adminStateRepo.Save(state)
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
@@ -1122,115 +1957,73 @@ func (h *ForumAdminHandler) handlePostTextInput(ctx context.Context, msg *tgmode
 		return
 	}
 
-	previewPrefix := "Предпросмотр поста:\n\n"
-	previewText := previewPrefix + msg.Text
-
-	var previewEntities []tgmodels.MessageEntity
-	if len(msg.Entities) > 0 {
-		offsetAdjustment := utf16Length(previewPrefix)
-		for _, entity := range msg.Entities {
-			adjustedEntity := entity
-			adjustedEntity.Offset += offsetAdjustment
-			previewEntities = append(previewEntities, adjustedEntity)
-		}
-	}
-
-	addPhotoLabel := "📸 Добавить фото"
-	if state.DraftUserPhotoID != "" {
-		addPhotoLabel = "📸 Изменить фото"
-	}
-
-	keyboard := &tgmodels.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
-			{
-				{Text: "✅ Подтвердить", CallbackData: "confirm_post"},
-			},
-			{
-				{Text: addPhotoLabel, CallbackData: "post_add_photo"},
-			},
-			{
-				{Text: "❌ Отмена", CallbackData: "cancel"},
-			},
-		},
-	}
-
-	if postType.PhotoID != "" {
-		_, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
-			ChatID:          msg.Chat.ID,
-			Photo:           &tgmodels.InputFileString{Data: postType.PhotoID},
-			Caption:         previewText,
-			ReplyMarkup:     keyboard,
-			CaptionEntities: previewEntities,
-		})
-	} else {
-		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:      msg.Chat.ID,
-			Text:        previewText,
-			ReplyMarkup: keyboard,
-			Entities:    previewEntities,
-		})
+	if state.CurrentState == fsm.StateNewPostChooseLayout {
+		h.showPostLayoutChoice(ctx, msg.Chat.ID, state, postType)
+		log.Printf("[FORUM_ADMIN] Post text of user %d exceeds the caption limit, layout choice shown", msg.From.ID)
+		return
 	}
 
-	if err != nil {
-		log.Printf("[FORUM_ADMIN] Failed to send preview: %v", err)
-	}
+	h.sendPostPreview(ctx, msg.Chat.ID, state, postType, postConfirmKeyboard(state, "✅ Подтвердить", h.sandboxEnabled(), h.canSwapDraftImage(state)))
 
 	log.Printf("[FORUM_ADMIN] Preview shown to user %d, state set to StateNewPostConfirm", msg.From.ID)
 }
 
-func (h *ForumAdminHandler) handlePostConfirmation(ctx context.Context, userID, chatID int64, messageID int) {
-	state, err := h.adminStateRepo.Get(userID)
-	if err != nil || state == nil || state.CurrentState != fsm.StateNewPostConfirm {
-		log.Printf("[FORUM_ADMIN] Invalid state for confirmation: %v", err)
-		h.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: chatID,
-			Text:   "❌ Ошибка: неверное состояние",
-		})
-		return
-	}
-
-	config, err := h.adminConfigRepo.Get()
-	if err != nil {
-		log.Printf("[FORUM_ADMIN] Failed to get config: %v", err)
-		h.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: chatID,
-			Text:   "❌ Ошибка получения конфигурации",
-		})
-		return
-	}
-
-	var entities []tgmodels.MessageEntity
-	if state.DraftEntities != "" {
-		json.Unmarshal([]byte(state.DraftEntities), &entities)
-		// log.Printf("[FORUM_ADMIN] Publishing with %d entities: %s", len(entities), state.DraftEntities)
-	}
+// publishDraft sends the draft post to the given chat and topic and returns
+// the post, not yet saved, with the IDs of the sent messages. followUpErr
+// reports a failed continuation after the post itself was sent.
+func (h *ForumAdminHandler) publishDraft(ctx context.Context, state *models.AdminState, authorID, chatID, topicID int64) (post *models.PublishedPost, followUps []int64, followUpErr error, err error) {
+	parts := draftParts(state)
+	caption, entities := parts[0].Text, parts[0].Entities
 
 	hasTypePhoto := state.DraftPhotoID != ""
 	hasUserPhoto := state.DraftUserPhotoID != ""
+	opts := models.ParseDeliveryOptions(state.DraftOptions)
+
+	previewURL := ""
+	if state.DraftLayout == models.PostLayoutPreview && !hasUserPhoto {
+		postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get post type: %w", err)
+		}
+		previewURL = postType.PhotoURL
+		hasTypePhoto = false
+	}
 
 	publishedPost := &models.PublishedPost{
-		PostTypeID: state.SelectedTypeID,
-		ChatID:     config.ForumChatID,
-		TopicID:    config.TopicID,
-		Text:       state.DraftText,
-		PhotoID:    state.DraftPhotoID,
-		Entities:   state.DraftEntities,
+		PostTypeID:      state.SelectedTypeID,
+		ChatID:          chatID,
+		TopicID:         topicID,
+		Text:            state.DraftText,
+		PhotoID:         state.DraftPhotoID,
+		Entities:        state.DraftEntities,
+		AuthorID:        authorID,
+		DeliveryOptions: state.DraftOptions,
+		PreviewURL:      previewURL,
+		Frame:           state.DraftFrame,
+	}
+	if previewURL != "" {
+		publishedPost.PhotoID = ""
 	}
 
 	if hasTypePhoto && hasUserPhoto {
 		msgs, sendErr := h.bot.SendMediaGroup(ctx, &bot.SendMediaGroupParams{
-			ChatID:          config.ForumChatID,
-			MessageThreadID: int(config.TopicID),
+			ChatID:          chatID,
+			MessageThreadID: int(topicID),
 			Media: []tgmodels.InputMedia{
 				&tgmodels.InputMediaPhoto{
-					Media:           state.DraftPhotoID,
-					Caption:         state.DraftText,
-					CaptionEntities: entities,
+					Media:                 state.DraftPhotoID,
+					Caption:               caption,
+					CaptionEntities:       entities,
+					ShowCaptionAboveMedia: opts.CaptionAboveMedia,
+					HasSpoiler:            opts.Spoiler,
 				},
 				&tgmodels.InputMediaPhoto{
-					Media: state.DraftUserPhotoID,
+					Media:      state.DraftUserPhotoID,
+					HasSpoiler: opts.Spoiler,
 				},
 			},
+			DisableNotification: opts.Silent,
+			ProtectContent:      opts.Protected,
 		})
 		if sendErr != nil {
 			err = sendErr
@@ -1242,11 +2035,15 @@ func (h *ForumAdminHandler) handlePostConfirmation(ctx context.Context, userID,
 	} else if hasUserPhoto {
 		var publishedMsg *tgmodels.Message
 		publishedMsg, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
-			ChatID:          config.ForumChatID,
-			MessageThreadID: int(config.TopicID),
-			Photo:           &tgmodels.InputFileString{Data: state.DraftUserPhotoID},
-			Caption:         state.DraftText,
-			CaptionEntities: entities,
+			ChatID:                chatID,
+			MessageThreadID:       int(topicID),
+			Photo:                 &tgmodels.InputFileString{Data: state.DraftUserPhotoID},
+			Caption:               caption,
+			CaptionEntities:       entities,
+			ShowCaptionAboveMedia: opts.CaptionAboveMedia,
+			HasSpoiler:            opts.Spoiler,
+			DisableNotification:   opts.Silent,
+			ProtectContent:        opts.Protected,
 		})
 		if err == nil && publishedMsg != nil {
 			publishedPost.MessageID = int64(publishedMsg.ID)
@@ -1255,28 +2052,97 @@ func (h *ForumAdminHandler) handlePostConfirmation(ctx context.Context, userID,
 	} else if hasTypePhoto {
 		var publishedMsg *tgmodels.Message
 		publishedMsg, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
-			ChatID:          config.ForumChatID,
-			MessageThreadID: int(config.TopicID),
-			Photo:           &tgmodels.InputFileString{Data: state.DraftPhotoID},
-			Caption:         state.DraftText,
-			CaptionEntities: entities,
+			ChatID:                chatID,
+			MessageThreadID:       int(topicID),
+			Photo:                 &tgmodels.InputFileString{Data: state.DraftPhotoID},
+			Caption:               caption,
+			CaptionEntities:       entities,
+			ShowCaptionAboveMedia: opts.CaptionAboveMedia,
+			HasSpoiler:            opts.Spoiler,
+			DisableNotification:   opts.Silent,
+			ProtectContent:        opts.Protected,
 		})
 		if err == nil && publishedMsg != nil {
 			publishedPost.MessageID = int64(publishedMsg.ID)
 		}
 	} else {
+		preview := linkPreviewOptions(opts)
+		if previewURL != "" {
+			preview = previewLinkOptions(previewURL, opts)
+		}
 		var publishedMsg *tgmodels.Message
 		publishedMsg, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:          config.ForumChatID,
-			MessageThreadID: int(config.TopicID),
-			Text:            state.DraftText,
-			Entities:        entities,
+			ChatID:              chatID,
+			MessageThreadID:     int(topicID),
+			Text:                caption,
+			Entities:            entities,
+			LinkPreviewOptions:  preview,
+			DisableNotification: opts.Silent,
+			ProtectContent:      opts.Protected,
+		})
+		if err == nil && publishedMsg != nil {
+			publishedPost.MessageID = int64(publishedMsg.ID)
+		}
+	}
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	followUps, followUpErr = h.sendFollowUps(ctx, chatID, topicID, parts[1:], opts)
+	return publishedPost, followUps, followUpErr, nil
+}
+
+func (h *ForumAdminHandler) handlePostConfirmation(ctx context.Context, userID, chatID int64, messageID int) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateNewPostConfirm {
+		log.Printf("[FORUM_ADMIN] Invalid state for confirmation: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Ошибка: неверное состояние",
+		})
+		return
+	}
+	if !h.checkTypeAccess(ctx, userID, chatID, state.SelectedTypeID) {
+		return
+	}
+
+	config, err := h.adminConfigRepo.Get()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get config: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Ошибка получения конфигурации",
+		})
+		return
+	}
+
+	postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Ошибка получения типа поста",
+		})
+		return
+	}
+	// The number is taken before sending: a failed send leaves a gap in the
+	// numbering, never a duplicate.
+	number, err := h.postTypeRepo.TakeNumber(postType.ID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to take post number of type %d: %v", postType.ID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Ошибка получения номера поста",
 		})
-		if err == nil && publishedMsg != nil {
-			publishedPost.MessageID = int64(publishedMsg.ID)
-		}
+		return
 	}
 
+	topicID := config.TopicID
+	if state.DraftTopicID != 0 {
+		topicID = state.DraftTopicID
+	}
+	publishedPost, followUps, followUpErr, err := h.publishDraft(ctx, numberedDraft(state, postType, number), userID, config.ForumChatID, topicID)
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to publish post: %v", err)
 		h.bot.SendMessage(ctx, &bot.SendMessageParams{
@@ -1285,8 +2151,29 @@ func (h *ForumAdminHandler) handlePostConfirmation(ctx context.Context, userID,
 		})
 		return
 	}
+	if followUpErr != nil {
+		log.Printf("[FORUM_ADMIN] Failed to publish post continuation: %v", followUpErr)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("⚠️ Не удалось опубликовать продолжение текста: %v", followUpErr),
+		})
+	}
+
+	if err := h.postTypeRepo.AdvanceImageCursor(state.SelectedTypeID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to advance image cursor of type %d: %v", state.SelectedTypeID, err)
+	}
+
+	publishedPost.Number = number
+	if version, err := h.typeVersionRepo.Current(postType.ID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get version of type %d: %v", postType.ID, err)
+	} else {
+		publishedPost.TypeVersion = int64(version)
+	}
 
 	err = h.publishedPostRepo.Create(publishedPost)
+	if err == nil && len(followUps) > 0 {
+		err = h.publishedPostRepo.SetFollowUps(publishedPost.ID, followUps)
+	}
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to save published post to DB: %v", err)
 		h.adminStateRepo.Clear(userID)
@@ -1312,8 +2199,9 @@ func (h *ForumAdminHandler) handlePostConfirmation(ctx context.Context, userID,
 	}
 
 	h.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: chatID,
-		Text:   "✅ Пост успешно опубликован!",
+		ChatID:             chatID,
+		Text:               withLink("✅ Пост успешно опубликован!", h.postPermalink(ctx, publishedPost)),
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
 	})
 
 	h.showAdminMenu(ctx, chatID, 0)
@@ -1333,24 +2221,19 @@ func (h *ForumAdminHandler) handleEditPostLinkInput(ctx context.Context, msg *tg
 		state.LastBotMessageID = 0
 	}
 
-	if msg.Text == "" {
+	post, errText := h.postFromInput(ctx, msg)
+	if post == nil {
 		h.bot.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: msg.Chat.ID,
-			Text:   "❌ Пожалуйста, отправьте ссылку на пост",
+			Text:   errText,
 		})
 		return
 	}
-
-	post, err := h.postManager.GetPostByLink(msg.Text)
-	if err != nil {
-		log.Printf("[FORUM_ADMIN] Failed to get post by link: %v", err)
-		h.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: msg.Chat.ID,
-			Text:   "❌ Неверный формат ссылки или пост не был создан этим ботом",
-		})
+	if !h.checkPostAccess(ctx, msg.From.ID, msg.Chat.ID, post) {
 		return
 	}
 
+	var err error
 	state.EditingPostID = post.ID
 
 	if post.PhotoID != "" || post.UserPhotoID != "" {
@@ -1389,19 +2272,7 @@ func (h *ForumAdminHandler) handleEditPostLinkInput(ctx context.Context, msg *tg
 		},
 	}
 
-	previewText := fmt.Sprintf("Текущий текст поста:\n\n%s\n\nОтправьте новый текст.", post.Text)
-	var previewEntities []tgmodels.MessageEntity
-	if post.Entities != "" {
-		var entities []tgmodels.MessageEntity
-		if err := json.Unmarshal([]byte(post.Entities), &entities); err == nil {
-			prefix := "Текущий текст поста:\n\n"
-			offset := utf16Length(prefix)
-			for _, e := range entities {
-				e.Offset += offset
-				previewEntities = append(previewEntities, e)
-			}
-		}
-	}
+	previewText, previewEntities := h.editTextPrompt(post)
 
 	sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      msg.Chat.ID,
@@ -1483,6 +2354,11 @@ func (h *ForumAdminHandler) handleNewPostPhotoInput(ctx context.Context, msg *tg
 	photo := msg.Photo[len(msg.Photo)-1]
 	state.DraftUserPhotoID = photo.FileID
 	state.CurrentState = fsm.StateNewPostConfirm
+	caption := "Фото добавлено. Нажмите «Опубликовать» для публикации."
+	if draftLength(state) > services.CaptionLimit && state.DraftLayout != models.PostLayoutSplit {
+		state.DraftLayout = models.PostLayoutSplit
+		caption += fmt.Sprintf("\n\nТекст длиннее %d символов: в подписи будет его начало, продолжение — отдельным сообщением.", services.CaptionLimit)
+	}
 	err := h.adminStateRepo.Save(state)
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
@@ -1493,25 +2369,12 @@ func (h *ForumAdminHandler) handleNewPostPhotoInput(ctx context.Context, msg *tg
 		return
 	}
 
-	addPhotoLabel := "📸 Изменить фото"
-	keyboard := &tgmodels.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
-			{
-				{Text: "✅ Опубликовать", CallbackData: "confirm_post"},
-			},
-			{
-				{Text: addPhotoLabel, CallbackData: "post_add_photo"},
-			},
-			{
-				{Text: "❌ Отмена", CallbackData: "cancel"},
-			},
-		},
-	}
+	keyboard := postConfirmKeyboard(state, "✅ Опубликовать", h.sandboxEnabled(), h.canSwapDraftImage(state))
 
 	_, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
 		ChatID:      msg.Chat.ID,
 		Photo:       &tgmodels.InputFileString{Data: photo.FileID},
-		Caption:     "Фото добавлено. Нажмите «Опубликовать» для публикации.",
+		Caption:     caption,
 		ReplyMarkup: keyboard,
 	})
 	if err != nil {
@@ -1547,6 +2410,9 @@ func (h *ForumAdminHandler) handleEditPostPhotoInput(ctx context.Context, msg *t
 		})
 		return
 	}
+	if !h.checkPostAccess(ctx, msg.From.ID, msg.Chat.ID, post) {
+		return
+	}
 
 	newPhotoID := msg.Photo[len(msg.Photo)-1].FileID
 
@@ -1565,10 +2431,20 @@ func (h *ForumAdminHandler) handleEditPostPhotoInput(ctx context.Context, msg *t
 		}
 	}
 
+	opts := models.ParseDeliveryOptions(post.DeliveryOptions)
+	media := &tgmodels.InputMediaPhoto{Media: newPhotoID, HasSpoiler: opts.Spoiler}
+	if targetMessageID == post.MessageID {
+		// EditMessageMedia replaces the caption too, so send it again.
+		text, entities := postText(post)
+		caption := postParts(post, text, entities)[0]
+		media.Caption = caption.Text
+		media.CaptionEntities = caption.Entities
+		media.ShowCaptionAboveMedia = opts.CaptionAboveMedia
+	}
 	_, err = h.bot.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
 		ChatID:    post.ChatID,
 		MessageID: int(targetMessageID),
-		Media:     &tgmodels.InputMediaPhoto{Media: newPhotoID},
+		Media:     media,
 	})
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to edit photo in Telegram: %v", err)
@@ -1594,6 +2470,7 @@ func (h *ForumAdminHandler) handleEditPostPhotoInput(ctx context.Context, msg *t
 		})
 		return
 	}
+	h.recordPostEvent(models.PostEventPostEdit, msg.From.ID, post.PostTypeID)
 
 	err = h.adminStateRepo.Clear(msg.From.ID)
 	if err != nil {
@@ -1611,17 +2488,6 @@ func (h *ForumAdminHandler) handleEditPostPhotoInput(ctx context.Context, msg *t
 }
 
 func (h *ForumAdminHandler) handleEditPostTextInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
-	if state.LastBotMessageID > 0 {
-		_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
-			ChatID:    msg.Chat.ID,
-			MessageID: state.LastBotMessageID,
-		})
-		if err != nil {
-			log.Printf("[FORUM_ADMIN] Failed to delete prompt message: %v", err)
-		}
-		state.LastBotMessageID = 0
-	}
-
 	if msg.Text == "" {
 		h.bot.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: msg.Chat.ID,
@@ -1639,23 +2505,31 @@ func (h *ForumAdminHandler) handleEditPostTextInput(ctx context.Context, msg *tg
 		})
 		return
 	}
+	if !h.checkPostAccess(ctx, msg.From.ID, msg.Chat.ID, post) {
+		return
+	}
 
-	if post.PhotoID != "" || post.UserPhotoID != "" {
-		_, err = h.bot.EditMessageCaption(ctx, &bot.EditMessageCaptionParams{
-			ChatID:          post.ChatID,
-			MessageID:       int(post.MessageID),
-			Caption:         msg.Text,
-			CaptionEntities: msg.Entities,
-		})
-	} else {
-		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
-			ChatID:    post.ChatID,
-			MessageID: int(post.MessageID),
-			Text:      msg.Text,
-			Entities:  msg.Entities,
+	text, entities, ok := h.parseInput(ctx, msg, h.postInputMode(post))
+	if !ok {
+		return
+	}
+	if n := postOverflow(post, text, entities); n > 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: messageTooLongText(n) + " Отправьте более короткий текст."})
+		return
+	}
+
+	if state.LastBotMessageID > 0 {
+		_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    msg.Chat.ID,
+			MessageID: state.LastBotMessageID,
 		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to delete prompt message: %v", err)
+		}
+		state.LastBotMessageID = 0
 	}
 
+	err = h.editPublishedText(ctx, post, text, entities)
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to edit post in Telegram: %v", err)
 		h.bot.SendMessage(ctx, &bot.SendMessageParams{
@@ -1665,9 +2539,9 @@ func (h *ForumAdminHandler) handleEditPostTextInput(ctx context.Context, msg *tg
 		return
 	}
 
-	post.Text = msg.Text
-	if len(msg.Entities) > 0 {
-		entitiesJSON, _ := json.Marshal(msg.Entities)
+	post.Text = text
+	if len(entities) > 0 {
+		entitiesJSON, _ := json.Marshal(entities)
 		post.Entities = string(entitiesJSON)
 	} else {
 		post.Entities = ""
@@ -1681,6 +2555,7 @@ func (h *ForumAdminHandler) handleEditPostTextInput(ctx context.Context, msg *tg
 		})
 		return
 	}
+	h.recordPostEvent(models.PostEventPostEdit, msg.From.ID, post.PostTypeID)
 
 	err = h.adminStateRepo.Clear(msg.From.ID)
 	if err != nil {
@@ -1688,8 +2563,9 @@ func (h *ForumAdminHandler) handleEditPostTextInput(ctx context.Context, msg *tg
 	}
 
 	h.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: msg.Chat.ID,
-		Text:   "✅ Пост успешно отредактирован!",
+		ChatID:             msg.Chat.ID,
+		Text:               withLink("✅ Пост успешно отредактирован!", h.postPermalink(ctx, post)),
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
 	})
 
 	h.showAdminMenu(ctx, msg.Chat.ID, 0)
@@ -1720,19 +2596,16 @@ func (h *ForumAdminHandler) handleDeletePostLinkInput(ctx context.Context, msg *
 		}
 	}
 
-	if msg.Text == "" {
-		sendError("❌ Пожалуйста, отправьте ссылку на пост")
+	post, errText := h.postFromInput(ctx, msg)
+	if post == nil {
+		sendError(errText)
 		return
 	}
-
-	post, err := h.postManager.GetPostByLink(msg.Text)
-	if err != nil {
-		log.Printf("[FORUM_ADMIN] Failed to get post by link: %v", err)
-		sendError("❌ Неверный формат ссылки или пост не был создан этим ботом")
+	if !h.checkPostAccess(ctx, msg.From.ID, msg.Chat.ID, post) {
 		return
 	}
 
-	_, err = h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+	_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
 		ChatID:    post.ChatID,
 		MessageID: int(post.MessageID),
 	})
@@ -1744,6 +2617,7 @@ func (h *ForumAdminHandler) handleDeletePostLinkInput(ctx context.Context, msg *
 		})
 		return
 	}
+	h.deletePostFollowUps(ctx, post)
 
 	err = h.postManager.DeletePost(ctx, post.ID)
 	if err != nil {
@@ -1754,6 +2628,7 @@ func (h *ForumAdminHandler) handleDeletePostLinkInput(ctx context.Context, msg *
 		})
 		return
 	}
+	h.recordPostEvent(models.PostEventPostDelete, msg.From.ID, post.PostTypeID)
 
 	err = h.adminStateRepo.Clear(msg.From.ID)
 	if err != nil {
@@ -1810,6 +2685,9 @@ func (h *ForumAdminHandler) showPostList(ctx context.Context, chatID int64, mess
 			if postType.Emoji != "" {
 				typeLabel = postType.Emoji + " " + postType.Name
 			}
+			if number := postNumberLabel(post, postType); number != "" {
+				typeLabel += " " + number
+			}
 			buttonText = fmt.Sprintf("%s — %s", typeLabel, post.CreatedAt.Format("02.01.06 15:04"))
 		} else {
 			buttonText = fmt.Sprintf("#%d — %s", post.ID, post.CreatedAt.Format("02.01.06 15:04"))
@@ -1838,6 +2716,11 @@ func (h *ForumAdminHandler) showPostList(ctx context.Context, chatID int64, mess
 		})
 	}
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, navRow)
+	if total > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
+			{Text: "🔎 Найти по номеру", CallbackData: "post_search"},
+		})
+	}
 
 	if messageID > 0 {
 		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
@@ -1893,6 +2776,7 @@ func (h *ForumAdminHandler) showPostDetails(ctx context.Context, userID, chatID
 		}
 	} else {
 		typeLabel = fmt.Sprintf("ID %d", post.PostTypeID)
+		postType = nil
 	}
 
 	preview := post.Text
@@ -1908,13 +2792,29 @@ func (h *ForumAdminHandler) showPostDetails(ctx context.Context, userID, chatID
 		photoNote = "\n📷 + дополнительное фото"
 	}
 
-	text := fmt.Sprintf("Пост #%d\nТип: %s\nДата: %s%s\n\nТекст:\n%s",
+	text := fmt.Sprintf("Пост #%d\nТип: %s\nДата: %s",
 		post.ID,
 		typeLabel,
 		post.CreatedAt.Format("02.01.2006 15:04"),
-		photoNote,
-		preview,
 	)
+	if number := postNumberLabel(post, postType); number != "" {
+		text += "\nНомер: " + number
+	}
+	var entities []tgmodels.MessageEntity
+	if post.AuthorID != 0 {
+		var mention tgmodels.MessageEntity
+		text, mention = h.userManager.Mention(text+"\nАвтор: ", post.AuthorID)
+		entities = append(entities, mention)
+	}
+	if count, err := h.commentRepo.CountByPost(post.ID); err == nil && count > 0 {
+		text += fmt.Sprintf("\n💬 Комментарии: %d", count)
+	}
+	if post.DeliveryOptions != "" {
+		kind := postKind(post.PhotoID != "" || post.UserPhotoID != "")
+		text += "\n📬 Отправка: " + deliveryOptionsSummary(models.ParseDeliveryOptions(post.DeliveryOptions), kind)
+	}
+	text = withLink(text, h.postPermalink(ctx, post))
+	text += photoNote + "\n\nТекст:\n" + preview
 
 	// Build action keyboard (skip the separate "details" screen)
 	rows := [][]tgmodels.InlineKeyboardButton{
@@ -1930,6 +2830,11 @@ func (h *ForumAdminHandler) showPostDetails(ctx context.Context, userID, chatID
 		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "📸 Изменить фото", CallbackData: "edit_post_photo"}})
 	}
 	rows = append(rows,
+		[]tgmodels.InlineKeyboardButton{
+			{Text: "🧾 HTML", CallbackData: fmt.Sprintf("post_source:%d:%s", post.ID, models.InputModeHTML)},
+			{Text: "🧾 Markdown", CallbackData: fmt.Sprintf("post_source:%d:%s", post.ID, models.InputModeMarkdown)},
+		},
+		[]tgmodels.InlineKeyboardButton{{Text: "📄 Дублировать", CallbackData: fmt.Sprintf("post_duplicate:%d", post.ID)}},
 		[]tgmodels.InlineKeyboardButton{{Text: "🗑 Удалить", CallbackData: fmt.Sprintf("post_list_delete:%d:%d", post.ID, page)}},
 		[]tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: fmt.Sprintf("post_list_page:%d", page)}},
 	)
@@ -1951,19 +2856,22 @@ func (h *ForumAdminHandler) showPostDetails(ctx context.Context, userID, chatID
 
 	if photoID != "" {
 		_, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
-			ChatID:      chatID,
-			Photo:       &tgmodels.InputFileString{Data: photoID},
-			Caption:     text,
-			ReplyMarkup: keyboard,
+			ChatID:          chatID,
+			Photo:           &tgmodels.InputFileString{Data: photoID},
+			Caption:         text,
+			CaptionEntities: entities,
+			ReplyMarkup:     keyboard,
 		})
 		if err != nil {
 			log.Printf("[FORUM_ADMIN] Failed to send post details photo: %v", err)
 		}
 	} else {
 		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:      chatID,
-			Text:        text,
-			ReplyMarkup: keyboard,
+			ChatID:             chatID,
+			Text:               text,
+			Entities:           entities,
+			LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
+			ReplyMarkup:        keyboard,
 		})
 		if err != nil {
 			log.Printf("[FORUM_ADMIN] Failed to send post details: %v", err)
@@ -2026,6 +2934,9 @@ func (h *ForumAdminHandler) handleEditPostFromList(ctx context.Context, userID,
 		})
 		return
 	}
+	if !h.checkPostAccess(ctx, userID, chatID, post) {
+		return
+	}
 
 	newState := &models.AdminState{
 		UserID:        userID,
@@ -2059,19 +2970,7 @@ func (h *ForumAdminHandler) handleEditPostFromList(ctx context.Context, userID,
 		return
 	}
 
-	previewText := fmt.Sprintf("Текущий текст поста:\n\n%s\n\nОтправьте новый текст.", post.Text)
-	var previewEntities []tgmodels.MessageEntity
-	if post.Entities != "" {
-		var entities []tgmodels.MessageEntity
-		if err := json.Unmarshal([]byte(post.Entities), &entities); err == nil {
-			prefix := "Текущий текст поста:\n\n"
-			offset := utf16Length(prefix)
-			for _, e := range entities {
-				e.Offset += offset
-				previewEntities = append(previewEntities, e)
-			}
-		}
-	}
+	previewText, previewEntities := h.editTextPrompt(post)
 
 	keyboard := &tgmodels.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
@@ -2107,7 +3006,11 @@ func (h *ForumAdminHandler) handleDeletePostFromList(ctx context.Context, userID
 		})
 		return
 	}
+	if !h.checkPostAccess(ctx, userID, chatID, post) {
+		return
+	}
 
+	h.deletePostFollowUps(ctx, post)
 	_, err = h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
 		ChatID:    post.ChatID,
 		MessageID: int(post.MessageID),
@@ -2126,6 +3029,7 @@ func (h *ForumAdminHandler) handleDeletePostFromList(ctx context.Context, userID
 		})
 		return
 	}
+	h.recordPostEvent(models.PostEventPostDelete, userID, post.PostTypeID)
 
 	log.Printf("[FORUM_ADMIN] Post %d deleted from list by user %d", postID, userID)
 
@@ -2211,37 +3115,37 @@ func (h *ForumAdminHandler) handleReplyLinkInput(ctx context.Context, msg *tgmod
 		return
 	}
 
-	chatID, messageID, threadID, err := h.postManager.ParsePostLinkFull(msg.Text)
-	if err != nil {
-		log.Printf("[FORUM_ADMIN] Failed to parse reply link: %v", err)
-		sendError("❌ Неверный формат ссылки. Используйте ссылку вида https://t.me/c/<chat>/<message>")
+	target, errText := h.resolveLink(ctx, msg.Text)
+	if target == nil {
+		sendError(errText)
 		return
 	}
 
-	if chatID == 0 {
-		config, err := h.adminConfigRepo.Get()
-		if err == nil {
-			chatID = config.ForumChatID
-		}
-	}
-
-	state.ReplyTargetChatID = chatID
-	state.ReplyTargetMessageID = messageID
-	state.TempName = fmt.Sprintf("%d", threadID)
+	state.ReplyTargetChatID = target.ChatID
+	state.ReplyTargetMessageID = target.MessageID
+	state.TempName = fmt.Sprintf("%d", target.ThreadID)
 	state.CurrentState = fsm.StateReplyEnterText
-	if err = h.adminStateRepo.Save(state); err != nil {
+	if err := h.adminStateRepo.Save(state); err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
 		return
 	}
 
+	h.sendReplyTextPrompt(ctx, msg.Chat.ID, state)
+}
+
+// sendReplyTextPrompt asks for the reply text and offers saved templates.
+func (h *ForumAdminHandler) sendReplyTextPrompt(ctx context.Context, chatID int64, state *models.AdminState) {
+	prompt := "Отправьте текст ответа. Можно прикрепить фото к сообщению."
+	rows := h.replyTemplateButtons()
+	if len(rows) > 0 {
+		prompt += "\n\nИли выберите шаблон:"
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "❌ Отмена", CallbackData: "cancel"}})
+
 	sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: msg.Chat.ID,
-		Text:   "Отправьте текст ответа. Можно прикрепить фото к сообщению.",
-		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
-			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
-				{{Text: "❌ Отмена", CallbackData: "cancel"}},
-			},
-		},
+		ChatID:      chatID,
+		Text:        prompt,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
 	})
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to send reply text prompt: %v", err)
@@ -2257,13 +3161,10 @@ func (h *ForumAdminHandler) handleReplyTextInput(ctx context.Context, msg *tgmod
 		state.LastBotMessageID = 0
 	}
 
-	text := msg.Text
-	photoID := ""
-	if msg.Photo != nil && len(msg.Photo) > 0 {
-		photoID = msg.Photo[len(msg.Photo)-1].FileID
-		if text == "" {
-			text = msg.Caption
-		}
+	text, photoID, entities := messageContent(msg)
+	if photoID == "" && state.ReplyTemplateID != 0 {
+		// An edited template keeps its photo unless a new one is attached.
+		photoID = state.DraftPhotoID
 	}
 
 	if text == "" && photoID == "" {
@@ -2283,11 +3184,12 @@ func (h *ForumAdminHandler) handleReplyTextInput(ctx context.Context, msg *tgmod
 		return
 	}
 
-	entities := msg.Entities
-	if photoID != "" {
-		entities = msg.CaptionEntities
-	}
+	h.saveReplyDraft(ctx, msg.Chat.ID, state, text, photoID, entities)
+}
 
+// saveReplyDraft stores the reply draft and sends its preview with the
+// confirmation keyboard.
+func (h *ForumAdminHandler) saveReplyDraft(ctx context.Context, chatID int64, state *models.AdminState, text, photoID string, entities []tgmodels.MessageEntity) {
 	state.DraftText = text
 	state.DraftPhotoID = photoID
 	if len(entities) > 0 {
@@ -2307,24 +3209,26 @@ func (h *ForumAdminHandler) handleReplyTextInput(ctx context.Context, msg *tgmod
 
 	var previewEntities []tgmodels.MessageEntity
 	if len(entities) > 0 {
-		offset := utf16Length(previewPrefix)
+		offset := services.UTF16Length(previewPrefix)
 		for _, e := range entities {
 			e.Offset += offset
 			previewEntities = append(previewEntities, e)
 		}
 	}
 
-	keyboard := &tgmodels.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
-			{{Text: "✅ Подтвердить", CallbackData: "confirm_reply"}},
-			{{Text: "❌ Отмена", CallbackData: "cancel"}},
-		},
+	rows := [][]tgmodels.InlineKeyboardButton{
+		{{Text: "✅ Подтвердить", CallbackData: "confirm_reply"}},
 	}
+	if state.ReplyTemplateID != 0 {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "✏️ Изменить", CallbackData: "reply_tpl_edit_draft"}})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "❌ Отмена", CallbackData: "cancel"}})
+	keyboard := &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
 
 	var err error
 	if photoID != "" {
 		_, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
-			ChatID:          msg.Chat.ID,
+			ChatID:          chatID,
 			Photo:           &tgmodels.InputFileString{Data: photoID},
 			Caption:         previewText,
 			CaptionEntities: previewEntities,
@@ -2332,7 +3236,7 @@ func (h *ForumAdminHandler) handleReplyTextInput(ctx context.Context, msg *tgmod
 		})
 	} else {
 		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:      msg.Chat.ID,
+			ChatID:      chatID,
 			Text:        previewText,
 			Entities:    previewEntities,
 			ReplyMarkup: keyboard,
@@ -2418,10 +3322,16 @@ func (h *ForumAdminHandler) handleReplyConfirmation(ctx context.Context, userID,
 		Text:             state.DraftText,
 		PhotoID:          state.DraftPhotoID,
 		Entities:         state.DraftEntities,
+		AuthorID:         userID,
 	}
 	if err := h.replyRepo.Create(reply); err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to save reply to DB: %v", err)
 	}
+	if state.ReplyTemplateID != 0 {
+		if err := h.replyTemplateRepo.IncrementUsage(state.ReplyTemplateID); err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to count reply template usage: %v", err)
+		}
+	}
 
 	h.adminStateRepo.Clear(userID)
 	if messageID > 0 {
@@ -2434,8 +3344,9 @@ func (h *ForumAdminHandler) handleReplyConfirmation(ctx context.Context, userID,
 	}
 
 	h.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: chatID,
-		Text:   "✅ Ответ успешно отправлен!",
+		ChatID:             chatID,
+		Text:               withLink("✅ Ответ успешно отправлен!", h.replyPermalink(ctx, reply)),
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
 	})
 	h.showAdminMenu(ctx, chatID, 0)
 
@@ -2549,20 +3460,33 @@ func (h *ForumAdminHandler) showReplyDetails(ctx context.Context, chatID int64,
 		}
 	}
 
-	prefix := fmt.Sprintf("Ответ #%d\nДата: %s\n\nТекст:\n",
+	header := fmt.Sprintf("Ответ #%d\nДата: %s",
 		reply.ID,
 		reply.CreatedAt.Format("02.01.2006 15:04"),
 	)
+	var headerEntities []tgmodels.MessageEntity
+	if reply.AuthorID != 0 {
+		var mention tgmodels.MessageEntity
+		header, mention = h.userManager.Mention(header+"\nАвтор: ", reply.AuthorID)
+		headerEntities = append(headerEntities, mention)
+	}
+
+	if count, err := h.commentRepo.CountByReply(reply.ID); err == nil && count > 0 {
+		header += fmt.Sprintf("\n💬 Комментарии: %d", count)
+	}
+	header = withLink(header, h.replyPermalink(ctx, reply))
+
+	prefix := header + "\n\nТекст:\n"
 	text := prefix + displayText
 
-	var previewEntities []tgmodels.MessageEntity
+	previewEntities := append([]tgmodels.MessageEntity{}, headerEntities...)
 	if reply.Entities != "" {
 		var storedEntities []tgmodels.MessageEntity
 		if err := json.Unmarshal([]byte(reply.Entities), &storedEntities); err != nil {
 			log.Printf("[FORUM_ADMIN] Failed to parse reply entities for %d: %v", reply.ID, err)
 		} else {
-			prefixOffset := utf16Length(prefix)
-			textLen := utf16Length(displayText)
+			prefixOffset := services.UTF16Length(prefix)
+			textLen := services.UTF16Length(displayText)
 			for _, e := range storedEntities {
 				if e.Length <= 0 || e.Offset < 0 || e.Offset >= textLen {
 					continue
@@ -2585,10 +3509,7 @@ func (h *ForumAdminHandler) showReplyDetails(ctx context.Context, chatID int64,
 	}
 
 	if reply.PhotoID != "" {
-		captionPrefix := fmt.Sprintf("Ответ #%d\nДата: %s\n\nПодпись:\n",
-			reply.ID,
-			reply.CreatedAt.Format("02.01.2006 15:04"),
-		)
+		captionPrefix := header + "\n\nПодпись:\n"
 		caption := captionPrefix
 		if strings.TrimSpace(reply.Text) != "" {
 			caption += reply.Text
@@ -2596,14 +3517,14 @@ func (h *ForumAdminHandler) showReplyDetails(ctx context.Context, chatID int64,
 			caption += "—"
 		}
 
-		var captionEntities []tgmodels.MessageEntity
+		captionEntities := append([]tgmodels.MessageEntity{}, headerEntities...)
 		if reply.Entities != "" {
 			var storedEntities []tgmodels.MessageEntity
 			if err := json.Unmarshal([]byte(reply.Entities), &storedEntities); err != nil {
 				log.Printf("[FORUM_ADMIN] Failed to parse reply entities for %d: %v", reply.ID, err)
 			} else {
-				prefixOffset := utf16Length(captionPrefix)
-				textLen := utf16Length(reply.Text)
+				prefixOffset := services.UTF16Length(captionPrefix)
+				textLen := services.UTF16Length(reply.Text)
 				for _, e := range storedEntities {
 					if e.Length <= 0 || e.Offset < 0 || e.Offset >= textLen {
 						continue
@@ -2633,26 +3554,29 @@ func (h *ForumAdminHandler) showReplyDetails(ctx context.Context, chatID int64,
 	} else {
 		if messageID > 0 {
 			_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
-				ChatID:      chatID,
-				MessageID:   messageID,
-				Text:        text,
-				Entities:    previewEntities,
-				ReplyMarkup: keyboard,
+				ChatID:             chatID,
+				MessageID:          messageID,
+				Text:               text,
+				Entities:           previewEntities,
+				LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
+				ReplyMarkup:        keyboard,
 			})
 			if err != nil {
 				_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
-					ChatID:      chatID,
-					Text:        text,
-					Entities:    previewEntities,
-					ReplyMarkup: keyboard,
+					ChatID:             chatID,
+					Text:               text,
+					Entities:           previewEntities,
+					LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
+					ReplyMarkup:        keyboard,
 				})
 			}
 		} else {
 			_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID:      chatID,
-				Text:        text,
-				Entities:    previewEntities,
-				ReplyMarkup: keyboard,
+				ChatID:             chatID,
+				Text:               text,
+				Entities:           previewEntities,
+				LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
+				ReplyMarkup:        keyboard,
 			})
 		}
 	}
@@ -2721,11 +3645,12 @@ func (h *ForumAdminHandler) handleEditReplyFromList(ctx context.Context, userID,
 		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
 	}
 
-	keyboard := &tgmodels.InlineKeyboardMarkup{
-		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
-			{{Text: "❌ Отмена", CallbackData: "cancel"}},
-		},
+	var rows [][]tgmodels.InlineKeyboardButton
+	if reply.PhotoID != "" {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "🗑 Убрать фото", CallbackData: "edit_reply_remove_photo"}})
 	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "❌ Отмена", CallbackData: "cancel"}})
+	keyboard := &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
 
 	var sentMsg *tgmodels.Message
 	if reply.PhotoID != "" {
@@ -2733,13 +3658,13 @@ func (h *ForumAdminHandler) handleEditReplyFromList(ctx context.Context, userID,
 		if reply.Text != "" {
 			previewCaption += reply.Text + "\n\n"
 		}
-		previewCaption += "Отправьте новый текст или фото с подписью."
+		previewCaption += "Отправьте новый текст, чтобы изменить подпись, или фото с подписью, чтобы заменить изображение."
 
 		var previewCaptionEntities []tgmodels.MessageEntity
 		if reply.Entities != "" {
 			var ents []tgmodels.MessageEntity
 			if err := json.Unmarshal([]byte(reply.Entities), &ents); err == nil {
-				off := utf16Length("Текущий ответ (изображение):\n\n")
+				off := services.UTF16Length("Текущий ответ (изображение):\n\n")
 				for _, e := range ents {
 					e.Offset += off
 					previewCaptionEntities = append(previewCaptionEntities, e)
@@ -2755,13 +3680,13 @@ func (h *ForumAdminHandler) handleEditReplyFromList(ctx context.Context, userID,
 			ReplyMarkup:     keyboard,
 		})
 	} else {
-		previewText := fmt.Sprintf("Текущий текст ответа:\n\n%s\n\nОтправьте новый текст или фото с подписью.", reply.Text)
+		previewText := fmt.Sprintf("Текущий текст ответа:\n\n%s\n\nОтправьте новый текст или фото с подписью, чтобы добавить изображение.", reply.Text)
 		var previewEntities []tgmodels.MessageEntity
 		if reply.Entities != "" {
 			var ents []tgmodels.MessageEntity
 			if err := json.Unmarshal([]byte(reply.Entities), &ents); err == nil {
 				prefix := "Текущий текст ответа:\n\n"
-				off := utf16Length(prefix)
+				off := services.UTF16Length(prefix)
 				for _, e := range ents {
 					e.Offset += off
 					previewEntities = append(previewEntities, e)
@@ -2816,14 +3741,9 @@ func (h *ForumAdminHandler) handleEditReplyTextInput(ctx context.Context, msg *t
 		return
 	}
 
-	if newPhotoID != "" {
-		if reply.PhotoID == "" {
-			h.bot.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: msg.Chat.ID,
-				Text:   "❌ Нельзя заменить текстовый ответ на фото. Удалите этот ответ и создайте новый.",
-			})
-			return
-		}
+	if newPhotoID != "" && reply.PhotoID == "" {
+		err = h.resendReply(ctx, reply, text, newPhotoID, entities)
+	} else if newPhotoID != "" {
 		_, err = h.bot.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
 			ChatID:    reply.ChatID,
 			MessageID: int(reply.MessageID),
@@ -2869,10 +3789,15 @@ func (h *ForumAdminHandler) handleEditReplyTextInput(ctx context.Context, msg *t
 		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения изменений"})
 		return
 	}
+	h.recordPostEvent(models.PostEventReplyEdit, state.UserID, 0)
 
 	h.adminStateRepo.Clear(state.UserID)
 
-	h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "✅ Ответ успешно отредактирован!"})
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             msg.Chat.ID,
+		Text:               withLink("✅ Ответ успешно отредактирован!", h.replyPermalink(ctx, reply)),
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
+	})
 	h.showAdminMenu(ctx, msg.Chat.ID, 0)
 
 	log.Printf("[FORUM_ADMIN] Reply %d edited by user %d", reply.ID, state.UserID)
@@ -2903,6 +3828,7 @@ func (h *ForumAdminHandler) handleDeleteReplyFromList(ctx context.Context, userI
 		})
 		return
 	}
+	h.recordPostEvent(models.PostEventReplyDelete, userID, 0)
 
 	log.Printf("[FORUM_ADMIN] Reply %d deleted from list by user %d", replyID, userID)
 	h.bot.SendMessage(ctx, &bot.SendMessageParams{
@@ -3300,6 +4226,9 @@ func (h *ForumAdminHandler) handleManageTypesMenu(ctx context.Context, chatID in
 	if len(allTypes) == 0 {
 		keyboard := &tgmodels.InlineKeyboardMarkup{
 			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{
+					{Text: "📥 Импорт", CallbackData: "types_import"},
+				},
 				{
 					{Text: "← Назад", CallbackData: "admin_settings"},
 				},
@@ -3327,6 +4256,9 @@ func (h *ForumAdminHandler) handleManageTypesMenu(ctx context.Context, chatID in
 		if pt.Emoji != "" {
 			buttonText = fmt.Sprintf("%s %s %s", statusIcon, pt.Emoji, pt.Name)
 		}
+		if pt.Category != "" {
+			buttonText += fmt.Sprintf(" (📁 %s)", pt.Category)
+		}
 		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
 			{
 				Text:         buttonText,
@@ -3335,9 +4267,18 @@ func (h *ForumAdminHandler) handleManageTypesMenu(ctx context.Context, chatID in
 		})
 	}
 
-	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
-		{Text: "← Назад", CallbackData: "admin_settings"},
-	})
+	columnsText := "▦ Две колонки при выборе: выкл"
+	if h.typePickerTwoColumns() {
+		columnsText = "▦ Две колонки при выборе: вкл"
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard,
+		[]tgmodels.InlineKeyboardButton{{Text: columnsText, CallbackData: "types_two_columns"}},
+		[]tgmodels.InlineKeyboardButton{
+			{Text: "📤 Экспорт", CallbackData: "types_export"},
+			{Text: "📥 Импорт", CallbackData: "types_import"},
+		},
+		[]tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "admin_settings"}},
+	)
 
 	text := "Выберите тип для управления:"
 
@@ -3385,6 +4326,18 @@ func (h *ForumAdminHandler) handleTypeManagementOptions(ctx context.Context, use
 	if !postType.IsActive {
 		toggleText = "🟢 Включить"
 	}
+	categoryLabel := "нет"
+	if postType.Category != "" {
+		categoryLabel = postType.Category
+	}
+	accessLabel := "все"
+	if ids := postType.AllowedAdminIDs(); len(ids) > 0 {
+		accessLabel = fmt.Sprintf("%d адм.", len(ids))
+	}
+	counterLabel := "выкл"
+	if postType.HasCounter() {
+		counterLabel = postType.CounterFormat
+	}
 
 	keyboard := &tgmodels.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
@@ -3397,12 +4350,47 @@ func (h *ForumAdminHandler) handleTypeManagementOptions(ctx context.Context, use
 			{
 				{Text: "🖼 Заменить изображение", CallbackData: fmt.Sprintf("edit_type_image:%d", typeID)},
 			},
+			{
+				{Text: "🎞 Пул изображений", CallbackData: fmt.Sprintf("type_images:%d", typeID)},
+			},
 			{
 				{Text: "📄 Заменить шаблон", CallbackData: fmt.Sprintf("edit_type_template:%d", typeID)},
 			},
+			{
+				{Text: "🔝 Шапка", CallbackData: fmt.Sprintf("edit_type_header:%d", typeID)},
+				{Text: "🔚 Подвал", CallbackData: fmt.Sprintf("edit_type_footer:%d", typeID)},
+			},
+			{
+				{Text: "🔢 Нумерация: " + counterLabel, CallbackData: fmt.Sprintf("type_counter:%d", typeID)},
+			},
+			{
+				{Text: "🕘 Версии и обновление постов", CallbackData: fmt.Sprintf("type_versions:%d", typeID)},
+			},
+			{
+				{Text: "🔗 Ссылка для превью", CallbackData: fmt.Sprintf("edit_type_photo_url:%d", typeID)},
+			},
+			{
+				{Text: "📬 Параметры отправки", CallbackData: fmt.Sprintf("type_options:%d", typeID)},
+			},
+			{
+				{Text: "🔤 Ввод: " + inputModeLabel(postType.InputMode), CallbackData: fmt.Sprintf("type_input_mode:%d", typeID)},
+			},
+			{
+				{Text: "📁 Категория: " + categoryLabel, CallbackData: fmt.Sprintf("edit_type_category:%d", typeID)},
+			},
+			{
+				{Text: "🔐 Доступ: " + accessLabel, CallbackData: fmt.Sprintf("type_access:%d", typeID)},
+			},
+			{
+				{Text: "⬆️ Выше", CallbackData: fmt.Sprintf("type_move:%d:-1", typeID)},
+				{Text: "⬇️ Ниже", CallbackData: fmt.Sprintf("type_move:%d:1", typeID)},
+			},
 			{
 				{Text: toggleText, CallbackData: fmt.Sprintf("toggle_type_active:%d", typeID)},
 			},
+			{
+				{Text: "🗑 Удалить тип", CallbackData: fmt.Sprintf("delete_type:%d", typeID)},
+			},
 			{
 				{Text: "← Назад", CallbackData: "settings_manage_types"},
 			},
@@ -3721,7 +4709,17 @@ func (h *ForumAdminHandler) handleEditTypeImageInput(ctx context.Context, msg *t
 		state.LastBotMessageID = 0
 	}
 
-	err := h.postTypeManager.UpdateTypePhoto(state.EditingTypeID, photoID)
+	prev, err := h.postTypeRepo.GetByID(state.EditingTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Ошибка получения типа поста",
+		})
+		return
+	}
+
+	err = h.postTypeManager.UpdateTypePhoto(state.EditingTypeID, photoID)
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to update type photo: %v", err)
 		h.bot.SendMessage(ctx, &bot.SendMessageParams{
@@ -3736,10 +4734,8 @@ func (h *ForumAdminHandler) handleEditTypeImageInput(ctx context.Context, msg *t
 		log.Printf("[FORUM_ADMIN] Failed to clear state: %v", err)
 	}
 
-	h.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: msg.Chat.ID,
-		Text:   "✅ Изображение типа обновлено!",
-	})
+	h.recordTypeVersion(prev, msg.From.ID)
+	h.sendTypeUpdated(ctx, msg.Chat.ID, state.EditingTypeID, "✅ Изображение типа обновлено!")
 
 	h.showAdminMenu(ctx, msg.Chat.ID, 0)
 
@@ -3773,7 +4769,15 @@ func (h *ForumAdminHandler) handleEditTypeTemplateStart(ctx context.Context, use
 		return
 	}
 
-	text := fmt.Sprintf("Текущий шаблон для типа \"%s\":\n\n<pre>%s</pre>\n\nВведите новый шаблон.", postType.Name, postType.Template)
+	var templateEntities []tgmodels.MessageEntity
+	if postType.TemplateEntities != "" {
+		json.Unmarshal([]byte(postType.TemplateEntities), &templateEntities)
+	}
+	template := services.RenderMarkup(postType.InputMode, postType.Template, templateEntities)
+	text := fmt.Sprintf("Текущий шаблон для типа \"%s\":\n\n%s\n\nВведите новый шаблон.", html.EscapeString(postType.Name), services.FormatCode(template))
+	if postType.InputMode != models.InputModeEntities {
+		text += fmt.Sprintf(" Шаблон принимается в формате %s.", inputModeLabel(postType.InputMode))
+	}
 
 	keyboard := &tgmodels.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
@@ -3833,9 +4837,15 @@ func (h *ForumAdminHandler) handleEditTypeTemplateInput(ctx context.Context, msg
 		return
 	}
 
-	postType.Template = msg.Text
-	if len(msg.Entities) > 0 {
-		entitiesJSON, _ := json.Marshal(msg.Entities)
+	template, templateEntities, ok := h.parseInput(ctx, msg, postType.InputMode)
+	if !ok {
+		return
+	}
+
+	prev := *postType
+	postType.Template = template
+	if len(templateEntities) > 0 {
+		entitiesJSON, _ := json.Marshal(templateEntities)
 		postType.TemplateEntities = string(entitiesJSON)
 	} else {
 		postType.TemplateEntities = ""
@@ -3856,10 +4866,8 @@ func (h *ForumAdminHandler) handleEditTypeTemplateInput(ctx context.Context, msg
 		log.Printf("[FORUM_ADMIN] Failed to clear state: %v", err)
 	}
 
-	h.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: msg.Chat.ID,
-		Text:   "✅ Шаблон типа обновлен!",
-	})
+	h.recordTypeVersion(&prev, msg.From.ID)
+	h.sendTypeUpdated(ctx, msg.Chat.ID, postType.ID, "✅ Шаблон типа обновлен!")
 
 	h.showAdminMenu(ctx, msg.Chat.ID, 0)
 
@@ -3918,16 +4926,7 @@ func (h *ForumAdminHandler) showAccessSettingsMenu(ctx context.Context, chatID i
 		return
 	}
 
-	adminIDsStr := ""
-	for i, id := range config.AdminIDs {
-		if i > 0 {
-			adminIDsStr += ", "
-		}
-		adminIDsStr += strconv.FormatInt(id, 10)
-	}
-	if adminIDsStr == "" {
-		adminIDsStr = "не настроены"
-	}
+	adminsStr := h.formatAdminListHTML(config.AdminIDs)
 
 	forumIDStr := strconv.FormatInt(config.ForumChatID, 10)
 	if config.ForumChatID == 0 {
@@ -3940,16 +4939,17 @@ func (h *ForumAdminHandler) showAccessSettingsMenu(ctx context.Context, chatID i
 	}
 
 	text := fmt.Sprintf("Настройки доступа:\n\n"+
-		"👥 ID администраторов: %s\n"+
+		"👥 Администраторы:\n%s\n"+
 		"💬 ID целевой группы: %s\n"+
-		"📌 ID топика: %s\n\n"+
+		"📌 ID топика: %s\n"+
+		"🧪 Песочница: %s\n\n"+
 		"Выберите настройку для изменения:",
-		adminIDsStr, forumIDStr, topicIDStr)
+		adminsStr, forumIDStr, topicIDStr, sandboxLabel(config))
 
 	keyboard := &tgmodels.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
 			{
-				{Text: "👥 ID администраторов", CallbackData: "access_edit_admins"},
+				{Text: "👥 Администраторы", CallbackData: "access_admins"},
 			},
 			{
 				{Text: "💬 ID целевой группы", CallbackData: "access_edit_forum"},
@@ -3958,16 +4958,28 @@ func (h *ForumAdminHandler) showAccessSettingsMenu(ctx context.Context, chatID i
 				{Text: "📌 ID топика", CallbackData: "access_edit_topic"},
 			},
 			{
-				{Text: "← Назад", CallbackData: "admin_settings"},
+				{Text: "🧪 Песочница", CallbackData: "access_edit_sandbox"},
 			},
 		},
 	}
+	if count, err := h.sandboxMessageRepo.Count(); err == nil && count > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
+			{Text: fmt.Sprintf("🧹 Очистить песочницу (%d)", count), CallbackData: "sandbox_clean"},
+		})
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgmodels.InlineKeyboardButton{
+		{Text: "← Назад", CallbackData: "admin_settings"},
+	})
 
 	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      chatID,
 		MessageID:   messageID,
 		Text:        text,
+		ParseMode:   tgmodels.ParseModeHTML,
 		ReplyMarkup: keyboard,
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{
+			IsDisabled: bot.True(),
+		},
 	})
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to send access settings menu: %v", err)
@@ -4002,19 +5014,8 @@ func (h *ForumAdminHandler) handleEditAdminIDsStart(ctx context.Context, userID,
 		return
 	}
 
-	adminIDsStr := ""
-	for i, id := range config.AdminIDs {
-		if i > 0 {
-			adminIDsStr += ", "
-		}
-		adminIDsStr += strconv.FormatInt(id, 10)
-	}
-	if adminIDsStr == "" {
-		adminIDsStr = "не настроены"
-	}
-
-	text := fmt.Sprintf("Текущие ID администраторов: %s\n\n"+
-		"Отправьте ID администраторов через запятую (например: 123456789, 987654321)", adminIDsStr)
+	text := fmt.Sprintf("Текущие администраторы:\n%s\n"+
+		"Отправьте ID администраторов через запятую (например: 123456789, 987654321)", h.formatAdminListHTML(config.AdminIDs))
 
 	keyboard := &tgmodels.InlineKeyboardMarkup{
 		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
@@ -4028,7 +5029,11 @@ func (h *ForumAdminHandler) handleEditAdminIDsStart(ctx context.Context, userID,
 		ChatID:      chatID,
 		MessageID:   messageID,
 		Text:        text,
+		ParseMode:   tgmodels.ParseModeHTML,
 		ReplyMarkup: keyboard,
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{
+			IsDisabled: bot.True(),
+		},
 	})
 	if err != nil {
 		log.Printf("[FORUM_ADMIN] Failed to send edit admin IDs prompt: %v", err)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/ad/go-telegram-admin/internal/db"
 	"github.com/ad/go-telegram-admin/internal/fsm"
@@ -32,12 +33,21 @@ func setupForumAdminHandler(t *testing.T) (*ForumAdminHandler, *sql.DB) {
 	publishedPostRepo := db.NewPublishedPostRepository(queue)
 	replyRepo := db.NewReplyRepository(queue)
 	adminStateRepo := db.NewAdminStateRepository(queue)
+	userRepo := db.NewUserRepository(queue)
+	adminPINRepo := db.NewAdminPINRepository(queue)
+	replyTemplateRepo := db.NewReplyTemplateRepository(queue)
+	commentRepo := db.NewCommentRepository(queue)
+	ticketRepo := db.NewTicketRepository(queue)
+	typeVersionRepo := db.NewPostTypeVersionRepository(queue)
 
 	authMiddleware := services.NewAdminAuthMiddleware(adminConfigRepo)
 	postManager := services.NewPostManager(publishedPostRepo, postTypeRepo, adminConfigRepo)
 	postTypeManager := services.NewPostTypeManager(postTypeRepo)
 	settingsManager := services.NewSettingsManager(adminConfigRepo)
 	backupManager := services.NewBackupManager(nil, ":memory:", queue)
+	userManager := services.NewUserManager(nil, userRepo, adminConfigRepo)
+	commentManager := services.NewCommentManager(commentRepo, publishedPostRepo, replyRepo, adminConfigRepo)
+	confirmationGuard := services.NewConfirmationGuard(adminPINRepo, 15*time.Minute, false)
 
 	handler := NewForumAdminHandler(
 		nil,
@@ -51,6 +61,20 @@ func setupForumAdminHandler(t *testing.T) (*ForumAdminHandler, *sql.DB) {
 		postTypeManager,
 		settingsManager,
 		backupManager,
+		userManager,
+		confirmationGuard,
+		replyTemplateRepo,
+		commentRepo,
+		commentManager,
+		ticketRepo,
+		nil,
+		db.NewSandboxMessageRepository(queue),
+		services.NewChatResolver(nil, time.Hour),
+		services.NewTypeBundleManager(nil, postTypeRepo),
+		db.NewAdminSignatureRepository(queue),
+		typeVersionRepo,
+		db.NewPostEventRepository(queue),
+		time.UTC,
 	)
 
 	return handler, testDB
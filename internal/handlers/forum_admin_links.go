@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+const linkFormatError = "❌ Неверный формат ссылки. Используйте ссылку вида https://t.me/c/<chat>/<message> или https://t.me/<username>/<message>"
+
+// resolveLink parses a message link and looks up the chat of @username
+// links. On failure it returns a message for the admin.
+func (h *ForumAdminHandler) resolveLink(ctx context.Context, link string) (*services.MessageLinkTarget, string) {
+	target, err := h.chatResolver.ResolveLink(ctx, link)
+	switch {
+	case errors.Is(err, services.ErrInvalidMessageLink):
+		return nil, linkFormatError
+	case errors.Is(err, services.ErrNoDiscussionGroup):
+		return nil, "❌ У канала нет группы обсуждения, комментарий по ссылке найти нельзя"
+	case err != nil:
+		log.Printf("[FORUM_ADMIN] Failed to resolve link: %v", err)
+		return nil, "❌ Не удалось найти чат по ссылке. Проверьте имя чата и что бот в нём состоит"
+	}
+	return target, ""
+}
+
+// postFromInput returns the published post referenced by a link or by its
+// number. On failure it returns the text to show.
+func (h *ForumAdminHandler) postFromInput(ctx context.Context, msg *tgmodels.Message) (*models.PublishedPost, string) {
+	if msg.Text == "" {
+		return nil, "❌ Пожалуйста, отправьте ссылку на пост или его номер"
+	}
+	if number, ok := parsePostNumber(msg.Text); ok {
+		return h.postByNumber(number)
+	}
+
+	target, errText := h.resolveLink(ctx, msg.Text)
+	if target == nil {
+		return nil, errText
+	}
+	post, err := h.publishedPostRepo.GetByMessageID(target.ChatID, target.MessageID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post by link: %v", err)
+		return nil, "❌ Пост не найден. Ссылка должна вести на пост, созданный этим ботом"
+	}
+	return post, ""
+}
+
+func (h *ForumAdminHandler) postPermalink(ctx context.Context, post *models.PublishedPost) string {
+	return h.chatResolver.Permalink(ctx, post.ChatID, post.TopicID, post.MessageID)
+}
+
+// replyPermalink links to a reply. Replies do not store their topic, and a
+// link without it opens the message in the right topic anyway.
+func (h *ForumAdminHandler) replyPermalink(ctx context.Context, reply *models.Reply) string {
+	return h.chatResolver.Permalink(ctx, reply.ChatID, 0, reply.MessageID)
+}
+
+// withLink appends a link line to a confirmation text.
+func withLink(text, link string) string {
+	if link == "" {
+		return text
+	}
+	return text + "\n🔗 " + link
+}
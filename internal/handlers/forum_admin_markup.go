@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+var inputModes = []struct {
+	mode  string
+	label string
+}{
+	{models.InputModeEntities, "форматирование Telegram"},
+	{models.InputModeHTML, "HTML"},
+	{models.InputModeMarkdown, "MarkdownV2"},
+}
+
+func inputModeLabel(mode string) string {
+	for _, m := range inputModes {
+		if m.mode == mode {
+			return m.label
+		}
+	}
+	return mode
+}
+
+// nextInputMode returns the mode following mode in inputModes.
+func nextInputMode(mode string) string {
+	for i, m := range inputModes {
+		if m.mode == mode {
+			return inputModes[(i+1)%len(inputModes)].mode
+		}
+	}
+	return models.InputModeEntities
+}
+
+// markupSource returns text to show an admin the current body in the given
+// input mode. In the HTML and Markdown modes it is the markup source in a
+// code block, ready to be copied, changed and sent back.
+func markupSource(mode, text, entitiesJSON string) (string, []tgmodels.MessageEntity) {
+	var entities []tgmodels.MessageEntity
+	if entitiesJSON != "" {
+		json.Unmarshal([]byte(entitiesJSON), &entities)
+	}
+	if mode == models.InputModeEntities {
+		return text, entities
+	}
+	source := services.RenderMarkup(mode, text, entities)
+	return source, []tgmodels.MessageEntity{{Type: tgmodels.MessageEntityTypePre, Length: services.UTF16Length(source)}}
+}
+
+// parseInput converts an admin's message to text and entities according to
+// mode. On invalid markup it tells the admin where the error is and returns
+// false; the state is left as is so the corrected text can be sent again.
+func (h *ForumAdminHandler) parseInput(ctx context.Context, msg *tgmodels.Message, mode string) (string, []tgmodels.MessageEntity, bool) {
+	text, entities, err := services.ParseMarkup(mode, msg.Text, msg.Entities)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Invalid %s markup from user %d: %v", mode, msg.From.ID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   fmt.Sprintf("❌ Ошибка в разметке %s (%v)\n\nИсправьте текст и отправьте его снова.", inputModeLabel(mode), err),
+		})
+		return "", nil, false
+	}
+	if strings.TrimSpace(text) == "" {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ После разбора разметки текст оказался пустым",
+		})
+		return "", nil, false
+	}
+	return text, entities, true
+}
+
+// postInputMode returns the input mode of the post's type.
+func (h *ForumAdminHandler) postInputMode(post *models.PublishedPost) string {
+	postType, err := h.postTypeRepo.GetByID(post.PostTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return models.InputModeEntities
+	}
+	return postType.InputMode
+}
+
+// editTextPrompt builds the prompt asking for a post's new text.
+func (h *ForumAdminHandler) editTextPrompt(post *models.PublishedPost) (string, []tgmodels.MessageEntity) {
+	mode := h.postInputMode(post)
+	prefix := "Текущий текст поста:\n\n"
+	if mode != models.InputModeEntities {
+		prefix = fmt.Sprintf("Текущий текст поста в формате %s:\n\n", inputModeLabel(mode))
+	}
+	source, sourceEntities := markupSource(mode, post.Text, post.Entities)
+
+	offset := services.UTF16Length(prefix)
+	var entities []tgmodels.MessageEntity
+	for _, e := range sourceEntities {
+		e.Offset += offset
+		entities = append(entities, e)
+	}
+	return prefix + source + "\n\nОтправьте новый текст.", entities
+}
+
+func (h *ForumAdminHandler) handleTypeInputModeToggle(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	postType.InputMode = nextInputMode(postType.InputMode)
+	if err := h.postTypeRepo.Update(postType); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+		return
+	}
+
+	log.Printf("[FORUM_ADMIN] Input mode of type %d set to %q by user %d", typeID, postType.InputMode, userID)
+	h.handleTypeManagementOptions(ctx, userID, chatID, messageID, typeID)
+}
+
+// handlePostSource sends the post's text as an HTML or MarkdownV2 file.
+func (h *ForumAdminHandler) handlePostSource(ctx context.Context, chatID int64, postID int64, mode string) {
+	post, err := h.publishedPostRepo.GetByID(postID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post %d: %v", postID, err)
+		return
+	}
+
+	text, entities := postText(post)
+
+	ext := "html"
+	if mode == models.InputModeMarkdown {
+		ext = "md"
+	}
+	_, err = h.bot.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID: chatID,
+		Document: &tgmodels.InputFileUpload{
+			Filename: fmt.Sprintf("post_%d.%s", post.ID, ext),
+			Data:     strings.NewReader(services.RenderMarkup(mode, text, entities)),
+		},
+		Caption: fmt.Sprintf("Текст поста #%d в формате %s", post.ID, inputModeLabel(mode)),
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send post source: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// draftText returns the full text of a new post: the body in its frame.
+func draftText(state *models.AdminState) (string, []tgmodels.MessageEntity) {
+	return services.ComposePost(models.ParsePostFrame(state.DraftFrame), state.DraftText, parseEntitiesJSON(state.DraftEntities))
+}
+
+func draftLength(state *models.AdminState) int {
+	text, _ := draftText(state)
+	return services.UTF16Length(text)
+}
+
+// postText returns the full text of a published post as it is in the chat.
+func postText(post *models.PublishedPost) (string, []tgmodels.MessageEntity) {
+	return services.ComposePost(models.ParsePostFrame(post.Frame), post.Text, parseEntitiesJSON(post.Entities))
+}
+
+// adminSignature returns the admin's signature, nil when there is none.
+func (h *ForumAdminHandler) adminSignature(userID int64) *models.AdminSignature {
+	signature, err := h.adminSignatureRepo.Get(userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("[FORUM_ADMIN] Failed to get signature of user %d: %v", userID, err)
+	}
+	return signature
+}
+
+// newPostFrame returns the frame of a new post of the type by the admin.
+func (h *ForumAdminHandler) newPostFrame(userID int64, postType *models.PostType) string {
+	return services.NewPostFrame(postType, h.adminSignature(userID)).String()
+}
+
+func (h *ForumAdminHandler) handleEditTypeFrameStart(ctx context.Context, userID, chatID int64, messageID int, typeID int64, footer bool) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	state := &models.AdminState{
+		UserID:        userID,
+		CurrentState:  fsm.StateEditTypeHeader,
+		EditingTypeID: typeID,
+	}
+	block, current, currentEntities := "Шапка", postType.Header, postType.HeaderEntities
+	where := "перед текстом"
+	if footer {
+		state.CurrentState = fsm.StateEditTypeFooter
+		block, current, currentEntities = "Подвал", postType.Footer, postType.FooterEntities
+		where = "после текста, перед подписью автора"
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	if current == "" {
+		current = "нет"
+	} else {
+		current, _ = markupSource(postType.InputMode, current, currentEntities)
+	}
+	text := fmt.Sprintf("%s типа «%s»:\n\n%s\n\nЭтот текст добавляется %s в каждом новом посте типа. Отправьте новый текст (формат ввода: %s) или «-», чтобы убрать его. Уже опубликованные посты не меняются.",
+		block, postType.Name, current, where, inputModeLabel(postType.InputMode))
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, text)
+}
+
+func (h *ForumAdminHandler) handleEditTypeFrameInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	postType, err := h.postTypeRepo.GetByID(state.EditingTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка получения типа поста"})
+		return
+	}
+
+	var text, entitiesJSON string
+	if strings.TrimSpace(msg.Text) != "-" {
+		parsed, entities, ok := h.parseInput(ctx, msg, postType.InputMode)
+		if !ok {
+			return
+		}
+		text = parsed
+		if len(entities) > 0 {
+			data, _ := json.Marshal(entities)
+			entitiesJSON = string(data)
+		}
+	}
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	prev := *postType
+	result := "✅ Шапка типа «%s» сохранена"
+	if text == "" {
+		result = "✅ Шапка типа «%s» убрана"
+	}
+	if state.CurrentState == fsm.StateEditTypeFooter {
+		result = "✅ Подвал типа «%s» сохранен"
+		if text == "" {
+			result = "✅ Подвал типа «%s» убран"
+		}
+		postType.Footer, postType.FooterEntities = text, entitiesJSON
+	} else {
+		postType.Header, postType.HeaderEntities = text, entitiesJSON
+	}
+	if err := h.postTypeRepo.Update(postType); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения изменений"})
+		return
+	}
+
+	h.adminStateRepo.Clear(msg.From.ID)
+	h.recordTypeVersion(&prev, msg.From.ID)
+	h.sendTypeUpdated(ctx, msg.Chat.ID, postType.ID, fmt.Sprintf(result, postType.Name))
+	h.showAdminMenu(ctx, msg.Chat.ID, 0)
+
+	log.Printf("[FORUM_ADMIN] %s of type %d updated by user %d", state.CurrentState, postType.ID, msg.From.ID)
+}
+
+func (h *ForumAdminHandler) showSignatureMenu(ctx context.Context, userID, chatID int64, messageID int) {
+	signature := h.adminSignature(userID)
+
+	text := "✍️ Подпись: не задана"
+	var entities []tgmodels.MessageEntity
+	if signature != nil {
+		prefix := "✍️ Подпись:\n\n"
+		text, entities = withPrefix(prefix, services.TextPart{Text: signature.Text, Entities: parseEntitiesJSON(signature.Entities)})
+	}
+	text += "\n\nПодпись добавляется в конце каждого поста, который вы публикуете, после подвала типа. При редактировании текста поста она сохраняется."
+
+	rows := [][]tgmodels.InlineKeyboardButton{}
+	if signature != nil {
+		rows = append(rows,
+			[]tgmodels.InlineKeyboardButton{{Text: "✏️ Изменить подпись", CallbackData: "signature_edit"}},
+			[]tgmodels.InlineKeyboardButton{{Text: "🗑 Удалить подпись", CallbackData: "signature_delete"}},
+		)
+	} else {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "➕ Добавить подпись", CallbackData: "signature_edit"}})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "admin_settings"}})
+	keyboard := &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
+
+	var err error
+	if messageID > 0 {
+		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			Entities:    entities,
+			ReplyMarkup: keyboard,
+		})
+	} else {
+		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        text,
+			Entities:    entities,
+			ReplyMarkup: keyboard,
+		})
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send signature menu: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleSignatureEditStart(ctx context.Context, userID, chatID int64, messageID int) {
+	state := &models.AdminState{UserID: userID, CurrentState: fsm.StateEditSignature}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, "Отправьте подпись, например «— Анна, редакция». Форматирование Telegram сохраняется.")
+}
+
+func (h *ForumAdminHandler) handleSignatureInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	text, entities, ok := h.parseInput(ctx, msg, models.InputModeEntities)
+	if !ok {
+		return
+	}
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	signature := &models.AdminSignature{UserID: msg.From.ID, Text: text}
+	if len(entities) > 0 {
+		data, _ := json.Marshal(entities)
+		signature.Entities = string(data)
+	}
+	if err := h.adminSignatureRepo.Set(signature); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save signature: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения подписи"})
+		return
+	}
+
+	h.adminStateRepo.Clear(msg.From.ID)
+	h.showSignatureMenu(ctx, msg.From.ID, msg.Chat.ID, 0)
+
+	log.Printf("[FORUM_ADMIN] Signature of user %d updated", msg.From.ID)
+}
+
+func (h *ForumAdminHandler) handleSignatureDelete(ctx context.Context, userID, chatID int64, messageID int) {
+	if err := h.adminSignatureRepo.Delete(userID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to delete signature: %v", err)
+		return
+	}
+	h.showSignatureMenu(ctx, userID, chatID, messageID)
+
+	log.Printf("[FORUM_ADMIN] Signature of user %d deleted", userID)
+}
+
+func parseEntitiesJSON(s string) []tgmodels.MessageEntity {
+	var entities []tgmodels.MessageEntity
+	if s != "" {
+		json.Unmarshal([]byte(s), &entities)
+	}
+	return entities
+}
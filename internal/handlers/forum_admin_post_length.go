@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+const previewPrefix = "Предпросмотр поста:\n\n"
+
+// draftParts returns the text of a new post split the way it will be
+// published: one part unless the draft uses the split layout.
+func draftParts(state *models.AdminState) []services.TextPart {
+	text, entities := draftText(state)
+	if state.DraftLayout == models.PostLayoutSplit {
+		return services.SplitText(text, entities, services.CaptionLimit, services.MessageLimit)
+	}
+	return []services.TextPart{{Text: text, Entities: entities}}
+}
+
+// postParts splits a published post's text into the caption and the
+// follow-up messages.
+func postParts(post *models.PublishedPost, text string, entities []tgmodels.MessageEntity) []services.TextPart {
+	if post.PhotoID == "" && post.UserPhotoID == "" {
+		return []services.TextPart{{Text: text, Entities: entities}}
+	}
+	return services.SplitText(text, entities, services.CaptionLimit, services.MessageLimit)
+}
+
+// draftOverflow returns the length of a draft that is published as one text
+// message and does not fit in it, or 0 when it fits. The length includes the
+// header, footer, signature and the number the post would get now.
+func draftOverflow(state *models.AdminState, postType *models.PostType) int {
+	hasPhoto := state.DraftPhotoID != "" || state.DraftUserPhotoID != ""
+	if hasPhoto && state.DraftLayout != models.PostLayoutPreview {
+		return 0
+	}
+	if n := draftLength(numberedDraft(state, postType, postType.CounterNext)); n > services.MessageLimit {
+		return n
+	}
+	return 0
+}
+
+// postOverflow is draftOverflow for new text of a published post.
+func postOverflow(post *models.PublishedPost, text string, entities []tgmodels.MessageEntity) int {
+	if post.PreviewURL == "" && (post.PhotoID != "" || post.UserPhotoID != "") {
+		return 0
+	}
+	text, _ = services.ComposePost(models.ParsePostFrame(post.Frame), text, entities)
+	if n := services.UTF16Length(text); n > services.MessageLimit {
+		return n
+	}
+	return 0
+}
+
+func messageTooLongText(length int) string {
+	return fmt.Sprintf("❌ Пост не помещается в одно сообщение: %d из %d символов вместе с шапкой, подвалом и подписью.", length, services.MessageLimit)
+}
+
+func withPrefix(prefix string, part services.TextPart) (string, []tgmodels.MessageEntity) {
+	offset := services.UTF16Length(prefix)
+	entities := make([]tgmodels.MessageEntity, 0, len(part.Entities))
+	for _, e := range part.Entities {
+		e.Offset += offset
+		entities = append(entities, e)
+	}
+	return prefix + part.Text, entities
+}
+
+func previewLinkOptions(previewURL string, opts models.DeliveryOptions) *tgmodels.LinkPreviewOptions {
+	preview := &tgmodels.LinkPreviewOptions{URL: &previewURL, PreferLargeMedia: bot.True()}
+	if opts.PreviewAboveText {
+		preview.ShowAboveText = bot.True()
+	}
+	return preview
+}
+
+// sendPostPreview shows a new post the way it will be published, with the
+// number the post would get now. The keyboard goes on the last message.
+func (h *ForumAdminHandler) sendPostPreview(ctx context.Context, chatID int64, state *models.AdminState, postType *models.PostType, keyboard *tgmodels.InlineKeyboardMarkup) {
+	state = numberedDraft(state, postType, postType.CounterNext)
+	opts := models.ParseDeliveryOptions(state.DraftOptions)
+	parts := draftParts(state)
+	photoID := state.DraftPhotoID
+	if photoID == "" {
+		photoID = state.DraftUserPhotoID
+	}
+
+	var err error
+	switch {
+	case state.DraftLayout == models.PostLayoutPreview:
+		text, entities := parts[0].Text, parts[0].Entities
+		if services.UTF16Length(previewPrefix)+services.UTF16Length(text) <= services.MessageLimit {
+			text, entities = withPrefix(previewPrefix, parts[0])
+		}
+		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:             chatID,
+			Text:               text,
+			Entities:           entities,
+			LinkPreviewOptions: previewLinkOptions(postType.PhotoURL, opts),
+			ReplyMarkup:        keyboard,
+		})
+	case photoID != "":
+		caption, captionEntities := parts[0].Text, parts[0].Entities
+		if services.UTF16Length(previewPrefix)+services.UTF16Length(caption) <= services.CaptionLimit {
+			caption, captionEntities = withPrefix(previewPrefix, parts[0])
+		}
+		params := &bot.SendPhotoParams{
+			ChatID:                chatID,
+			Photo:                 &tgmodels.InputFileString{Data: photoID},
+			Caption:               caption,
+			CaptionEntities:       captionEntities,
+			ShowCaptionAboveMedia: opts.CaptionAboveMedia,
+		}
+		if len(parts) == 1 {
+			params.ReplyMarkup = keyboard
+		}
+		_, err = h.bot.SendPhoto(ctx, params)
+		for i, part := range parts[1:] {
+			if err != nil {
+				break
+			}
+			params := &bot.SendMessageParams{
+				ChatID:   chatID,
+				Text:     part.Text,
+				Entities: part.Entities,
+			}
+			if i == len(parts)-2 {
+				params.ReplyMarkup = keyboard
+			}
+			_, err = h.bot.SendMessage(ctx, params)
+		}
+	default:
+		text, entities := parts[0].Text, parts[0].Entities
+		if services.UTF16Length(previewPrefix)+services.UTF16Length(text) <= services.MessageLimit {
+			text, entities = withPrefix(previewPrefix, parts[0])
+		}
+		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:             chatID,
+			Text:               text,
+			Entities:           entities,
+			LinkPreviewOptions: linkPreviewOptions(opts),
+			ReplyMarkup:        keyboard,
+		})
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send preview: %v", err)
+	}
+}
+
+// showPostLayoutChoice asks how to publish a text that does not fit in the
+// type photo's caption.
+func (h *ForumAdminHandler) showPostLayoutChoice(ctx context.Context, chatID int64, state *models.AdminState, postType *models.PostType) {
+	text := fmt.Sprintf("Текст длиннее подписи к фото: %d из %d символов.\n\nКак опубликовать пост?\n"+
+		"• 🖼 Фото с началом текста, продолжение — отдельным сообщением\n", draftLength(state), services.CaptionLimit)
+	rows := [][]tgmodels.InlineKeyboardButton{
+		{{Text: "🖼 Фото + продолжение", CallbackData: "post_layout:" + models.PostLayoutSplit}},
+	}
+	single := *state
+	single.DraftLayout = models.PostLayoutPreview
+	switch {
+	case postType.PhotoURL == "":
+		text += "\nЧтобы публиковать длинные тексты с превью изображения, задайте ссылку на изображение в настройках типа.\n"
+	case draftOverflow(&single, postType) == 0:
+		text += "• 🔗 Текст одним сообщением с превью изображения типа\n"
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "🔗 Текст с превью", CallbackData: "post_layout:" + models.PostLayoutPreview}})
+	default:
+		text += fmt.Sprintf("\nОдним сообщением с превью текст не опубликовать: в сообщение помещается %d символов.\n", services.MessageLimit)
+	}
+	text += "\nИли отправьте более короткий текст."
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "❌ Отмена", CallbackData: "cancel"}})
+
+	sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send layout choice: %v", err)
+		return
+	}
+	state.LastBotMessageID = sentMsg.ID
+	h.adminStateRepo.Save(state)
+}
+
+func (h *ForumAdminHandler) handlePostLayoutChoice(ctx context.Context, userID, chatID int64, messageID int, layout string) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateNewPostChooseLayout {
+		log.Printf("[FORUM_ADMIN] Invalid state for layout choice: %v", err)
+		return
+	}
+
+	postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Ошибка получения типа поста"})
+		return
+	}
+	if layout != models.PostLayoutSplit && (layout != models.PostLayoutPreview || postType.PhotoURL == "") {
+		return
+	}
+
+	state.DraftLayout = layout
+	if n := draftOverflow(state, postType); n > 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: messageTooLongText(n) + " Выберите «🖼 Фото + продолжение» или отправьте более короткий текст."})
+		return
+	}
+	state.CurrentState = fsm.StateNewPostConfirm
+	state.LastBotMessageID = 0
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendPostPreview(ctx, chatID, state, postType, postConfirmKeyboard(state, "✅ Подтвердить", h.sandboxEnabled(), h.canSwapDraftImage(state)))
+
+	log.Printf("[FORUM_ADMIN] Layout %s chosen by user %d", layout, userID)
+}
+
+// sendFollowUps publishes the parts after the first one as text messages in
+// the post's topic.
+func (h *ForumAdminHandler) sendFollowUps(ctx context.Context, chatID, topicID int64, parts []services.TextPart, opts models.DeliveryOptions) ([]int64, error) {
+	var messageIDs []int64
+	for _, part := range parts {
+		sent, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:              chatID,
+			MessageThreadID:     int(topicID),
+			Text:                part.Text,
+			Entities:            part.Entities,
+			LinkPreviewOptions:  linkPreviewOptions(opts),
+			DisableNotification: opts.Silent,
+			ProtectContent:      opts.Protected,
+		})
+		if err != nil {
+			return messageIDs, err
+		}
+		messageIDs = append(messageIDs, int64(sent.ID))
+	}
+	return messageIDs, nil
+}
+
+// editPostCaption replaces the caption of a photo post.
+func (h *ForumAdminHandler) editPostCaption(ctx context.Context, post *models.PublishedPost, part services.TextPart) error {
+	opts := models.ParseDeliveryOptions(post.DeliveryOptions)
+	if opts.CaptionAboveMedia {
+		// EditMessageCaptionParams does not serialize show_caption_above_media,
+		// so the caption position is kept by re-sending the same photo.
+		_, err := h.bot.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
+			ChatID:    post.ChatID,
+			MessageID: int(post.MessageID),
+			Media: &tgmodels.InputMediaPhoto{
+				Media:                 captionPhotoID(post),
+				Caption:               part.Text,
+				CaptionEntities:       part.Entities,
+				ShowCaptionAboveMedia: true,
+				HasSpoiler:            opts.Spoiler,
+			},
+		})
+		return err
+	}
+	_, err := h.bot.EditMessageCaption(ctx, &bot.EditMessageCaptionParams{
+		ChatID:          post.ChatID,
+		MessageID:       int(post.MessageID),
+		Caption:         part.Text,
+		CaptionEntities: part.Entities,
+	})
+	return err
+}
+
+// editPublishedText replaces the body of a published post, keeping the
+// header and footer it was published with and moving text that does not fit
+// in the caption to follow-up messages. Follow-ups are edited in place,
+// added or deleted as needed.
+func (h *ForumAdminHandler) editPublishedText(ctx context.Context, post *models.PublishedPost, text string, entities []tgmodels.MessageEntity) error {
+	opts := models.ParseDeliveryOptions(post.DeliveryOptions)
+	text, entities = services.ComposePost(models.ParsePostFrame(post.Frame), text, entities)
+
+	if post.PreviewURL != "" || (post.PhotoID == "" && post.UserPhotoID == "") {
+		preview := linkPreviewOptions(opts)
+		if post.PreviewURL != "" {
+			preview = previewLinkOptions(post.PreviewURL, opts)
+		}
+		_, err := h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:             post.ChatID,
+			MessageID:          int(post.MessageID),
+			Text:               text,
+			Entities:           entities,
+			LinkPreviewOptions: preview,
+		})
+		return err
+	}
+
+	parts := postParts(post, text, entities)
+	if err := h.editPostCaption(ctx, post, parts[0]); err != nil {
+		return err
+	}
+
+	followUps, err := h.publishedPostRepo.GetFollowUps(post.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get follow-up messages: %w", err)
+	}
+	if len(followUps) == 0 && len(parts) == 1 {
+		return nil
+	}
+
+	var messageIDs []int64
+	for i, part := range parts[1:] {
+		if i < len(followUps) {
+			_, err := h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+				ChatID:             post.ChatID,
+				MessageID:          int(followUps[i]),
+				Text:               part.Text,
+				Entities:           part.Entities,
+				LinkPreviewOptions: linkPreviewOptions(opts),
+			})
+			if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+				log.Printf("[FORUM_ADMIN] Failed to edit follow-up %d of post %d: %v", followUps[i], post.ID, err)
+			}
+			messageIDs = append(messageIDs, followUps[i])
+			continue
+		}
+		sent, err := h.sendFollowUps(ctx, post.ChatID, post.TopicID, parts[i+1:i+2], opts)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to send follow-up of post %d: %v", post.ID, err)
+			break
+		}
+		messageIDs = append(messageIDs, sent...)
+	}
+	for _, messageID := range followUps[min(len(followUps), len(parts)-1):] {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: post.ChatID, MessageID: int(messageID)})
+	}
+
+	return h.publishedPostRepo.SetFollowUps(post.ID, messageIDs)
+}
+
+// deletePostFollowUps removes the follow-up messages of a post from the chat.
+func (h *ForumAdminHandler) deletePostFollowUps(ctx context.Context, post *models.PublishedPost) {
+	followUps, err := h.publishedPostRepo.GetFollowUps(post.ID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get follow-up messages of post %d: %v", post.ID, err)
+		return
+	}
+	for _, messageID := range followUps {
+		if _, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    post.ChatID,
+			MessageID: int(messageID),
+		}); err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to delete follow-up %d of post %d: %v", messageID, post.ID, err)
+		}
+	}
+}
+
+func (h *ForumAdminHandler) handleEditTypePhotoURLStart(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	state := &models.AdminState{
+		UserID:        userID,
+		CurrentState:  fsm.StateEditTypePhotoURL,
+		EditingTypeID: typeID,
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	current := "не задана"
+	if postType.PhotoURL != "" {
+		current = postType.PhotoURL
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, fmt.Sprintf(
+		"Ссылка на изображение для превью: %s\n\nОна нужна, чтобы публиковать тексты длиннее %d символов одним сообщением с превью изображения. Отправьте ссылку https://… или «-», чтобы убрать её.",
+		current, services.CaptionLimit))
+}
+
+func (h *ForumAdminHandler) handleEditTypePhotoURLInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	photoURL := strings.TrimSpace(msg.Text)
+	if photoURL == "-" {
+		photoURL = ""
+	} else if u, err := url.Parse(photoURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Отправьте ссылку, начинающуюся с http:// или https://, или «-»")
+		return
+	}
+
+	postType, err := h.postTypeRepo.GetByID(state.EditingTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка получения типа поста"})
+		return
+	}
+	postType.PhotoURL = photoURL
+	if err := h.postTypeRepo.Update(postType); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения изменений"})
+		return
+	}
+
+	h.adminStateRepo.Clear(msg.From.ID)
+	text := "✅ Ссылка на изображение сохранена"
+	if photoURL == "" {
+		text = "✅ Ссылка на изображение удалена"
+	}
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: text})
+	h.showAdminMenu(ctx, msg.Chat.ID, 0)
+
+	log.Printf("[FORUM_ADMIN] Photo URL of type %d updated by user %d", postType.ID, msg.From.ID)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+)
+
+func TestDraftOverflow(t *testing.T) {
+	postType := &models.PostType{}
+	frame := models.PostFrame{Header: "Шапка", Footer: "Подпись"}.String()
+	fits := strings.Repeat("а", services.MessageLimit-20)
+	tooLong := strings.Repeat("а", services.MessageLimit-5)
+
+	if n := draftOverflow(&models.AdminState{DraftText: fits, DraftFrame: frame}, postType); n != 0 {
+		t.Errorf("draftOverflow() of a text that fits = %d", n)
+	}
+	if n := draftOverflow(&models.AdminState{DraftText: tooLong, DraftFrame: frame}, postType); n <= services.MessageLimit {
+		t.Errorf("draftOverflow() with header and footer = %d, want the length over the limit", n)
+	}
+	if n := draftOverflow(&models.AdminState{DraftText: tooLong, DraftFrame: frame, DraftPhotoID: "photo", DraftLayout: models.PostLayoutSplit}, postType); n != 0 {
+		t.Errorf("draftOverflow() of a split photo post = %d, want 0", n)
+	}
+	if n := draftOverflow(&models.AdminState{DraftText: tooLong, DraftFrame: frame, DraftPhotoID: "photo", DraftLayout: models.PostLayoutPreview}, postType); n == 0 {
+		t.Error("draftOverflow() of a preview post = 0, want the length")
+	}
+
+	textPost := &models.PublishedPost{Frame: frame}
+	if n := postOverflow(textPost, tooLong, nil); n == 0 {
+		t.Error("postOverflow() of a text post = 0, want the length")
+	}
+	if n := postOverflow(&models.PublishedPost{PhotoID: "photo"}, tooLong, nil); n != 0 {
+		t.Errorf("postOverflow() of a photo post = %d, want 0", n)
+	}
+}
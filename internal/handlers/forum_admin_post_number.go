@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+const maxCounterFormatLength = 32
+
+// numberedDraft returns a copy of the draft with the post number n put in
+// its text and frame, or the draft itself when the type has no counter.
+func numberedDraft(state *models.AdminState, postType *models.PostType, n int64) *models.AdminState {
+	if !postType.HasCounter() {
+		return state
+	}
+	frame, text, entities := services.FillPostNumber(models.ParsePostFrame(state.DraftFrame), state.DraftText, parseEntitiesJSON(state.DraftEntities), postType.FormatNumber(n))
+	numbered := *state
+	numbered.DraftText = text
+	numbered.DraftEntities = ""
+	if len(entities) > 0 {
+		data, _ := json.Marshal(entities)
+		numbered.DraftEntities = string(data)
+	}
+	numbered.DraftFrame = frame.String()
+	return &numbered
+}
+
+// postNumberLabel returns the formatted number of a post, or "" when it has
+// none.
+func postNumberLabel(post *models.PublishedPost, postType *models.PostType) string {
+	if post.Number == 0 {
+		return ""
+	}
+	if postType == nil || !postType.HasCounter() {
+		return "#" + strconv.FormatInt(post.Number, 10)
+	}
+	return postType.FormatNumber(post.Number)
+}
+
+// parsePostNumber reads a post number such as "154", "#154" or "№ 154".
+func parsePostNumber(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "#")
+	s = strings.TrimPrefix(s, "№")
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// postByNumber finds the post with the number. The number is unique only
+// within a type, so several posts may match.
+func (h *ForumAdminHandler) postByNumber(number int64) (*models.PublishedPost, string) {
+	posts, err := h.publishedPostRepo.GetByNumber(number)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get posts by number: %v", err)
+		return nil, "❌ Ошибка поиска поста"
+	}
+	switch len(posts) {
+	case 0:
+		return nil, fmt.Sprintf("❌ Пост с номером %d не найден", number)
+	case 1:
+		return posts[0], ""
+	}
+	var types []string
+	for _, post := range posts {
+		if postType, err := h.postTypeRepo.GetByID(post.PostTypeID); err == nil {
+			types = append(types, "«"+postType.Name+"»")
+		}
+	}
+	return nil, fmt.Sprintf("❌ Номер %d есть у нескольких постов (%s). Пришлите ссылку на пост", number, strings.Join(types, ", "))
+}
+
+func (h *ForumAdminHandler) handleTypeCounter(ctx context.Context, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	text := fmt.Sprintf("🔢 Нумерация постов типа «%s»: выключена\n\nКаждый новый пост получит следующий номер. Номер подставляется вместо %s в тексте, шаблоне, шапке и подвале поста, и по нему можно найти пост в списке постов.",
+		postType.Name, services.NumberPlaceholder)
+	rows := [][]tgmodels.InlineKeyboardButton{
+		{{Text: "🔢 Включить", CallbackData: fmt.Sprintf("type_counter_format:%d", typeID)}},
+	}
+	if postType.HasCounter() {
+		text = fmt.Sprintf("🔢 Нумерация постов типа «%s»\n\nФормат: %s\nСледующий пост: %s\n\nНомер подставляется вместо %s в тексте, шаблоне, шапке и подвале поста.",
+			postType.Name, postType.CounterFormat, postType.FormatNumber(postType.CounterNext), services.NumberPlaceholder)
+		rows = [][]tgmodels.InlineKeyboardButton{
+			{{Text: "✏️ Формат", CallbackData: fmt.Sprintf("type_counter_format:%d", typeID)}},
+			{{Text: "🔁 Следующий номер", CallbackData: fmt.Sprintf("type_counter_next:%d", typeID)}},
+			{{Text: "🚫 Выключить", CallbackData: fmt.Sprintf("type_counter_off:%d", typeID)}},
+		}
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: fmt.Sprintf("manage_type:%d", typeID)}})
+	h.editOrSendMenu(ctx, chatID, messageID, text, &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (h *ForumAdminHandler) handleTypeCounterEditStart(ctx context.Context, userID, chatID int64, messageID int, typeID int64, next bool) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	state := &models.AdminState{
+		UserID:        userID,
+		CurrentState:  fsm.StateEditTypeCounterFormat,
+		EditingTypeID: typeID,
+	}
+	text := fmt.Sprintf("Отправьте формат номера для типа «%s». %s в нём заменяется числом, например «№%s» даст «№154».",
+		postType.Name, models.CounterPlaceholder, models.CounterPlaceholder)
+	if next {
+		state.CurrentState = fsm.StateEditTypeCounterNext
+		text = fmt.Sprintf("Сейчас следующий пост типа «%s» получит номер %d. Отправьте новый номер.", postType.Name, postType.CounterNext)
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, text)
+}
+
+func (h *ForumAdminHandler) handleTypeCounterInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	postType, err := h.postTypeRepo.GetByID(state.EditingTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка получения типа поста"})
+		return
+	}
+	input := strings.TrimSpace(msg.Text)
+
+	if state.CurrentState == fsm.StateEditTypeCounterNext {
+		next, err := strconv.ParseInt(input, 10, 64)
+		if err != nil || next < 1 {
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Номер должен быть целым числом больше нуля"})
+			return
+		}
+		err = h.postTypeRepo.SetCounterNext(postType.ID, next)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to set counter of type %d: %v", postType.ID, err)
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения изменений"})
+			return
+		}
+	} else {
+		if !strings.Contains(input, models.CounterPlaceholder) {
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: fmt.Sprintf("❌ Формат должен содержать %s", models.CounterPlaceholder)})
+			return
+		}
+		if utf8.RuneCountInString(input) > maxCounterFormatLength {
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: fmt.Sprintf("❌ Формат длиннее %d символов", maxCounterFormatLength)})
+			return
+		}
+		postType.CounterFormat = input
+		if err := h.postTypeRepo.Update(postType); err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения изменений"})
+			return
+		}
+	}
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+	h.adminStateRepo.Clear(msg.From.ID)
+	h.handleTypeCounter(ctx, msg.Chat.ID, 0, postType.ID)
+
+	log.Printf("[FORUM_ADMIN] Counter of type %d updated by user %d", postType.ID, msg.From.ID)
+}
+
+func (h *ForumAdminHandler) handleTypeCounterOff(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	postType.CounterFormat = ""
+	if err := h.postTypeRepo.Update(postType); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+		return
+	}
+	h.handleTypeCounter(ctx, chatID, messageID, typeID)
+
+	log.Printf("[FORUM_ADMIN] Counter of type %d turned off by user %d", typeID, userID)
+}
+
+func (h *ForumAdminHandler) handlePostSearchStart(ctx context.Context, userID, chatID int64, messageID int) {
+	state := &models.AdminState{UserID: userID, CurrentState: fsm.StatePostSearch}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, "Отправьте номер поста, например «154» или «#154»")
+}
+
+func (h *ForumAdminHandler) handlePostSearchInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	number, ok := parsePostNumber(msg.Text)
+	if !ok {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Номер должен быть целым числом больше нуля"})
+		return
+	}
+	posts, err := h.publishedPostRepo.GetByNumber(number)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get posts by number: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка поиска поста"})
+		return
+	}
+	if len(posts) == 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: fmt.Sprintf("❌ Пост с номером %d не найден. Отправьте другой номер или /cancel", number)})
+		return
+	}
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+	h.adminStateRepo.Clear(msg.From.ID)
+
+	if len(posts) == 1 {
+		h.showPostDetails(ctx, msg.From.ID, msg.Chat.ID, 0, posts[0].ID, 0)
+		return
+	}
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, post := range posts {
+		label := fmt.Sprintf("#%d", post.ID)
+		if postType, err := h.postTypeRepo.GetByID(post.PostTypeID); err == nil {
+			label = postTypeLabel(postType) + " " + postNumberLabel(post, postType)
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%s — %s", label, post.CreatedAt.Format("02.01.06 15:04")), CallbackData: fmt.Sprintf("post_details:%d:0", post.ID)},
+		})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "post_list_page:0"}})
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      msg.Chat.ID,
+		Text:        fmt.Sprintf("Посты с номером %d:", number),
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestParsePostNumber(t *testing.T) {
+	for input, want := range map[string]int64{"154": 154, "#154": 154, "№154": 154, " № 154 ": 154} {
+		if got, ok := parsePostNumber(input); !ok || got != want {
+			t.Errorf("parsePostNumber(%q) = %d, %v", input, got, ok)
+		}
+	}
+	for _, input := range []string{"", "#", "0", "-3", "https://t.me/c/1234567890/55", "#154a"} {
+		if got, ok := parsePostNumber(input); ok {
+			t.Errorf("parsePostNumber(%q) = %d, expected rejection", input, got)
+		}
+	}
+}
+
+func TestPostByNumber(t *testing.T) {
+	h, testDB := setupForumAdminHandler(t)
+	defer testDB.Close()
+
+	var typeIDs []int64
+	for _, name := range []string{"Вакансии", "Резюме"} {
+		postType := &models.PostType{Name: name, Template: "{number}", IsActive: true, CounterFormat: "№{n}"}
+		if err := h.postTypeRepo.Create(postType); err != nil {
+			t.Fatalf("Failed to create post type: %v", err)
+		}
+		typeIDs = append(typeIDs, postType.ID)
+	}
+	for i, number := range []int64{7, 8, 8} {
+		post := &models.PublishedPost{PostTypeID: typeIDs[min(i, 1)], ChatID: -100, MessageID: int64(i + 1), Text: "пост", Number: number}
+		if err := h.publishedPostRepo.Create(post); err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+	}
+
+	if post, errText := h.postByNumber(7); post == nil || post.MessageID != 1 {
+		t.Errorf("postByNumber(7) = %+v (%s)", post, errText)
+	}
+	if post, errText := h.postByNumber(8); post != nil || errText == "" {
+		t.Errorf("postByNumber(8) = %+v, expected ambiguity", post)
+	}
+	if post, errText := h.postByNumber(9); post != nil || errText == "" {
+		t.Errorf("postByNumber(9) = %+v, expected not found", post)
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// replyFromLink finds a reply sent by the bot by its message link. On failure
+// it returns a message for the admin.
+func (h *ForumAdminHandler) replyFromLink(ctx context.Context, link string) (*models.Reply, string) {
+	if strings.TrimSpace(link) == "" {
+		return nil, "❌ Пожалуйста, отправьте ссылку на ответ"
+	}
+
+	target, errText := h.resolveLink(ctx, link)
+	if target == nil {
+		return nil, errText
+	}
+
+	reply, err := h.replyRepo.GetByMessageID(target.ChatID, target.MessageID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("[FORUM_ADMIN] Failed to get reply by message: %v", err)
+		}
+		return nil, "❌ Ответ не найден. Ссылка должна вести на ответ, отправленный этим ботом"
+	}
+	return reply, ""
+}
+
+// handleReplyByLinkCommand handles /editreply and /deletereply. With a link
+// the reply is opened right away, otherwise the admin is asked for one.
+func (h *ForumAdminHandler) handleReplyByLinkCommand(ctx context.Context, userID, chatID int64, lookupState, link string) {
+	if link != "" {
+		reply, errText := h.replyFromLink(ctx, link)
+		if reply == nil {
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: errText})
+			return
+		}
+		h.openReplyByLink(ctx, userID, chatID, lookupState, reply)
+		return
+	}
+
+	state := &models.AdminState{
+		UserID:       userID,
+		CurrentState: lookupState,
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	prompt := "Отправьте ссылку на ответ, который нужно отредактировать"
+	if lookupState == fsm.StateDeleteReplyEnterLink {
+		prompt = "Отправьте ссылку на ответ, который нужно удалить"
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, prompt)
+}
+
+func (h *ForumAdminHandler) handleReplyLookupLinkInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	reply, errText := h.replyFromLink(ctx, msg.Text)
+	if reply == nil {
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, errText)
+		return
+	}
+
+	if err := h.adminStateRepo.Clear(msg.From.ID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to clear state: %v", err)
+	}
+	h.openReplyByLink(ctx, msg.From.ID, msg.Chat.ID, state.CurrentState, reply)
+}
+
+func (h *ForumAdminHandler) openReplyByLink(ctx context.Context, userID, chatID int64, lookupState string, reply *models.Reply) {
+	if lookupState == fsm.StateDeleteReplyEnterLink {
+		h.showDeleteReplyConfirm(ctx, chatID, 0, reply.ID, 0)
+		return
+	}
+	h.handleEditReplyFromList(ctx, userID, chatID, 0, reply.ID)
+}
+
+// resendReply replaces a reply with a new message. Telegram cannot turn a
+// text message into a photo or back, so the new message is sent as a reply
+// to the same message and the old one is deleted.
+func (h *ForumAdminHandler) resendReply(ctx context.Context, reply *models.Reply, text, photoID string, entities []tgmodels.MessageEntity) error {
+	replyParams := &tgmodels.ReplyParameters{
+		MessageID:                int(reply.ReplyToMessageID),
+		AllowSendingWithoutReply: true,
+	}
+
+	var sentMsg *tgmodels.Message
+	var err error
+	if photoID != "" {
+		sentMsg, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
+			ChatID:          reply.ChatID,
+			Photo:           &tgmodels.InputFileString{Data: photoID},
+			Caption:         text,
+			CaptionEntities: entities,
+			ReplyParameters: replyParams,
+		})
+	} else {
+		sentMsg, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          reply.ChatID,
+			Text:            text,
+			Entities:        entities,
+			ReplyParameters: replyParams,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    reply.ChatID,
+		MessageID: int(reply.MessageID),
+	}); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to delete old reply message %d: %v", reply.MessageID, err)
+	}
+
+	reply.MessageID = int64(sentMsg.ID)
+	return nil
+}
+
+func (h *ForumAdminHandler) handleEditReplyRemovePhoto(ctx context.Context, userID, chatID int64, messageID int) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateEditReplyEnterText {
+		log.Printf("[FORUM_ADMIN] Invalid state for reply photo removal")
+		return
+	}
+
+	reply, err := h.replyRepo.GetByID(state.EditingPostID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get reply: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Ошибка получения ответа"})
+		return
+	}
+	if reply.PhotoID == "" {
+		return
+	}
+	if strings.TrimSpace(reply.Text) == "" {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ У ответа нет подписи. Отправьте текст, который останется вместо фото",
+		})
+		return
+	}
+
+	var entities []tgmodels.MessageEntity
+	if reply.Entities != "" {
+		json.Unmarshal([]byte(reply.Entities), &entities)
+	}
+	if err := h.resendReply(ctx, reply, reply.Text, "", entities); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to resend reply without photo: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: fmt.Sprintf("❌ Не удалось убрать фото: %v", err)})
+		return
+	}
+
+	reply.PhotoID = ""
+	if err := h.replyRepo.Update(reply); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update reply in DB: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Ошибка сохранения изменений"})
+		return
+	}
+	h.recordPostEvent(models.PostEventReplyEdit, userID, 0)
+
+	h.adminStateRepo.Clear(userID)
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "✅ Фото убрано из ответа"})
+	h.showAdminMenu(ctx, chatID, 0)
+
+	log.Printf("[FORUM_ADMIN] Photo removed from reply %d by user %d", reply.ID, userID)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestReplyFromLink(t *testing.T) {
+	h, testDB := setupForumAdminHandler(t)
+	defer testDB.Close()
+
+	queue := db.NewDBQueueForTest(testDB)
+	config, err := db.NewAdminConfigRepository(queue).Get()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+	config.ForumChatID = -1001234567890
+	if err := h.adminConfigRepo.Save(config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	reply := &models.Reply{ChatID: -1001234567890, ReplyToMessageID: 10, MessageID: 55, Text: "ответ"}
+	if err := h.replyRepo.Create(reply); err != nil {
+		t.Fatalf("Failed to create reply: %v", err)
+	}
+
+	got, errText := h.replyFromLink(context.Background(), "https://t.me/c/1234567890/3/55")
+	if got == nil || got.ID != reply.ID {
+		t.Fatalf("Expected reply %d, got %+v (%s)", reply.ID, got, errText)
+	}
+
+	for _, link := range []string{"", "not a link", "https://t.me/c/1234567890/56"} {
+		if got, errText := h.replyFromLink(context.Background(), link); got != nil || errText == "" {
+			t.Errorf("Expected %q to be rejected, got %+v", link, got)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// maxReplyTemplateButtons limits how many templates are offered at the reply
+// text step; the most used ones come first.
+const maxReplyTemplateButtons = 8
+
+// messageContent extracts the text, the largest photo and the matching
+// entities from an admin's message.
+func messageContent(msg *tgmodels.Message) (string, string, []tgmodels.MessageEntity) {
+	if len(msg.Photo) > 0 {
+		text := msg.Text
+		if text == "" {
+			text = msg.Caption
+		}
+		return text, msg.Photo[len(msg.Photo)-1].FileID, msg.CaptionEntities
+	}
+	return msg.Text, "", msg.Entities
+}
+
+func placeholderHelpText() string {
+	var sb strings.Builder
+	sb.WriteString("Доступные подстановки:\n")
+	for _, p := range services.ReplyPlaceholders {
+		sb.WriteString(fmt.Sprintf("%s — %s\n", p.Key, p.Description))
+	}
+	return sb.String()
+}
+
+// replyPlaceholderValues collects placeholder values for the reply target
+// stored in state. The author is only known for messages the bot has seen.
+func (h *ForumAdminHandler) replyPlaceholderValues(userID int64, state *models.AdminState) map[string]string {
+	values := map[string]string{
+		"admin": h.userManager.DisplayName(userID),
+		"date":  time.Now().Format("02.01.2006"),
+	}
+	author := h.userManager.MessageAuthor(state.ReplyTargetChatID, state.ReplyTargetMessageID)
+	if author != nil {
+		values["name"] = author.DisplayName()
+		values["first_name"] = author.FirstName
+		if author.Username != "" {
+			values["username"] = "@" + author.Username
+		}
+	}
+	return values
+}
+
+func (h *ForumAdminHandler) replyTemplateButtons() [][]tgmodels.InlineKeyboardButton {
+	templates, err := h.replyTemplateRepo.GetAll()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get reply templates: %v", err)
+		return nil
+	}
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for i, tpl := range templates {
+		if i >= maxReplyTemplateButtons {
+			break
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: "📝 " + tpl.Name, CallbackData: fmt.Sprintf("reply_tpl:%d", tpl.ID)},
+		})
+	}
+	return rows
+}
+
+func (h *ForumAdminHandler) showReplyTemplates(ctx context.Context, chatID int64, messageID int) {
+	templates, err := h.replyTemplateRepo.GetAll()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get reply templates: %v", err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      "❌ Ошибка получения шаблонов",
+		})
+		return
+	}
+
+	text := "📝 Шаблоны ответов\n\nШаблоны можно выбрать при ответе на сообщение."
+	if len(templates) == 0 {
+		text += "\n\nШаблонов пока нет."
+	}
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, tpl := range templates {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%s (%d)", tpl.Name, tpl.UsageCount), CallbackData: fmt.Sprintf("reply_tpl_manage:%d", tpl.ID)},
+		})
+	}
+	rows = append(rows,
+		[]tgmodels.InlineKeyboardButton{{Text: "➕ Новый шаблон", CallbackData: "reply_tpl_new"}},
+		[]tgmodels.InlineKeyboardButton{{Text: "◀️ Назад", CallbackData: "admin_settings"}},
+	)
+	keyboard := &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
+
+	if messageID > 0 {
+		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			ReplyMarkup: keyboard,
+		})
+	} else {
+		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        text,
+			ReplyMarkup: keyboard,
+		})
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to show reply templates: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) showReplyTemplateDetails(ctx context.Context, chatID int64, messageID int, templateID int64) {
+	tpl, err := h.replyTemplateRepo.GetByID(templateID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get reply template %d: %v", templateID, err)
+		h.showReplyTemplates(ctx, chatID, messageID)
+		return
+	}
+
+	prefix := fmt.Sprintf("📝 %s\nИспользован: %d раз\n", tpl.Name, tpl.UsageCount)
+	if tpl.PhotoID != "" {
+		prefix += "🖼 С фото\n"
+	}
+	prefix += "\n"
+
+	var entities []tgmodels.MessageEntity
+	if tpl.Entities != "" {
+		var ents []tgmodels.MessageEntity
+		if jsonErr := json.Unmarshal([]byte(tpl.Entities), &ents); jsonErr == nil {
+			offset := services.UTF16Length(prefix)
+			for _, e := range ents {
+				e.Offset += offset
+				entities = append(entities, e)
+			}
+		}
+	}
+
+	keyboard := &tgmodels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+			{
+				{Text: "✏️ Текст", CallbackData: fmt.Sprintf("reply_tpl_edit_text:%d", tpl.ID)},
+				{Text: "🏷 Название", CallbackData: fmt.Sprintf("reply_tpl_edit_name:%d", tpl.ID)},
+			},
+			{{Text: "🗑 Удалить", CallbackData: fmt.Sprintf("reply_tpl_delete:%d", tpl.ID)}},
+			{{Text: "◀️ Назад", CallbackData: "settings_reply_templates"}},
+		},
+	}
+
+	if messageID > 0 {
+		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        prefix + tpl.Text,
+			Entities:    entities,
+			ReplyMarkup: keyboard,
+		})
+	} else {
+		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        prefix + tpl.Text,
+			Entities:    entities,
+			ReplyMarkup: keyboard,
+		})
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to show reply template: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) showDeleteReplyTemplateConfirm(ctx context.Context, chatID int64, messageID int, templateID int64) {
+	tpl, err := h.replyTemplateRepo.GetByID(templateID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get reply template %d: %v", templateID, err)
+		h.showReplyTemplates(ctx, chatID, messageID)
+		return
+	}
+
+	keyboard := &tgmodels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+			{
+				{Text: "✅ Да, удалить", CallbackData: fmt.Sprintf("reply_tpl_delete_confirm:%d", tpl.ID)},
+				{Text: "❌ Отмена", CallbackData: fmt.Sprintf("reply_tpl_manage:%d", tpl.ID)},
+			},
+		},
+	}
+
+	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        fmt.Sprintf("Удалить шаблон «%s»?", tpl.Name),
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send delete template confirmation: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleDeleteReplyTemplate(ctx context.Context, userID, chatID int64, messageID int, templateID int64) {
+	if err := h.replyTemplateRepo.Delete(templateID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to delete reply template %d: %v", templateID, err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      "❌ Ошибка удаления шаблона",
+		})
+		return
+	}
+
+	log.Printf("[FORUM_ADMIN] Reply template %d deleted by user %d", templateID, userID)
+	h.showReplyTemplates(ctx, chatID, messageID)
+}
+
+// startReplyTemplateInput switches the admin into one of the template input
+// states and replaces the current menu with a prompt.
+func (h *ForumAdminHandler) startReplyTemplateInput(ctx context.Context, userID, chatID int64, messageID int, newState string, templateID int64, prompt string) {
+	state := &models.AdminState{
+		UserID:          userID,
+		CurrentState:    newState,
+		ReplyTemplateID: templateID,
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      "❌ Ошибка сохранения состояния",
+		})
+		return
+	}
+
+	keyboard := &tgmodels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+			{{Text: "❌ Отмена", CallbackData: "cancel"}},
+		},
+	}
+
+	sentMsg, err := h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        prompt,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send reply template prompt: %v", err)
+	} else if sentMsg != nil {
+		state.LastBotMessageID = sentMsg.ID
+		h.adminStateRepo.Save(state)
+	}
+}
+
+func (h *ForumAdminHandler) handleNewReplyTemplateStart(ctx context.Context, userID, chatID int64, messageID int) {
+	h.startReplyTemplateInput(ctx, userID, chatID, messageID, fsm.StateNewReplyTemplateName, 0,
+		"Отправьте название нового шаблона. Оно будет видно на кнопке при ответе.")
+}
+
+func (h *ForumAdminHandler) handleEditReplyTemplateNameStart(ctx context.Context, userID, chatID int64, messageID int, templateID int64) {
+	h.startReplyTemplateInput(ctx, userID, chatID, messageID, fsm.StateEditReplyTemplateName, templateID,
+		"Отправьте новое название шаблона")
+}
+
+func (h *ForumAdminHandler) handleEditReplyTemplateTextStart(ctx context.Context, userID, chatID int64, messageID int, templateID int64) {
+	h.startReplyTemplateInput(ctx, userID, chatID, messageID, fsm.StateEditReplyTemplateText, templateID,
+		"Отправьте новый текст шаблона. Можно прикрепить фото, без него шаблон будет без фото.\n\n"+placeholderHelpText())
+}
+
+func (h *ForumAdminHandler) deletePromptMessage(ctx context.Context, chatID int64, state *models.AdminState) {
+	if state.LastBotMessageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: state.LastBotMessageID})
+		state.LastBotMessageID = 0
+	}
+}
+
+func (h *ForumAdminHandler) sendCancelablePrompt(ctx context.Context, chatID int64, state *models.AdminState, text string) {
+	sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "❌ Отмена", CallbackData: "cancel"}},
+			},
+		},
+	})
+	if err == nil && sentMsg != nil {
+		state.LastBotMessageID = sentMsg.ID
+		h.adminStateRepo.Save(state)
+	}
+}
+
+func (h *ForumAdminHandler) handleReplyTemplateNameInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	name := strings.TrimSpace(msg.Text)
+	if name == "" {
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Название не может быть пустым")
+		return
+	}
+
+	if state.CurrentState == fsm.StateEditReplyTemplateName {
+		tpl, err := h.replyTemplateRepo.GetByID(state.ReplyTemplateID)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to get reply template %d: %v", state.ReplyTemplateID, err)
+			h.adminStateRepo.Clear(msg.From.ID)
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Шаблон не найден"})
+			return
+		}
+		tpl.Name = name
+		if err := h.replyTemplateRepo.Update(tpl); err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to update reply template: %v", err)
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения шаблона"})
+			return
+		}
+		h.adminStateRepo.Clear(msg.From.ID)
+		h.showReplyTemplateDetails(ctx, msg.Chat.ID, 0, tpl.ID)
+		return
+	}
+
+	state.TempName = name
+	state.CurrentState = fsm.StateNewReplyTemplateText
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	h.sendCancelablePrompt(ctx, msg.Chat.ID, state,
+		"Отправьте текст шаблона. Форматирование сохранится, можно прикрепить фото.\n\n"+placeholderHelpText())
+}
+
+func (h *ForumAdminHandler) handleReplyTemplateTextInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	text, photoID, entities := messageContent(msg)
+	if text == "" && photoID == "" {
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Пожалуйста, отправьте текст или фото с текстом")
+		return
+	}
+
+	entitiesJSON := ""
+	if len(entities) > 0 {
+		entJSON, _ := json.Marshal(entities)
+		entitiesJSON = string(entJSON)
+	}
+
+	var tpl *models.ReplyTemplate
+	var err error
+	if state.CurrentState == fsm.StateEditReplyTemplateText {
+		tpl, err = h.replyTemplateRepo.GetByID(state.ReplyTemplateID)
+		if err == nil {
+			tpl.Text, tpl.PhotoID, tpl.Entities = text, photoID, entitiesJSON
+			err = h.replyTemplateRepo.Update(tpl)
+		}
+	} else {
+		tpl = &models.ReplyTemplate{
+			Name:     state.TempName,
+			Text:     text,
+			Entities: entitiesJSON,
+			PhotoID:  photoID,
+		}
+		err = h.replyTemplateRepo.Create(tpl)
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save reply template: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Ошибка сохранения шаблона",
+		})
+		return
+	}
+
+	h.adminStateRepo.Clear(msg.From.ID)
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: msg.Chat.ID,
+		Text:   fmt.Sprintf("✅ Шаблон «%s» сохранён", tpl.Name),
+	})
+	h.showReplyTemplateDetails(ctx, msg.Chat.ID, 0, tpl.ID)
+
+	log.Printf("[FORUM_ADMIN] Reply template %d saved by user %d", tpl.ID, msg.From.ID)
+}
+
+// handleReplyTemplateSelect fills the reply draft from a template and shows
+// the usual preview.
+func (h *ForumAdminHandler) handleReplyTemplateSelect(ctx context.Context, userID, chatID int64, messageID int, templateID int64) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateReplyEnterText {
+		log.Printf("[FORUM_ADMIN] Invalid state for reply template selection")
+		return
+	}
+
+	tpl, err := h.replyTemplateRepo.GetByID(templateID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get reply template %d: %v", templateID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Шаблон не найден",
+		})
+		return
+	}
+
+	var entities []tgmodels.MessageEntity
+	if tpl.Entities != "" {
+		json.Unmarshal([]byte(tpl.Entities), &entities)
+	}
+	text, entities := services.RenderPlaceholders(tpl.Text, entities, h.replyPlaceholderValues(userID, state))
+
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	state.LastBotMessageID = 0
+	state.ReplyTemplateID = tpl.ID
+	h.saveReplyDraft(ctx, chatID, state, text, tpl.PhotoID, entities)
+}
+
+// handleReplyTemplateEditDraft sends the rendered template back so the admin
+// can copy, adjust and resend it.
+func (h *ForumAdminHandler) handleReplyTemplateEditDraft(ctx context.Context, userID, chatID int64, messageID int) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateReplyConfirm {
+		log.Printf("[FORUM_ADMIN] Invalid state for reply draft editing")
+		return
+	}
+
+	state.CurrentState = fsm.StateReplyEnterText
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+
+	var entities []tgmodels.MessageEntity
+	if state.DraftEntities != "" {
+		json.Unmarshal([]byte(state.DraftEntities), &entities)
+	}
+	if state.DraftText != "" {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:   chatID,
+			Text:     state.DraftText,
+			Entities: entities,
+		})
+	}
+
+	prompt := "Скопируйте текст выше, исправьте и отправьте ответ."
+	if state.DraftPhotoID != "" {
+		prompt += " Фото из шаблона сохранится, если не прикрепить новое."
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, prompt)
+}
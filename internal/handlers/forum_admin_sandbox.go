@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// sandboxMarker precedes every test publication so it is not mistaken for a
+// real post.
+const sandboxMarker = "🧪 Тестовая публикация. Пост ниже не опубликован в форуме и не сохранён."
+
+func (h *ForumAdminHandler) sandboxEnabled() bool {
+	config, err := h.adminConfigRepo.Get()
+	return err == nil && config.SandboxChatID != 0
+}
+
+func sandboxLabel(config *models.AdminConfig) string {
+	if config.SandboxChatID == 0 {
+		return "не настроена"
+	}
+	if config.SandboxTopicID != 0 {
+		return fmt.Sprintf("%d, топик %d", config.SandboxChatID, config.SandboxTopicID)
+	}
+	return strconv.FormatInt(config.SandboxChatID, 10)
+}
+
+// handlePostTest publishes the draft to the sandbox. The draft stays in the
+// confirm step so it can be changed, tested again or published.
+func (h *ForumAdminHandler) handlePostTest(ctx context.Context, userID, chatID int64) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateNewPostConfirm {
+		log.Printf("[FORUM_ADMIN] Invalid state for test publication: %v", err)
+		return
+	}
+	if !h.checkTypeAccess(ctx, userID, chatID, state.SelectedTypeID) {
+		return
+	}
+
+	config, err := h.adminConfigRepo.Get()
+	if err != nil || config.SandboxChatID == 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Песочница не настроена: укажите её в Настройки → Настройки доступа",
+		})
+		return
+	}
+
+	marker, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:              config.SandboxChatID,
+		MessageThreadID:     int(config.SandboxTopicID),
+		Text:                sandboxMarker,
+		DisableNotification: true,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send sandbox marker: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("❌ Не удалось отправить в песочницу: %v", err),
+		})
+		return
+	}
+	messageIDs := []int64{int64(marker.ID)}
+
+	draft := state
+	if postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID); err == nil {
+		draft = numberedDraft(state, postType, postType.CounterNext)
+	}
+	post, followUps, followUpErr, err := h.publishDraft(ctx, draft, userID, config.SandboxChatID, config.SandboxTopicID)
+	if post != nil {
+		messageIDs = append(messageIDs, post.MessageID)
+		if post.UserPhotoMessageID != 0 {
+			messageIDs = append(messageIDs, post.UserPhotoMessageID)
+		}
+	}
+	messageIDs = append(messageIDs, followUps...)
+	if addErr := h.sandboxMessageRepo.Add(config.SandboxChatID, messageIDs); addErr != nil {
+		log.Printf("[FORUM_ADMIN] Failed to record sandbox messages: %v", addErr)
+	}
+
+	if err == nil {
+		err = followUpErr
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to publish test post: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("❌ Не удалось отправить в песочницу: %v", err),
+		})
+		return
+	}
+
+	text := "🧪 Тестовая публикация отправлена в песочницу"
+	if link := h.chatResolver.Permalink(ctx, config.SandboxChatID, config.SandboxTopicID, int64(marker.ID)); link != "" {
+		text += ": " + link
+	}
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               text,
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
+	})
+
+	log.Printf("[FORUM_ADMIN] Test post sent to sandbox %d by user %d", config.SandboxChatID, userID)
+}
+
+func (h *ForumAdminHandler) handleEditSandboxStart(ctx context.Context, userID, chatID int64, messageID int) {
+	config, err := h.adminConfigRepo.Get()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get config: %v", err)
+		return
+	}
+
+	state := &models.AdminState{
+		UserID:       userID,
+		CurrentState: fsm.StateEditSandbox,
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, fmt.Sprintf(
+		"Песочница: %s\n\nСюда отправляются тестовые публикации, чтобы увидеть пост так, как он будет выглядеть в форуме. Отправьте ID чата и, если нужно, ID топика через пробел (например: -1001234567890 42) или «0», чтобы отключить песочницу. Бот должен иметь право писать и удалять сообщения в этом чате.",
+		sandboxLabel(config)))
+}
+
+func (h *ForumAdminHandler) handleEditSandboxInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	fields := strings.Fields(msg.Text)
+	var chatID, topicID int64
+	var err error
+	if len(fields) == 0 || len(fields) > 2 {
+		err = fmt.Errorf("expected chat ID and optional topic ID")
+	} else {
+		chatID, err = strconv.ParseInt(fields[0], 10, 64)
+		if err == nil && len(fields) == 2 {
+			topicID, err = strconv.ParseInt(fields[1], 10, 64)
+		}
+	}
+	if err != nil {
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Неверный формат. Отправьте ID чата и, при необходимости, ID топика через пробел, или «0»")
+		return
+	}
+	if chatID == 0 {
+		topicID = 0
+	}
+
+	config, err := h.adminConfigRepo.Get()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get config: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка получения конфигурации"})
+		return
+	}
+	config.SandboxChatID = chatID
+	config.SandboxTopicID = topicID
+	if err := h.adminConfigRepo.Save(config); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save config: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения конфигурации"})
+		return
+	}
+
+	h.adminStateRepo.Clear(msg.From.ID)
+	text := "✅ Песочница настроена"
+	if chatID == 0 {
+		text = "✅ Песочница отключена"
+	}
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: text})
+	h.showAdminMenu(ctx, msg.Chat.ID, 0)
+
+	log.Printf("[FORUM_ADMIN] Sandbox set to %d/%d by user %d", chatID, topicID, msg.From.ID)
+}
+
+// handleSandboxClean deletes all recorded test messages. Messages Telegram
+// refuses to delete (e.g. older than 48 hours) are forgotten as well, since
+// later attempts would fail the same way.
+func (h *ForumAdminHandler) handleSandboxClean(ctx context.Context, userID, chatID int64, messageID int) {
+	messages, err := h.sandboxMessageRepo.GetAll()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get sandbox messages: %v", err)
+		return
+	}
+
+	failed := 0
+	for _, m := range messages {
+		_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    m.ChatID,
+			MessageID: int(m.MessageID),
+		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to delete sandbox message %d in chat %d: %v", m.MessageID, m.ChatID, err)
+			failed++
+		}
+		if err := h.sandboxMessageRepo.Delete(m.ChatID, m.MessageID); err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to forget sandbox message: %v", err)
+		}
+	}
+
+	text := fmt.Sprintf("🧹 Песочница очищена, удалено сообщений: %d", len(messages)-failed)
+	if failed > 0 {
+		text += fmt.Sprintf("\n⚠️ Не удалось удалить: %d", failed)
+	}
+	h.showAccessSettingsMenu(ctx, chatID, messageID)
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text})
+
+	log.Printf("[FORUM_ADMIN] Sandbox cleaned by user %d: %d messages, %d failed", userID, len(messages), failed)
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// sensitiveActions lists callbacks that require a PIN or a second admin's
+// approval. Keys ending with ":" match by prefix.
+var sensitiveActions = map[string]string{
+	"admin_delete_post":            "удаление поста по ссылке",
+	"post_list_delete_confirm:":    "удаление поста",
+	"reply_list_delete_confirm:":   "удаление ответа",
+	"access_edit_admins":           "изменение списка администраторов",
+	"access_add_admin":             "добавление администратора",
+	"access_remove_admin_confirm:": "удаление администратора",
+	"access_edit_forum":            "изменение ID группы",
+	"access_edit_topic":            "изменение ID топика",
+	"settings_backup":              "создание бэкапа",
+	"security_set_pin":             "изменение PIN-кода",
+	"security_remove_pin":          "удаление PIN-кода",
+	"delete_type_to:":              "удаление типа поста",
+	"delete_type_archive:":         "удаление типа поста",
+	"types_import:replace":         "замена типов постов при импорте",
+	"type_rerender_run:":           "обновление опубликованных постов типа",
+}
+
+func sensitiveActionLabel(data string) (string, bool) {
+	if label, ok := sensitiveActions[data]; ok && !strings.HasSuffix(data, ":") {
+		return label, true
+	}
+	for key, label := range sensitiveActions {
+		if strings.HasSuffix(key, ":") && strings.HasPrefix(data, key) {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+// confirmSensitive returns true when the action may run right away. Otherwise
+// it asks for a PIN or a second admin's approval; once confirmed, the admin
+// gets a button that repeats the original callback.
+func (h *ForumAdminHandler) confirmSensitive(ctx context.Context, userID, chatID int64, action string) bool {
+	label, ok := sensitiveActionLabel(action)
+	if !ok {
+		return true
+	}
+	if h.confirmationGuard.ConsumeGrant(userID, action) {
+		log.Printf("[FORUM_ADMIN] User %d runs approved %s", userID, action)
+		return true
+	}
+
+	admins, err := h.settingsManager.GetAdmins()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get admins: %v", err)
+	}
+
+	switch h.confirmationGuard.Method(userID, len(admins)) {
+	case services.ConfirmPIN:
+		h.confirmationGuard.SetPending(userID, action)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("🔐 Введите PIN-код для подтверждения: %s", label),
+			ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+				InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+					{{Text: "❌ Отмена", CallbackData: "cancel"}},
+				},
+			},
+		})
+		log.Printf("[FORUM_ADMIN] PIN requested from user %d for %s", userID, action)
+		return false
+
+	case services.ConfirmSecondAdmin:
+		req, err := h.confirmationGuard.RequestApproval(userID, chatID, action)
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to create approval request: %v", err)
+			h.bot.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "❌ Не удалось создать запрос на подтверждение",
+			})
+			return false
+		}
+
+		text := fmt.Sprintf("🔐 Администратор %s запрашивает подтверждение: %s",
+			h.userManager.MentionHTML(userID), label)
+		keyboard := &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{
+					{Text: "✅ Подтвердить", CallbackData: "approve_action:" + req.Token},
+					{Text: "❌ Отклонить", CallbackData: "reject_action:" + req.Token},
+				},
+			},
+		}
+		notified := 0
+		for _, adminID := range admins {
+			if adminID == userID {
+				continue
+			}
+			_, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:      adminID,
+				Text:        text,
+				ParseMode:   tgmodels.ParseModeHTML,
+				ReplyMarkup: keyboard,
+			})
+			if err != nil {
+				log.Printf("[FORUM_ADMIN] Failed to send approval request to %d: %v", adminID, err)
+				continue
+			}
+			notified++
+		}
+
+		reply := "⏳ Запрос на подтверждение отправлен другим администраторам"
+		if notified == 0 {
+			reply = "❌ Не удалось отправить запрос ни одному администратору. Они должны сначала написать боту"
+		}
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   reply,
+		})
+		log.Printf("[FORUM_ADMIN] Approval requested by user %d for %s, notified %d admins", userID, action, notified)
+		return false
+	}
+
+	return true
+}
+
+func (h *ForumAdminHandler) sendContinueButton(ctx context.Context, chatID int64, text, action string) {
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "▶️ Продолжить", CallbackData: action}},
+				{{Text: "❌ Отмена", CallbackData: "cancel"}},
+			},
+		},
+	})
+}
+
+// ExpectsPIN reports whether the user's next message is a PIN, so its text
+// must not be logged.
+func (h *ForumAdminHandler) ExpectsPIN(userID int64) bool {
+	if _, ok := h.confirmationGuard.Pending(userID); ok {
+		return true
+	}
+	state, err := h.adminStateRepo.Get(userID)
+	return err == nil && state != nil && state.CurrentState == fsm.StateSetPIN
+}
+
+func (h *ForumAdminHandler) handlePINInput(ctx context.Context, msg *tgmodels.Message, action string) {
+	// The message contains the PIN, so it should not stay in the chat history.
+	_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to delete PIN message: %v", err)
+	}
+
+	ok, err := h.confirmationGuard.VerifyPIN(msg.From.ID, strings.TrimSpace(msg.Text))
+	if errors.Is(err, services.ErrPINLocked) {
+		h.confirmationGuard.ClearPending(msg.From.ID)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "🔒 Слишком много неверных попыток. Попробуйте позже",
+		})
+		log.Printf("[FORUM_ADMIN] PIN locked for user %d", msg.From.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to verify PIN: %v", err)
+		h.confirmationGuard.ClearPending(msg.From.ID)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Ошибка проверки PIN-кода",
+		})
+		return
+	}
+	if !ok {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Неверный PIN-код. Попробуйте еще раз или нажмите /cancel",
+		})
+		log.Printf("[FORUM_ADMIN] Wrong PIN from user %d", msg.From.ID)
+		return
+	}
+
+	h.confirmationGuard.ClearPending(msg.From.ID)
+	h.sendContinueButton(ctx, msg.Chat.ID, "✅ PIN-код подтвержден", action)
+	log.Printf("[FORUM_ADMIN] PIN confirmed by user %d for %s", msg.From.ID, action)
+}
+
+func (h *ForumAdminHandler) handleApprovalCallback(ctx context.Context, approverID, chatID int64, messageID int, token string, approve bool) {
+	req, err := h.confirmationGuard.ResolveApproval(token, approverID, approve)
+	if err != nil {
+		text := "❌ Запрос не найден или устарел"
+		if errors.Is(err, services.ErrSelfApproval) {
+			text = "❌ Нельзя подтвердить собственный запрос"
+		}
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      text,
+		})
+		return
+	}
+
+	label, _ := sensitiveActionLabel(req.Action)
+	if approve {
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      fmt.Sprintf("✅ Подтверждено: %s", label),
+		})
+		h.sendContinueButton(ctx, req.ChatID,
+			fmt.Sprintf("✅ Администратор %s подтвердил действие: %s", h.userManager.DisplayName(approverID), label),
+			req.Action)
+		log.Printf("[FORUM_ADMIN] User %d approved %s for user %d", approverID, req.Action, req.RequesterID)
+		return
+	}
+
+	h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: messageID,
+		Text:      fmt.Sprintf("❌ Отклонено: %s", label),
+	})
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: req.ChatID,
+		Text:   fmt.Sprintf("❌ Администратор %s отклонил действие: %s", h.userManager.DisplayName(approverID), label),
+	})
+	log.Printf("[FORUM_ADMIN] User %d rejected %s for user %d", approverID, req.Action, req.RequesterID)
+}
+
+func (h *ForumAdminHandler) showSecurityMenu(ctx context.Context, userID, chatID int64, messageID int) {
+	hasPIN := h.confirmationGuard.HasPIN(userID)
+
+	status := "не установлен"
+	if hasPIN {
+		status = "установлен"
+	}
+	text := fmt.Sprintf("🔑 PIN-код: %s\n\n"+
+		"PIN-код запрашивается перед удалением постов и ответов, изменением настроек доступа и созданием бэкапа. "+
+		"После %s бездействия PIN-код запрашивается снова.",
+		status, h.confirmationGuard.IdleTimeout())
+
+	rows := [][]tgmodels.InlineKeyboardButton{}
+	if hasPIN {
+		rows = append(rows,
+			[]tgmodels.InlineKeyboardButton{{Text: "✏️ Изменить PIN-код", CallbackData: "security_set_pin"}},
+			[]tgmodels.InlineKeyboardButton{{Text: "🗑 Удалить PIN-код", CallbackData: "security_remove_pin"}},
+		)
+	} else {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "➕ Установить PIN-код", CallbackData: "security_set_pin"}})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "admin_settings"}})
+	keyboard := &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
+
+	if messageID > 0 {
+		_, err := h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			ReplyMarkup: keyboard,
+		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to edit security menu: %v", err)
+		}
+	} else {
+		_, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        text,
+			ReplyMarkup: keyboard,
+		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to send security menu: %v", err)
+		}
+	}
+}
+
+func (h *ForumAdminHandler) handleSetPINStart(ctx context.Context, userID, chatID int64, messageID int) {
+	err := h.adminStateRepo.Save(&models.AdminState{
+		UserID:       userID,
+		CurrentState: fsm.StateSetPIN,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Ошибка сохранения состояния",
+		})
+		return
+	}
+
+	sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "Отправьте новый PIN-код (от 4 до 12 цифр). Сообщение с PIN-кодом будет удалено.",
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "❌ Отмена", CallbackData: "cancel"}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send set PIN prompt: %v", err)
+	} else if sentMsg != nil {
+		state, _ := h.adminStateRepo.Get(userID)
+		if state != nil {
+			state.LastBotMessageID = sentMsg.ID
+			h.adminStateRepo.Save(state)
+		}
+	}
+
+	log.Printf("[FORUM_ADMIN] Set PIN started for user %d", userID)
+}
+
+func (h *ForumAdminHandler) handleSetPINInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to delete PIN message: %v", err)
+	}
+
+	if state.LastBotMessageID > 0 {
+		_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    msg.Chat.ID,
+			MessageID: state.LastBotMessageID,
+		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to delete prompt message: %v", err)
+		}
+		state.LastBotMessageID = 0
+	}
+
+	err = h.confirmationGuard.SetPIN(msg.From.ID, strings.TrimSpace(msg.Text))
+	if errors.Is(err, services.ErrInvalidPIN) {
+		sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ PIN-код должен состоять из 4–12 цифр",
+		})
+		if err == nil && sentMsg != nil {
+			state.LastBotMessageID = sentMsg.ID
+			h.adminStateRepo.Save(state)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to set PIN: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Ошибка сохранения PIN-кода",
+		})
+		return
+	}
+
+	if err := h.adminStateRepo.Clear(msg.From.ID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to clear state: %v", err)
+	}
+
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: msg.Chat.ID,
+		Text:   "✅ PIN-код установлен",
+	})
+	h.showSecurityMenu(ctx, msg.From.ID, msg.Chat.ID, 0)
+
+	log.Printf("[FORUM_ADMIN] PIN set by user %d", msg.From.ID)
+}
+
+func (h *ForumAdminHandler) handleRemovePIN(ctx context.Context, userID, chatID int64, messageID int) {
+	if err := h.confirmationGuard.RemovePIN(userID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to remove PIN: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Ошибка удаления PIN-кода",
+		})
+		return
+	}
+
+	log.Printf("[FORUM_ADMIN] PIN removed by user %d", userID)
+	h.showSecurityMenu(ctx, userID, chatID, messageID)
+}
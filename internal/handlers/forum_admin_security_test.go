@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestSensitiveActionLabel(t *testing.T) {
+	cases := map[string]bool{
+		"settings_backup":                true,
+		"post_list_delete_confirm:5:0":   true,
+		"reply_list_delete_confirm:7:1":  true,
+		"access_remove_admin_confirm:42": true,
+		"access_remove_admin:42":         false,
+		"post_list_delete:5:0":           false,
+		"post_list_delete_confirm:":      true,
+		"admin_settings":                 false,
+	}
+	for data, want := range cases {
+		if _, got := sensitiveActionLabel(data); got != want {
+			t.Errorf("sensitiveActionLabel(%q) = %v, want %v", data, got, want)
+		}
+	}
+}
+
+func TestExpectsPIN(t *testing.T) {
+	handler, testDB := setupForumAdminHandler(t)
+	defer testDB.Close()
+
+	if handler.ExpectsPIN(1) {
+		t.Error("ExpectsPIN() without a prompt = true")
+	}
+	handler.confirmationGuard.SetPending(1, "settings_backup")
+	if !handler.ExpectsPIN(1) {
+		t.Error("ExpectsPIN() with a pending confirmation = false")
+	}
+	if err := handler.adminStateRepo.Save(&models.AdminState{UserID: 2, CurrentState: fsm.StateSetPIN}); err != nil {
+		t.Fatal(err)
+	}
+	if !handler.ExpectsPIN(2) {
+		t.Error("ExpectsPIN() while setting a PIN = false")
+	}
+}
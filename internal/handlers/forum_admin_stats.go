@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+const (
+	statsDefaultRange = "30"
+	statsDateLayout   = "20060102"
+	// statsWeeksUpTo is the longest range, in days, grouped by week by
+	// default; longer ones are grouped by month.
+	statsWeeksUpTo = 92
+)
+
+var statsPresets = []struct {
+	spec  string
+	label string
+}{
+	{"7", "7 дней"},
+	{"30", "30 дней"},
+	{"90", "90 дней"},
+	{"365", "Год"},
+}
+
+// parseStatsRange reads a range of a callback: the last N days up to today,
+// or "20260901-20260930". Days start at midnight in loc. The end is
+// exclusive: the day after the last one.
+func parseStatsRange(spec string, now time.Time, loc *time.Location) (time.Time, time.Time, bool) {
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if fromText, toText, ok := strings.Cut(spec, "-"); ok {
+		from, err := time.ParseInLocation(statsDateLayout, fromText, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		to, err := time.ParseInLocation(statsDateLayout, toText, loc)
+		if err != nil || to.Before(from) {
+			return time.Time{}, time.Time{}, false
+		}
+		return from, to.AddDate(0, 0, 1), true
+	}
+	days, err := strconv.Atoi(spec)
+	if err != nil || days <= 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	to := today.AddDate(0, 0, 1)
+	return to.AddDate(0, 0, -days), to, true
+}
+
+// parseStatsRangeInput reads a range typed by an admin, such as
+// "01.09.2026-30.09.2026" or a single day "01.09.2026", and returns it as a
+// callback range.
+func parseStatsRangeInput(s string) (string, bool) {
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "–", "-"), "—", "-")
+	fromText, toText, isRange := strings.Cut(s, "-")
+	if !isRange {
+		toText = fromText
+	}
+	from, err := time.Parse("02.01.2006", strings.TrimSpace(fromText))
+	if err != nil {
+		return "", false
+	}
+	to, err := time.Parse("02.01.2006", strings.TrimSpace(toText))
+	if err != nil {
+		return "", false
+	}
+	if to.Before(from) {
+		from, to = to, from
+	}
+	return from.Format(statsDateLayout) + "-" + to.Format(statsDateLayout), true
+}
+
+func defaultStatsPeriod(from, to time.Time) string {
+	if to.Sub(from) > statsWeeksUpTo*24*time.Hour {
+		return services.StatsPeriodMonth
+	}
+	return services.StatsPeriodWeek
+}
+
+// usageStats collects the statistics of the range.
+func (h *ForumAdminHandler) usageStats(from, to time.Time, period string) (*services.UsageStats, error) {
+	types, err := h.postTypeRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	posts, err := h.publishedPostRepo.GetCreatedBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := h.replyRepo.GetCreatedBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	events, err := h.postEventRepo.GetCreatedBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewUsageStats(from, to, h.statsLocation, period, types, posts, replies, events, h.userManager.DisplayName), nil
+}
+
+// recordPostEvent logs an edit or deletion of a post or reply for the
+// statistics.
+func (h *ForumAdminHandler) recordPostEvent(action string, adminID, postTypeID int64) {
+	if err := h.postEventRepo.Create(&models.PostEvent{Action: action, AdminID: adminID, PostTypeID: postTypeID}); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to record %s by user %d: %v", action, adminID, err)
+	}
+}
+
+// showStats shows the statistics of the range. A period of "" picks weeks
+// or months by the length of the range.
+func (h *ForumAdminHandler) showStats(ctx context.Context, chatID int64, messageID int, spec, period string) {
+	from, to, ok := parseStatsRange(spec, time.Now(), h.statsLocation)
+	if !ok {
+		log.Printf("[FORUM_ADMIN] Invalid stats range %q", spec)
+		return
+	}
+	if period != services.StatsPeriodWeek && period != services.StatsPeriodMonth {
+		period = defaultStatsPeriod(from, to)
+	}
+	stats, err := h.usageStats(from, to, period)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to collect stats: %v", err)
+		h.editOrSendMenu(ctx, chatID, messageID, "❌ Ошибка получения статистики", &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{{{Text: "← Назад", CallbackData: "admin_settings"}}},
+		})
+		return
+	}
+
+	var presets []tgmodels.InlineKeyboardButton
+	for _, preset := range statsPresets {
+		label := preset.label
+		if preset.spec == spec {
+			label = "• " + label
+		}
+		presets = append(presets, tgmodels.InlineKeyboardButton{Text: label, CallbackData: fmt.Sprintf("stats:%s:%s", preset.spec, period)})
+	}
+	toggle := tgmodels.InlineKeyboardButton{Text: "🗓 По месяцам", CallbackData: fmt.Sprintf("stats:%s:%s", spec, services.StatsPeriodMonth)}
+	if period == services.StatsPeriodMonth {
+		toggle = tgmodels.InlineKeyboardButton{Text: "🗓 По неделям", CallbackData: fmt.Sprintf("stats:%s:%s", spec, services.StatsPeriodWeek)}
+	}
+	keyboard := &tgmodels.InlineKeyboardMarkup{InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+		presets,
+		{{Text: "📅 Свой период", CallbackData: "stats_range"}},
+		{toggle, {Text: "📥 CSV", CallbackData: fmt.Sprintf("stats_csv:%s:%s", spec, period)}},
+		{{Text: "← Назад", CallbackData: "admin_settings"}},
+	}}
+
+	text, entities := stats.Text()
+	parts := services.SplitText(text, entities, services.MessageLimit, services.MessageLimit)
+	if len(parts) == 1 && messageID > 0 {
+		_, err := h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			Entities:    entities,
+			ReplyMarkup: keyboard,
+		})
+		if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+			log.Printf("[FORUM_ADMIN] Failed to edit stats: %v", err)
+		}
+		return
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	for i, part := range parts {
+		params := &bot.SendMessageParams{ChatID: chatID, Text: part.Text, Entities: part.Entities}
+		if i == len(parts)-1 {
+			params.ReplyMarkup = keyboard
+		}
+		if _, err := h.bot.SendMessage(ctx, params); err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to send stats: %v", err)
+			return
+		}
+	}
+}
+
+func (h *ForumAdminHandler) handleStatsCSV(ctx context.Context, chatID int64, spec, period string) {
+	from, to, ok := parseStatsRange(spec, time.Now(), h.statsLocation)
+	if !ok {
+		log.Printf("[FORUM_ADMIN] Invalid stats range %q", spec)
+		return
+	}
+	stats, err := h.usageStats(from, to, period)
+	var data []byte
+	if err == nil {
+		data, err = stats.CSV()
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to export stats: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Не удалось выгрузить статистику"})
+		return
+	}
+
+	last := to.AddDate(0, 0, -1)
+	_, err = h.bot.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID: chatID,
+		Document: &tgmodels.InputFileUpload{
+			Filename: fmt.Sprintf("stats_%s_%s.csv", from.Format("2006-01-02"), last.Format("2006-01-02")),
+			Data:     bytes.NewReader(data),
+		},
+		Caption: fmt.Sprintf("📥 Статистика за %s–%s (время %s)", from.Format("02.01.2006"), last.Format("02.01.2006"), h.statsLocation),
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send stats CSV: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Не удалось отправить файл"})
+	}
+}
+
+func (h *ForumAdminHandler) handleStatsRangeStart(ctx context.Context, userID, chatID int64, messageID int) {
+	state := &models.AdminState{UserID: userID, CurrentState: fsm.StateStatsRange}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, fmt.Sprintf("Отправьте период в виде «01.09.2026-30.09.2026» или один день «01.09.2026». Даты — по часовому поясу %s.", h.statsLocation))
+}
+
+func (h *ForumAdminHandler) handleStatsRangeInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	spec, ok := parseStatsRangeInput(msg.Text)
+	if !ok {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Отправьте период вида «01.09.2026-30.09.2026»"})
+		return
+	}
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+	h.adminStateRepo.Clear(msg.From.ID)
+	h.showStats(ctx, msg.Chat.ID, 0, spec, "")
+}
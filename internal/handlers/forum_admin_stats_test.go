@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestParseStatsRange(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+	from, to, ok := parseStatsRange("7", now, time.UTC)
+	if !ok || !from.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseStatsRange(7) = %v, %v, %v", from, to, ok)
+	}
+
+	spec, ok := parseStatsRangeInput(" 30.09.2026 – 01.09.2026 ")
+	if !ok || spec != "20260901-20260930" {
+		t.Fatalf("parseStatsRangeInput() = %q, %v", spec, ok)
+	}
+	from, to, ok = parseStatsRange(spec, now, time.UTC)
+	if !ok || !from.Equal(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseStatsRange(%q) = %v, %v, %v", spec, from, to, ok)
+	}
+	if spec, ok := parseStatsRangeInput("01.09.2026"); !ok || spec != "20260901-20260901" {
+		t.Errorf("parseStatsRangeInput() of one day = %q, %v", spec, ok)
+	}
+
+	// 15:00 UTC is already the next day at UTC+10.
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	from, to, ok = parseStatsRange("1", now, loc)
+	if !ok || !from.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, loc)) || !to.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, loc)) {
+		t.Errorf("parseStatsRange(1) at UTC+10 = %v, %v, %v", from, to, ok)
+	}
+
+	for _, spec := range []string{"", "0", "-5", "20260930-20260901", "2026-09-01"} {
+		if _, _, ok := parseStatsRange(spec, now, time.UTC); ok {
+			t.Errorf("parseStatsRange(%q) accepted", spec)
+		}
+	}
+	if _, ok := parseStatsRangeInput("сентябрь"); ok {
+		t.Error("parseStatsRangeInput() accepted a word")
+	}
+}
+
+func TestUsageStats(t *testing.T) {
+	h, testDB := setupForumAdminHandler(t)
+	defer testDB.Close()
+
+	postType := &models.PostType{Name: "Новости", Template: "шаблон", IsActive: true}
+	if err := h.postTypeRepo.Create(postType); err != nil {
+		t.Fatalf("Failed to create post type: %v", err)
+	}
+	post := &models.PublishedPost{PostTypeID: postType.ID, ChatID: -100, MessageID: 1, Text: "пост", AuthorID: 7}
+	if err := h.publishedPostRepo.Create(post); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	if err := h.replyRepo.Create(&models.Reply{ChatID: -100, ReplyToMessageID: 1, MessageID: 2, Text: "ответ", AuthorID: 7}); err != nil {
+		t.Fatalf("Failed to create reply: %v", err)
+	}
+	h.recordPostEvent(models.PostEventPostEdit, 7, postType.ID)
+	h.recordPostEvent(models.PostEventReplyDelete, 8, 0)
+
+	from, to, _ := parseStatsRange("1", time.Now(), h.statsLocation)
+	stats, err := h.usageStats(from, to, "")
+	if err != nil {
+		t.Fatalf("usageStats() error = %v", err)
+	}
+	if stats.Posts != 1 || stats.Replies != 1 || stats.PostEdits != 1 || stats.ReplyDeletions != 1 {
+		t.Errorf("usageStats() = %+v", stats)
+	}
+	if len(stats.Types) != 1 || stats.Types[0].Edits != 1 || len(stats.Admins) != 2 || stats.Admins[0].Posts != 1 {
+		t.Errorf("usageStats() types %+v, admins %+v", stats.Types, stats.Admins)
+	}
+
+	stats, err = h.usageStats(from.AddDate(0, 0, -7), from, "")
+	if err != nil || stats.Posts != 0 || stats.PostEdits != 0 {
+		t.Errorf("usageStats() of last week = %+v, %v", stats, err)
+	}
+}
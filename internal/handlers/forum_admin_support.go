@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+const (
+	ticketListPageSize = 10
+	ticketHistorySize  = 15
+)
+
+// HandleSupportMessage handles the support inbox: private messages from
+// non-admins become tickets, and admin replies to ticket messages are
+// delivered to the user. It returns false when the inbox is disabled.
+func (h *ForumAdminHandler) HandleSupportMessage(ctx context.Context, msg *tgmodels.Message) bool {
+	if h.supportInbox == nil || msg.From == nil {
+		return false
+	}
+
+	if h.authMiddleware.ShouldIgnore(msg.From.ID) {
+		if msg.Chat.Type != tgmodels.ChatTypePrivate {
+			return false
+		}
+		h.handleUserSupportMessage(ctx, msg)
+		return true
+	}
+
+	if msg.ReplyToMessage == nil {
+		return false
+	}
+	ticket, err := h.supportInbox.TicketForMessage(msg.Chat.ID, int64(msg.ReplyToMessage.ID))
+	if err != nil {
+		return false
+	}
+	h.handleTicketAnswer(ctx, msg, ticket)
+	return true
+}
+
+func (h *ForumAdminHandler) supportTargets() []int64 {
+	if chatID := h.supportInbox.ChatID(); chatID != 0 {
+		return []int64{chatID}
+	}
+	admins, err := h.settingsManager.GetAdmins()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get admins: %v", err)
+		return nil
+	}
+	return admins
+}
+
+func (h *ForumAdminHandler) handleUserSupportMessage(ctx context.Context, msg *tgmodels.Message) {
+	if msg.Text == "/start" {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "👋 Напишите свой вопрос, и администраторы ответят вам здесь.",
+		})
+		return
+	}
+
+	ticket, isNew, err := h.supportInbox.Receive(msg)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to store support message from %d: %v", msg.From.ID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Не удалось передать сообщение. Попробуйте позже",
+		})
+		return
+	}
+
+	for _, target := range h.supportTargets() {
+		if isNew {
+			text, entities := h.ticketHeader(ticket)
+			sent, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:      target,
+				Text:        text,
+				Entities:    entities,
+				ReplyMarkup: ticketKeyboard(ticket, false),
+			})
+			if err != nil {
+				log.Printf("[FORUM_ADMIN] Failed to send ticket %d header to %d: %v", ticket.ID, target, err)
+				continue
+			}
+			h.linkTicketMessage(target, sent.ID, ticket.ID)
+		}
+
+		forwarded, err := h.bot.ForwardMessage(ctx, &bot.ForwardMessageParams{
+			ChatID:     target,
+			FromChatID: msg.Chat.ID,
+			MessageID:  msg.ID,
+		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to forward ticket %d message to %d: %v", ticket.ID, target, err)
+			continue
+		}
+		h.linkTicketMessage(target, forwarded.ID, ticket.ID)
+	}
+
+	if isNew {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "✅ Сообщение получено. Администраторы ответят вам здесь.",
+		})
+	}
+	log.Printf("[FORUM_ADMIN] Support message from user %d stored in ticket %d", msg.From.ID, ticket.ID)
+}
+
+func (h *ForumAdminHandler) linkTicketMessage(chatID int64, messageID int, ticketID int64) {
+	if err := h.supportInbox.LinkMessage(chatID, int64(messageID), ticketID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to link message %d to ticket %d: %v", messageID, ticketID, err)
+	}
+}
+
+func (h *ForumAdminHandler) handleTicketAnswer(ctx context.Context, msg *tgmodels.Message, ticket *models.Ticket) {
+	reply := func(text string) {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          msg.Chat.ID,
+			Text:            text,
+			ReplyParameters: &tgmodels.ReplyParameters{MessageID: msg.ID},
+		})
+	}
+
+	mediaType, text, fileID, ok := services.TicketContent(msg)
+	if !ok {
+		reply("❌ Пользователю можно отправить только текст, фото или документ")
+		return
+	}
+
+	var err error
+	switch mediaType {
+	case models.TicketMediaPhoto:
+		_, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
+			ChatID:          ticket.UserID,
+			Photo:           &tgmodels.InputFileString{Data: fileID},
+			Caption:         text,
+			CaptionEntities: msg.CaptionEntities,
+		})
+	case models.TicketMediaDocument:
+		_, err = h.bot.SendDocument(ctx, &bot.SendDocumentParams{
+			ChatID:          ticket.UserID,
+			Document:        &tgmodels.InputFileString{Data: fileID},
+			Caption:         text,
+			CaptionEntities: msg.CaptionEntities,
+		})
+	default:
+		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:   ticket.UserID,
+			Text:     text,
+			Entities: msg.Entities,
+		})
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to deliver answer for ticket %d: %v", ticket.ID, err)
+		reply(fmt.Sprintf("❌ Не удалось доставить ответ: %v", err))
+		return
+	}
+
+	if err := h.supportInbox.RecordAnswer(ticket, msg.From.ID, mediaType, text, fileID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to record answer for ticket %d: %v", ticket.ID, err)
+	}
+
+	status := ""
+	if ticket.Status == models.TicketStatusClosed {
+		status = " Обращение закрыто; новое сообщение пользователя откроет новое обращение."
+	}
+	reply(fmt.Sprintf("✅ Ответ по обращению #%d отправлен.%s", ticket.ID, status))
+	log.Printf("[FORUM_ADMIN] Ticket %d answered by user %d", ticket.ID, msg.From.ID)
+}
+
+func ticketStatusLabel(status string) string {
+	if status == models.TicketStatusClosed {
+		return "закрыто"
+	}
+	return "открыто"
+}
+
+func (h *ForumAdminHandler) ticketHeader(ticket *models.Ticket) (string, []tgmodels.MessageEntity) {
+	text, mention := h.userManager.Mention(fmt.Sprintf("🎫 Обращение #%d\nПользователь: ", ticket.ID), ticket.UserID)
+	text += fmt.Sprintf(" (%d)\nСтатус: %s", ticket.UserID, ticketStatusLabel(ticket.Status))
+	if ticket.AssignedTo != 0 {
+		text += "\nОтветственный: " + h.userManager.DisplayName(ticket.AssignedTo)
+	}
+	return text, []tgmodels.MessageEntity{mention}
+}
+
+// ticketKeyboard builds ticket actions; withBack adds navigation for the
+// ticket card opened from the list.
+func ticketKeyboard(ticket *models.Ticket, withBack bool) *tgmodels.InlineKeyboardMarkup {
+	statusButton := tgmodels.InlineKeyboardButton{Text: "✅ Закрыть", CallbackData: fmt.Sprintf("ticket_close:%d", ticket.ID)}
+	if ticket.Status == models.TicketStatusClosed {
+		statusButton = tgmodels.InlineKeyboardButton{Text: "🔓 Открыть снова", CallbackData: fmt.Sprintf("ticket_reopen:%d", ticket.ID)}
+	}
+	rows := [][]tgmodels.InlineKeyboardButton{
+		{
+			{Text: "🙋 Взять себе", CallbackData: fmt.Sprintf("ticket_assign:%d", ticket.ID)},
+			statusButton,
+		},
+		{{Text: "📜 История", CallbackData: fmt.Sprintf("ticket_view:%d", ticket.ID)}},
+	}
+	if withBack {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: fmt.Sprintf("tickets:%s:0", ticket.Status)}})
+	}
+	return &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func (h *ForumAdminHandler) showTicketList(ctx context.Context, chatID int64, messageID int, status string, page int) {
+	total, err := h.ticketRepo.CountByStatus(status)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to count tickets: %v", err)
+		return
+	}
+	tickets, err := h.ticketRepo.GetPaginated(status, ticketListPageSize, int64(page*ticketListPageSize))
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get tickets: %v", err)
+		return
+	}
+
+	totalPages := int((total + ticketListPageSize - 1) / ticketListPageSize)
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	text := fmt.Sprintf("🎫 Обращения: %s (%d)", map[string]string{
+		models.TicketStatusOpen:   "открытые",
+		models.TicketStatusClosed: "закрытые",
+	}[status], total)
+	if totalPages > 1 {
+		text += fmt.Sprintf("\nСтр. %d/%d", page+1, totalPages)
+	}
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, ticket := range tickets {
+		label := fmt.Sprintf("#%d · %s · %s", ticket.ID, h.userManager.DisplayName(ticket.UserID), ticket.UpdatedAt.Format("02.01 15:04"))
+		if ticket.AssignedTo != 0 {
+			label += " · 🙋 " + h.userManager.DisplayName(ticket.AssignedTo)
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: label, CallbackData: fmt.Sprintf("ticket_view:%d", ticket.ID)},
+		})
+	}
+
+	var navRow []tgmodels.InlineKeyboardButton
+	if page > 0 {
+		navRow = append(navRow, tgmodels.InlineKeyboardButton{Text: "← Пред.", CallbackData: fmt.Sprintf("tickets:%s:%d", status, page-1)})
+	}
+	if page < totalPages-1 {
+		navRow = append(navRow, tgmodels.InlineKeyboardButton{Text: "След. →", CallbackData: fmt.Sprintf("tickets:%s:%d", status, page+1)})
+	}
+	if len(navRow) > 0 {
+		rows = append(rows, navRow)
+	}
+
+	otherStatus, otherLabel := models.TicketStatusClosed, "📁 Закрытые"
+	if status == models.TicketStatusClosed {
+		otherStatus, otherLabel = models.TicketStatusOpen, "📂 Открытые"
+	}
+	rows = append(rows,
+		[]tgmodels.InlineKeyboardButton{{Text: otherLabel, CallbackData: fmt.Sprintf("tickets:%s:0", otherStatus)}},
+		[]tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "post_list_back"}},
+	)
+	keyboard := &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows}
+
+	if messageID > 0 {
+		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			ReplyMarkup: keyboard,
+		})
+		if err == nil {
+			return
+		}
+	}
+	_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to show ticket list: %v", err)
+	}
+}
+
+func ticketMessageLine(msg *models.TicketMessage, authorName string) string {
+	prefix := "👤"
+	if msg.Direction == models.TicketDirectionOut {
+		prefix = "🛡 " + authorName
+	}
+
+	body := strings.TrimSpace(msg.Text)
+	switch msg.MediaType {
+	case models.TicketMediaPhoto:
+		body = strings.TrimSpace("[фото] " + body)
+	case models.TicketMediaDocument:
+		body = strings.TrimSpace("[документ] " + body)
+	}
+	return fmt.Sprintf("%s %s: %s", prefix, msg.CreatedAt.Format("02.01 15:04"), truncateRunes(body, 300))
+}
+
+// showTicket renders the ticket card with its recent history. The card is
+// linked to the ticket, so replying to it answers the user.
+func (h *ForumAdminHandler) showTicket(ctx context.Context, chatID int64, messageID int, ticketID int64) {
+	ticket, err := h.ticketRepo.GetByID(ticketID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get ticket %d: %v", ticketID, err)
+		return
+	}
+	messages, err := h.ticketRepo.GetMessages(ticketID, ticketHistorySize)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get ticket messages: %v", err)
+		return
+	}
+
+	text, entities := h.ticketHeader(ticket)
+	text += fmt.Sprintf("\nСоздано: %s\n\n", ticket.CreatedAt.Format("02.01.2006 15:04"))
+	for _, m := range messages {
+		text += ticketMessageLine(m, h.userManager.DisplayName(m.AdminID)) + "\n"
+	}
+	text += "\nОтветьте на это сообщение, чтобы написать пользователю."
+	text = truncateRunes(text, 4000)
+
+	keyboard := ticketKeyboard(ticket, true)
+	if messageID > 0 {
+		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			Entities:    entities,
+			ReplyMarkup: keyboard,
+		})
+		if err == nil {
+			h.linkTicketMessage(chatID, messageID, ticket.ID)
+			return
+		}
+	}
+	sent, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		Entities:    entities,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to show ticket: %v", err)
+		return
+	}
+	h.linkTicketMessage(chatID, sent.ID, ticket.ID)
+}
+
+func (h *ForumAdminHandler) handleTicketAction(ctx context.Context, userID, chatID int64, messageID int, action string, ticketID int64) {
+	var err error
+	switch action {
+	case "ticket_assign":
+		err = h.ticketRepo.Assign(ticketID, userID)
+	case "ticket_close":
+		err = h.ticketRepo.SetStatus(ticketID, models.TicketStatusClosed)
+	case "ticket_reopen":
+		err = h.ticketRepo.SetStatus(ticketID, models.TicketStatusOpen)
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to apply %s to ticket %d: %v", action, ticketID, err)
+		return
+	}
+
+	log.Printf("[FORUM_ADMIN] %s applied to ticket %d by user %d", action, ticketID, userID)
+	h.showTicket(ctx, chatID, messageID, ticketID)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// filterAllowedTypes keeps the types the admin may publish.
+func filterAllowedTypes(types []*models.PostType, userID int64) []*models.PostType {
+	var allowed []*models.PostType
+	for _, pt := range types {
+		if pt.AllowsAdmin(userID) {
+			allowed = append(allowed, pt)
+		}
+	}
+	return allowed
+}
+
+// checkTypeAccess reports whether the admin may use the type and tells them
+// when they may not. It is called wherever a type is applied, not only when
+// the list is built, so that old buttons and drafts cannot bypass it.
+func (h *ForumAdminHandler) checkTypeAccess(ctx context.Context, userID, chatID, typeID int64) bool {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		// A missing type is reported by the caller's own lookup.
+		return true
+	}
+	if postType.AllowsAdmin(userID) {
+		return true
+	}
+
+	log.Printf("[FORUM_ADMIN] User %d denied access to type %d", userID, typeID)
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("🔒 Тип «%s» доступен только назначенным администраторам", postType.Name),
+	})
+	return false
+}
+
+func (h *ForumAdminHandler) checkPostAccess(ctx context.Context, userID, chatID int64, post *models.PublishedPost) bool {
+	return h.checkTypeAccess(ctx, userID, chatID, post.PostTypeID)
+}
+
+func (h *ForumAdminHandler) handleTypeAccess(ctx context.Context, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	admins, err := h.settingsManager.GetAdmins()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get admins: %v", err)
+		return
+	}
+
+	allowed := postType.AllowedAdminIDs()
+	// Admins removed from the bot stay listed so they can be unchecked.
+	for _, id := range allowed {
+		if !slices.Contains(admins, id) {
+			admins = append(admins, id)
+		}
+	}
+
+	current := "все администраторы"
+	if len(allowed) > 0 {
+		current = fmt.Sprintf("только отмеченные (%d)", len(allowed))
+	}
+	text := fmt.Sprintf("🔐 Доступ к типу «%s»: %s\n\nОтмеченные администраторы могут публиковать и редактировать посты этого типа. Если никто не отмечен, тип доступен всем.", postType.Name, current)
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, id := range admins {
+		mark := "⬜ "
+		if slices.Contains(allowed, id) {
+			mark = "✅ "
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: mark + h.userManager.DisplayName(id), CallbackData: fmt.Sprintf("type_access_toggle:%d:%d", typeID, id)},
+		})
+	}
+	if len(allowed) > 0 {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: "👥 Открыть всем", CallbackData: fmt.Sprintf("type_access_clear:%d", typeID)},
+		})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{
+		{Text: "← Назад", CallbackData: fmt.Sprintf("manage_type:%d", typeID)},
+	})
+
+	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send type access: %v", err)
+	}
+}
+
+// handleTypeAccessToggle adds the admin to the type's allow-list or removes
+// them from it. adminID 0 clears the list.
+func (h *ForumAdminHandler) handleTypeAccessToggle(ctx context.Context, userID, chatID int64, messageID int, typeID, adminID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	var allowed []int64
+	if adminID != 0 {
+		allowed = postType.AllowedAdminIDs()
+		if i := slices.Index(allowed, adminID); i >= 0 {
+			allowed = slices.Delete(allowed, i, i+1)
+		} else {
+			allowed = append(allowed, adminID)
+		}
+	}
+	postType.SetAllowedAdminIDs(allowed)
+	if err := h.postTypeRepo.Update(postType); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+		return
+	}
+	log.Printf("[FORUM_ADMIN] Access to type %d set to %q by user %d", typeID, postType.AllowedAdmins, userID)
+
+	h.handleTypeAccess(ctx, chatID, messageID, typeID)
+}
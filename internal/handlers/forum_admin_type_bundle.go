@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+func parseTypeSelection(s string) []int64 {
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.ParseInt(part, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func formatTypeSelection(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (h *ForumAdminHandler) handleExportTypesStart(ctx context.Context, userID, chatID int64, messageID int) {
+	state := &models.AdminState{
+		UserID:       userID,
+		CurrentState: fsm.StateExportTypes,
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	h.showExportTypes(ctx, chatID, messageID, state)
+}
+
+func (h *ForumAdminHandler) showExportTypes(ctx context.Context, chatID int64, messageID int, state *models.AdminState) {
+	allTypes, err := h.postTypeRepo.GetAll()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post types: %v", err)
+		return
+	}
+
+	selected := parseTypeSelection(state.TypeSelection)
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, pt := range allTypes {
+		mark := "⬜ "
+		if slices.Contains(selected, pt.ID) {
+			mark = "✅ "
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: mark + postTypeLabel(pt), CallbackData: fmt.Sprintf("types_export_toggle:%d", pt.ID)},
+		})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "☑️ Выбрать все", CallbackData: "types_export_all"}})
+	if len(selected) > 0 {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: fmt.Sprintf("📤 Экспортировать (%d)", len(selected)), CallbackData: "types_export_send"},
+		})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "settings_manage_types"}})
+
+	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        "📤 Экспорт типов\n\nОтметьте типы для экспорта. Бот пришлет ZIP-архив с настройками и изображениями, который можно импортировать в другом боте.",
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send export selection: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) exportState(userID int64) *models.AdminState {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateExportTypes {
+		log.Printf("[FORUM_ADMIN] Invalid state for type export: %v", err)
+		return nil
+	}
+	return state
+}
+
+// handleExportTypesToggle adds the type to the export selection or removes
+// it. typeID 0 selects every type.
+func (h *ForumAdminHandler) handleExportTypesToggle(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	state := h.exportState(userID)
+	if state == nil {
+		return
+	}
+
+	selected := parseTypeSelection(state.TypeSelection)
+	if typeID == 0 {
+		allTypes, err := h.postTypeRepo.GetAll()
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to get post types: %v", err)
+			return
+		}
+		selected = selected[:0]
+		for _, pt := range allTypes {
+			selected = append(selected, pt.ID)
+		}
+	} else if i := slices.Index(selected, typeID); i >= 0 {
+		selected = slices.Delete(selected, i, i+1)
+	} else {
+		selected = append(selected, typeID)
+	}
+
+	state.TypeSelection = formatTypeSelection(selected)
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	h.showExportTypes(ctx, chatID, messageID, state)
+}
+
+func (h *ForumAdminHandler) handleExportTypesSend(ctx context.Context, userID, chatID int64, messageID int) {
+	state := h.exportState(userID)
+	if state == nil {
+		return
+	}
+	typeIDs := parseTypeSelection(state.TypeSelection)
+	if len(typeIDs) == 0 {
+		return
+	}
+
+	h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: messageID,
+		Text:      "⏳ Готовлю архив...",
+	})
+
+	bundle, err := h.typeBundleManager.Export(ctx, typeIDs)
+	var archive bytes.Buffer
+	if err == nil {
+		err = bundle.WriteZip(&archive)
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to export types: %v", err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      fmt.Sprintf("❌ Не удалось экспортировать типы: %v", err),
+		})
+		return
+	}
+
+	h.adminStateRepo.Clear(userID)
+	_, err = h.bot.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID: chatID,
+		Document: &tgmodels.InputFileUpload{
+			Filename: fmt.Sprintf("post_types_%s.zip", time.Now().Format("2006-01-02_15-04-05")),
+			Data:     &archive,
+		},
+		Caption: fmt.Sprintf("📦 Типов в архиве: %d. Чтобы перенести их, откройте в другом боте «Типы постов → 📥 Импорт» и отправьте этот файл.", len(bundle.Types)),
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send type bundle: %v", err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      "❌ Не удалось отправить архив",
+		})
+		return
+	}
+	h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+
+	log.Printf("[FORUM_ADMIN] %d types exported by user %d", len(bundle.Types), userID)
+}
+
+func (h *ForumAdminHandler) handleImportTypesStart(ctx context.Context, userID, chatID int64, messageID int) {
+	state := &models.AdminState{
+		UserID:       userID,
+		CurrentState: fsm.StateImportTypes,
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, "📥 Импорт типов\n\nОтправьте ZIP-архив, экспортированный в «Типы постов → 📤 Экспорт». Изображения будут загружены заново.")
+}
+
+// loadTypeBundle downloads and parses an uploaded bundle. The returned text
+// explains the failure to the admin.
+func (h *ForumAdminHandler) loadTypeBundle(ctx context.Context, fileID string) (*services.TypeBundle, string) {
+	data, err := h.typeBundleManager.DownloadFile(ctx, fileID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to download type bundle: %v", err)
+		return nil, "❌ Не удалось скачать файл"
+	}
+	bundle, err := services.ReadTypeBundle(data)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to read type bundle: %v", err)
+		if errors.Is(err, services.ErrInvalidTypeBundle) {
+			return nil, fmt.Sprintf("❌ Файл не является архивом типов: %v", err)
+		}
+		return nil, "❌ Не удалось прочитать архив"
+	}
+	return bundle, ""
+}
+
+func (h *ForumAdminHandler) handleImportTypesInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	if msg.Document == nil || !strings.HasSuffix(strings.ToLower(msg.Document.FileName), ".zip") {
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Отправьте ZIP-архив типов файлом")
+		return
+	}
+	if msg.Document.FileSize > services.MaxTypeBundleSize {
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Архив больше 20 МБ, бот не может его скачать")
+		return
+	}
+
+	bundle, errText := h.loadTypeBundle(ctx, msg.Document.FileID)
+	if bundle == nil {
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, errText)
+		return
+	}
+	conflicts, err := h.typeBundleManager.Conflicts(bundle)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to check type names: %v", err)
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Ошибка получения типов постов")
+		return
+	}
+	if len(conflicts) == 0 {
+		h.runTypeImport(ctx, msg.From.ID, msg.Chat.ID, bundle, services.ImportRename)
+		return
+	}
+
+	state.CurrentState = fsm.StateImportTypesConflict
+	state.ImportFileID = msg.Document.FileID
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: msg.Chat.ID,
+		Text: fmt.Sprintf("В архиве типов: %d. Уже существуют: %s\n\nЧто сделать с этими типами?",
+			len(bundle.Types), strings.Join(conflicts, ", ")),
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "✏️ Импортировать под новым именем", CallbackData: "types_import:" + services.ImportRename}},
+				{{Text: "♻️ Заменить существующие", CallbackData: "types_import:" + services.ImportReplace}},
+				{{Text: "⏭ Пропустить", CallbackData: "types_import:" + services.ImportSkip}},
+				{{Text: "❌ Отмена", CallbackData: "cancel"}},
+			},
+		},
+	})
+}
+
+func (h *ForumAdminHandler) handleImportTypesResolve(ctx context.Context, userID, chatID int64, messageID int, strategy string) {
+	state, err := h.adminStateRepo.Get(userID)
+	if err != nil || state == nil || state.CurrentState != fsm.StateImportTypesConflict || state.ImportFileID == "" {
+		log.Printf("[FORUM_ADMIN] Invalid state for type import: %v", err)
+		return
+	}
+
+	bundle, errText := h.loadTypeBundle(ctx, state.ImportFileID)
+	if bundle == nil {
+		h.adminStateRepo.Clear(userID)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: errText})
+		return
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.runTypeImport(ctx, userID, chatID, bundle, strategy)
+}
+
+func (h *ForumAdminHandler) runTypeImport(ctx context.Context, userID, chatID int64, bundle *services.TypeBundle, strategy string) {
+	h.adminStateRepo.Clear(userID)
+
+	result, err := h.typeBundleManager.Import(ctx, bundle, chatID, userID, strategy)
+	var sb strings.Builder
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to import types: %v", err)
+		sb.WriteString(fmt.Sprintf("❌ Импорт прерван, типы не изменены: %v", err))
+	} else {
+		sb.WriteString("✅ Импорт завершен\n\n")
+	}
+	if result != nil {
+		sb.WriteString(fmt.Sprintf("Создано: %d\nЗаменено: %d\nПропущено: %d", result.Created, result.Replaced, result.Skipped))
+		if len(result.Renamed) > 0 {
+			sb.WriteString("\nПереименованы: " + strings.Join(result.Renamed, ", "))
+		}
+		log.Printf("[FORUM_ADMIN] Types imported by user %d: %d created, %d replaced, %d skipped",
+			userID, result.Created, result.Replaced, result.Skipped)
+	}
+
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: sb.String()})
+	h.showAdminMenu(ctx, chatID, 0)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+func postTypeLabel(pt *models.PostType) string {
+	if pt.Emoji != "" {
+		return pt.Emoji + " " + pt.Name
+	}
+	return pt.Name
+}
+
+// handleDeleteTypeStart shows how many posts use the type and what can be
+// done with them before the type is deleted.
+func (h *ForumAdminHandler) handleDeleteTypeStart(ctx context.Context, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	count, err := h.postTypeRepo.CountPosts(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to count posts of type %d: %v", typeID, err)
+		return
+	}
+
+	var text string
+	var rows [][]tgmodels.InlineKeyboardButton
+	if count == 0 {
+		text = fmt.Sprintf("Удалить тип «%s»? Постов этого типа нет.", postType.Name)
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: "🗑 Удалить", CallbackData: fmt.Sprintf("delete_type_archive:%d", typeID)},
+		})
+	} else {
+		text = fmt.Sprintf("Удалить тип «%s»?\n\nПостов этого типа: %d. Их можно перенести в другой тип или оставить в архивном типе «%s %s». Посты в форуме не изменятся.",
+			postType.Name, count, models.ArchiveTypeEmoji, models.ArchiveTypeName)
+		rows = append(rows,
+			[]tgmodels.InlineKeyboardButton{{Text: "🔀 Перенести в другой тип", CallbackData: fmt.Sprintf("delete_type_move:%d", typeID)}},
+			[]tgmodels.InlineKeyboardButton{{Text: "🗄 Оставить в архиве", CallbackData: fmt.Sprintf("delete_type_archive:%d", typeID)}},
+		)
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "❌ Отмена", CallbackData: fmt.Sprintf("manage_type:%d", typeID)}})
+
+	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send type deletion options: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleDeleteTypeChooseTarget(ctx context.Context, chatID int64, messageID int, typeID int64) {
+	postTypes, err := h.postTypeRepo.GetAll()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post types: %v", err)
+		return
+	}
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, pt := range postTypes {
+		if pt.ID == typeID {
+			continue
+		}
+		label := postTypeLabel(pt)
+		if !pt.IsActive {
+			label += " (отключен)"
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: label, CallbackData: fmt.Sprintf("delete_type_to:%d:%d", typeID, pt.ID)},
+		})
+	}
+	text := "Выберите тип, в который перенести посты:"
+	if len(rows) == 0 {
+		text = "Других типов нет. Посты можно оставить в архиве."
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: fmt.Sprintf("delete_type:%d", typeID)}})
+
+	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send target type choice: %v", err)
+	}
+}
+
+// handleDeleteType deletes the type, moving its posts to targetID or, when
+// targetID is 0, to the archive type.
+func (h *ForumAdminHandler) handleDeleteType(ctx context.Context, userID, chatID int64, messageID int, typeID, targetID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil || postType.IsArchive {
+		log.Printf("[FORUM_ADMIN] Post type %d cannot be deleted: %v", typeID, err)
+		return
+	}
+	target := fmt.Sprintf("%s %s", models.ArchiveTypeEmoji, models.ArchiveTypeName)
+	if targetID != 0 {
+		targetType, err := h.postTypeRepo.GetByID(targetID)
+		if err != nil || targetType.IsArchive {
+			log.Printf("[FORUM_ADMIN] Post type %d cannot receive posts: %v", targetID, err)
+			return
+		}
+		target = postTypeLabel(targetType)
+	}
+
+	moved, err := h.postTypeRepo.DeleteMovingPosts(typeID, targetID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to delete post type %d: %v", typeID, err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      fmt.Sprintf("❌ Ошибка удаления типа: %v", err),
+		})
+		return
+	}
+
+	text := fmt.Sprintf("✅ Тип «%s» удален", postType.Name)
+	if moved > 0 {
+		text += fmt.Sprintf(", постов перенесено в «%s»: %d", target, moved)
+	}
+	h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: messageID,
+		Text:      text,
+	})
+	h.showAdminMenu(ctx, chatID, 0)
+
+	log.Printf("[FORUM_ADMIN] Type %d deleted by user %d, %d posts moved to type %d", typeID, userID, moved, targetID)
+}
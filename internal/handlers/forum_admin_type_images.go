@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// imageStrategies is the order in which the strategy button cycles.
+var imageStrategies = []string{
+	models.ImageStrategyFixed,
+	models.ImageStrategyRandom,
+	models.ImageStrategyRoundRobin,
+	models.ImageStrategyWeekday,
+}
+
+func imageStrategyLabel(strategy string) string {
+	switch strategy {
+	case models.ImageStrategyRandom:
+		return "🎲 случайное"
+	case models.ImageStrategyRoundRobin:
+		return "🔁 по очереди"
+	case models.ImageStrategyWeekday:
+		return "📅 по дню недели"
+	default:
+		return "📌 всегда первое"
+	}
+}
+
+// typeImages returns the images a new post of the type can get.
+func (h *ForumAdminHandler) typeImages(postType *models.PostType) []string {
+	pool, err := h.postTypeRepo.GetImages(postType.ID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get images of type %d: %v", postType.ID, err)
+	}
+	return services.TypeImages(postType, pool)
+}
+
+func (h *ForumAdminHandler) pickDraftImage(postType *models.PostType) string {
+	return services.PickTypeImage(postType, h.typeImages(postType), time.Now())
+}
+
+// canSwapDraftImage reports whether the preview should offer another image
+// from the type's pool.
+func (h *ForumAdminHandler) canSwapDraftImage(state *models.AdminState) bool {
+	if state.DraftPhotoID == "" || state.DraftLayout == models.PostLayoutPreview {
+		return false
+	}
+	postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
+	if err != nil {
+		return false
+	}
+	return len(h.typeImages(postType)) > 1
+}
+
+func (h *ForumAdminHandler) handlePostOtherImage(ctx context.Context, userID, chatID int64, messageID int) {
+	state := h.draftState(userID)
+	if state == nil {
+		return
+	}
+	postType, err := h.postTypeRepo.GetByID(state.SelectedTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+
+	state.DraftPhotoID = services.OtherTypeImage(h.typeImages(postType), state.DraftPhotoID)
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.showDraft(ctx, chatID, state)
+}
+
+func (h *ForumAdminHandler) handleTypeImages(ctx context.Context, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	pool, err := h.postTypeRepo.GetImages(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get images of type %d: %v", typeID, err)
+		return
+	}
+
+	mainImage := "нет"
+	if postType.PhotoID != "" {
+		mainImage = "есть"
+	}
+	text := fmt.Sprintf("🖼 Изображения типа «%s»\n\nОсновное изображение: %s (меняется кнопкой «🖼 Заменить изображение»)\nДополнительных изображений: %d\nВыбор для нового поста: %s\n\nСписок изображений начинается с основного. При предпросмотре поста изображение можно сменить кнопкой «🎲 Другое изображение».",
+		postType.Name, mainImage, len(pool), imageStrategyLabel(postType.ImageStrategy))
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for i, image := range pool {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: fmt.Sprintf("👁 Изображение %d", i+1), CallbackData: fmt.Sprintf("type_image_show:%d", image.ID)},
+			{Text: "🗑", CallbackData: fmt.Sprintf("type_image_delete:%d", image.ID)},
+		})
+	}
+	rows = append(rows,
+		[]tgmodels.InlineKeyboardButton{{Text: "➕ Добавить изображения", CallbackData: fmt.Sprintf("type_image_add:%d", typeID)}},
+		[]tgmodels.InlineKeyboardButton{{Text: "Выбор: " + imageStrategyLabel(postType.ImageStrategy), CallbackData: fmt.Sprintf("type_image_strategy:%d", typeID)}},
+		[]tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: fmt.Sprintf("manage_type:%d", typeID)}},
+	)
+	h.editOrSendMenu(ctx, chatID, messageID, text, &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (h *ForumAdminHandler) handleTypeImageStrategy(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	next := imageStrategies[0]
+	for i, strategy := range imageStrategies {
+		if strategy == postType.ImageStrategy {
+			next = imageStrategies[(i+1)%len(imageStrategies)]
+			break
+		}
+	}
+	postType.ImageStrategy = next
+	if err := h.postTypeRepo.Update(postType); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+		return
+	}
+	log.Printf("[FORUM_ADMIN] Image strategy of type %d set to %q by user %d", typeID, next, userID)
+	h.handleTypeImages(ctx, chatID, messageID, typeID)
+}
+
+func (h *ForumAdminHandler) handleTypeImageAddStart(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	state := &models.AdminState{
+		UserID:        userID,
+		CurrentState:  fsm.StateAddTypeImage,
+		EditingTypeID: typeID,
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, "Отправьте одно или несколько изображений (можно альбомом). Каждое будет добавлено в пул типа.")
+}
+
+func (h *ForumAdminHandler) handleTypeImageAddInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	if len(msg.Photo) == 0 {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Пожалуйста, отправьте изображение",
+		})
+		return
+	}
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	if _, err := h.postTypeRepo.AddImage(state.EditingTypeID, msg.Photo[len(msg.Photo)-1].FileID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to add image to type %d: %v", state.EditingTypeID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения изображения"})
+		return
+	}
+	pool, _ := h.postTypeRepo.GetImages(state.EditingTypeID)
+
+	sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: msg.Chat.ID,
+		Text:   fmt.Sprintf("✅ Изображение добавлено, в пуле: %d. Отправьте еще или нажмите «Готово».", len(pool)),
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "✅ Готово", CallbackData: fmt.Sprintf("type_images:%d", state.EditingTypeID)}},
+			},
+		},
+	})
+	if err == nil {
+		state.LastBotMessageID = sentMsg.ID
+		h.adminStateRepo.Save(state)
+	}
+
+	log.Printf("[FORUM_ADMIN] Image added to type %d by user %d", state.EditingTypeID, msg.From.ID)
+}
+
+func (h *ForumAdminHandler) handleTypeImageShow(ctx context.Context, chatID int64, imageID int64) {
+	image, err := h.postTypeRepo.GetImage(imageID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get type image %d: %v", imageID, err)
+		return
+	}
+	_, err = h.bot.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID: chatID,
+		Photo:  &tgmodels.InputFileString{Data: image.PhotoID},
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "🗑 Удалить из пула", CallbackData: fmt.Sprintf("type_image_delete:%d", image.ID)}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send type image: %v", err)
+	}
+}
+
+// handleTypeImageDelete removes the image from the pool and shows the pool
+// again in place of the message the button was on.
+func (h *ForumAdminHandler) handleTypeImageDelete(ctx context.Context, userID, chatID int64, messageID int, imageID int64) {
+	image, err := h.postTypeRepo.GetImage(imageID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get type image %d: %v", imageID, err)
+		return
+	}
+	if err := h.postTypeRepo.DeleteImage(imageID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to delete type image %d: %v", imageID, err)
+		return
+	}
+	log.Printf("[FORUM_ADMIN] Image %d removed from type %d by user %d", imageID, image.PostTypeID, userID)
+
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.handleTypeImages(ctx, chatID, 0, image.PostTypeID)
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+const (
+	// recentTypesLimit is how many recently used types the picker shows.
+	recentTypesLimit = 3
+	// shortTypeNameRunes is the longest label that is paired with another
+	// one in the two-column layout.
+	shortTypeNameRunes = 16
+)
+
+// buttonRows lays buttons out one per row or, with twoColumns, pairs
+// consecutive buttons whose labels are short.
+func buttonRows(buttons []tgmodels.InlineKeyboardButton, twoColumns bool) [][]tgmodels.InlineKeyboardButton {
+	var rows [][]tgmodels.InlineKeyboardButton
+	for i := 0; i < len(buttons); i++ {
+		b := buttons[i]
+		if twoColumns && i+1 < len(buttons) &&
+			utf8.RuneCountInString(b.Text) <= shortTypeNameRunes &&
+			utf8.RuneCountInString(buttons[i+1].Text) <= shortTypeNameRunes {
+			rows = append(rows, []tgmodels.InlineKeyboardButton{b, buttons[i+1]})
+			i++
+			continue
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{b})
+	}
+	return rows
+}
+
+func typeButtons(types []*models.PostType, labelPrefix string) []tgmodels.InlineKeyboardButton {
+	buttons := make([]tgmodels.InlineKeyboardButton, 0, len(types))
+	for _, pt := range types {
+		buttons = append(buttons, tgmodels.InlineKeyboardButton{
+			Text:         labelPrefix + postTypeLabel(pt),
+			CallbackData: fmt.Sprintf("select_type:%d", pt.ID),
+		})
+	}
+	return buttons
+}
+
+// typeCategories returns the categories of types in the order their first
+// type appears.
+func typeCategories(types []*models.PostType) []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, pt := range types {
+		if pt.Category != "" && !seen[pt.Category] {
+			seen[pt.Category] = true
+			categories = append(categories, pt.Category)
+		}
+	}
+	return categories
+}
+
+// typePickerRows builds the top level of the type picker: recently used
+// types, types without a category and a button per category. Categories are
+// addressed by their position in typeCategories.
+func typePickerRows(types, recent []*models.PostType, twoColumns bool) [][]tgmodels.InlineKeyboardButton {
+	rows := buttonRows(typeButtons(recent, "🕘 "), twoColumns)
+
+	var uncategorized []*models.PostType
+	count := make(map[string]int)
+	for _, pt := range types {
+		if pt.Category == "" {
+			uncategorized = append(uncategorized, pt)
+		}
+		count[pt.Category]++
+	}
+	rows = append(rows, buttonRows(typeButtons(uncategorized, ""), twoColumns)...)
+
+	var categoryButtons []tgmodels.InlineKeyboardButton
+	for i, category := range typeCategories(types) {
+		categoryButtons = append(categoryButtons, tgmodels.InlineKeyboardButton{
+			Text:         fmt.Sprintf("📁 %s (%d)", category, count[category]),
+			CallbackData: fmt.Sprintf("new_post_category:%d", i),
+		})
+	}
+	return append(rows, buttonRows(categoryButtons, twoColumns)...)
+}
+
+func (h *ForumAdminHandler) typePickerTwoColumns() bool {
+	config, err := h.adminConfigRepo.Get()
+	return err == nil && config.TypeTwoColumns
+}
+
+func (h *ForumAdminHandler) editOrSendMenu(ctx context.Context, chatID int64, messageID int, text string, keyboard *tgmodels.InlineKeyboardMarkup) {
+	var err error
+	if messageID > 0 {
+		_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			ReplyMarkup: keyboard,
+		})
+	} else {
+		_, err = h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        text,
+			ReplyMarkup: keyboard,
+		})
+	}
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send menu: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleNewPostCategory(ctx context.Context, userID, chatID int64, messageID int, index int) {
+	activeTypes, err := h.postTypeRepo.GetActive()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get active types: %v", err)
+		return
+	}
+	activeTypes = filterAllowedTypes(activeTypes, userID)
+	categories := typeCategories(activeTypes)
+	if index < 0 || index >= len(categories) {
+		// The categories changed since the picker was shown.
+		h.handleNewCommand(ctx, userID, chatID, messageID)
+		return
+	}
+
+	var types []*models.PostType
+	for _, pt := range activeTypes {
+		if pt.Category == categories[index] {
+			types = append(types, pt)
+		}
+	}
+	rows := buttonRows(typeButtons(types, ""), h.typePickerTwoColumns())
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: "admin_new_post"}})
+
+	h.editOrSendMenu(ctx, chatID, messageID, fmt.Sprintf("📁 %s\n\nВыберите тип поста:", categories[index]),
+		&tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (h *ForumAdminHandler) handleTypeMove(ctx context.Context, userID, chatID int64, messageID int, typeID int64, delta int) {
+	if err := h.postTypeRepo.Move(typeID, delta); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to move type %d: %v", typeID, err)
+		return
+	}
+	log.Printf("[FORUM_ADMIN] Type %d moved by %d by user %d", typeID, delta, userID)
+	h.handleTypeManagementOptions(ctx, userID, chatID, messageID, typeID)
+}
+
+func (h *ForumAdminHandler) handleTypeColumnsToggle(ctx context.Context, userID, chatID int64, messageID int) {
+	config, err := h.adminConfigRepo.Get()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get config: %v", err)
+		return
+	}
+	config.TypeTwoColumns = !config.TypeTwoColumns
+	if err := h.adminConfigRepo.Save(config); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save config: %v", err)
+		return
+	}
+	log.Printf("[FORUM_ADMIN] Two-column type picker set to %v by user %d", config.TypeTwoColumns, userID)
+	h.handleManageTypesMenu(ctx, chatID, messageID)
+}
+
+func (h *ForumAdminHandler) handleEditTypeCategoryStart(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	allTypes, err := h.postTypeRepo.GetAll()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post types: %v", err)
+		return
+	}
+
+	state := &models.AdminState{
+		UserID:        userID,
+		CurrentState:  fsm.StateEditTypeCategory,
+		EditingTypeID: typeID,
+	}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+
+	current := "нет"
+	if postType.Category != "" {
+		current = postType.Category
+	}
+	text := fmt.Sprintf("Категория типа «%s»: %s\n\nТипы одной категории открываются отдельным подменю при выборе типа поста. Отправьте название категории или «-», чтобы убрать тип из категории.", postType.Name, current)
+	if categories := typeCategories(allTypes); len(categories) > 0 {
+		text += "\n\nСуществующие категории: " + strings.Join(categories, ", ")
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, text)
+}
+
+func (h *ForumAdminHandler) handleEditTypeCategoryInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+
+	category := strings.TrimSpace(msg.Text)
+	switch {
+	case category == "":
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Отправьте название категории или «-»")
+		return
+	case category == "-":
+		category = ""
+	case utf8.RuneCountInString(category) > 32:
+		h.sendCancelablePrompt(ctx, msg.Chat.ID, state, "❌ Название категории должно быть не длиннее 32 символов")
+		return
+	}
+
+	postType, err := h.postTypeRepo.GetByID(state.EditingTypeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка получения типа поста"})
+		return
+	}
+	postType.Category = category
+	if err := h.postTypeRepo.Update(postType); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to update post type: %v", err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Ошибка сохранения изменений"})
+		return
+	}
+
+	h.adminStateRepo.Clear(msg.From.ID)
+	text := fmt.Sprintf("✅ Тип «%s» перенесен в категорию «%s»", postType.Name, category)
+	if category == "" {
+		text = fmt.Sprintf("✅ Тип «%s» убран из категории", postType.Name)
+	}
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: text})
+	h.showAdminMenu(ctx, msg.Chat.ID, 0)
+
+	log.Printf("[FORUM_ADMIN] Category of type %d set to %q by user %d", postType.ID, category, msg.From.ID)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+func TestButtonRows(t *testing.T) {
+	buttons := []tgmodels.InlineKeyboardButton{
+		{Text: "Новости"},
+		{Text: "Анонсы"},
+		{Text: "Очень длинное название типа"},
+		{Text: "События"},
+	}
+	if rows := buttonRows(buttons, false); len(rows) != 4 {
+		t.Errorf("Expected one button per row, got %d rows", len(rows))
+	}
+	rows := buttonRows(buttons, true)
+	if len(rows) != 3 || len(rows[0]) != 2 || len(rows[1]) != 1 || len(rows[2]) != 1 {
+		t.Errorf("Expected short labels to be paired, got %v", rows)
+	}
+}
+
+func TestTypePickerRows(t *testing.T) {
+	types := []*models.PostType{
+		{ID: 1, Name: "Новости"},
+		{ID: 2, Name: "Турниры", Category: "Спорт"},
+		{ID: 3, Name: "Матчи", Category: "Спорт"},
+		{ID: 4, Name: "Анонсы"},
+	}
+	recent := []*models.PostType{types[2]}
+
+	rows := typePickerRows(types, recent, false)
+	var got []string
+	for _, row := range rows {
+		got = append(got, row[0].Text+" "+row[0].CallbackData)
+	}
+	want := []string{
+		"🕘 Матчи select_type:3",
+		"Новости select_type:1",
+		"Анонсы select_type:4",
+		"📁 Спорт (2) new_post_category:0",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("typePickerRows() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFilterAllowedTypes(t *testing.T) {
+	types := []*models.PostType{
+		{ID: 1, Name: "Новости"},
+		{ID: 2, Name: "Официальные анонсы", AllowedAdmins: "10,20"},
+	}
+	if got := filterAllowedTypes(types, 10); len(got) != 2 {
+		t.Errorf("Expected a listed admin to see both types, got %d", len(got))
+	}
+	if got := filterAllowedTypes(types, 30); len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("Expected other admins to see only the unrestricted type, got %v", got)
+	}
+}
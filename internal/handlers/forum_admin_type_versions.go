@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/ad/go-telegram-admin/internal/services"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// rerenderDelay is the pause between edited posts, which keeps a bulk edit
+// under Telegram's limit of about 20 messages a minute in a group.
+var rerenderDelay = 3 * time.Second
+
+const (
+	rerenderProgressEvery  = 5
+	rerenderFailuresShown  = 20
+	typeVersionsShown      = 10
+	rerenderScopeAll       = "all"
+	rerenderScopeOld       = "old"
+	rerenderScopeLast10    = "last10"
+	rerenderScopeLast50    = "last50"
+	rerenderScopeRangeMark = "r"
+)
+
+func rerenderScopeLabel(scope string, byNumber bool) string {
+	switch scope {
+	case rerenderScopeOld:
+		return "посты старых версий"
+	case rerenderScopeLast10:
+		return "последние 10 постов"
+	case rerenderScopeLast50:
+		return "последние 50 постов"
+	}
+	if from, to, ok := parseRerenderRange(strings.TrimPrefix(scope, rerenderScopeRangeMark)); ok && strings.HasPrefix(scope, rerenderScopeRangeMark) {
+		if byNumber {
+			return fmt.Sprintf("посты с номерами %d–%d", from, to)
+		}
+		return fmt.Sprintf("посты #%d–#%d", from, to)
+	}
+	return "все посты"
+}
+
+// parseRerenderRange reads a range of posts such as "120-154" or a single
+// post "154".
+func parseRerenderRange(s string) (int64, int64, bool) {
+	s = strings.ReplaceAll(s, "–", "-")
+	fromText, toText, isRange := strings.Cut(s, "-")
+	from, ok := parsePostNumber(fromText)
+	if !ok {
+		return 0, 0, false
+	}
+	if !isRange {
+		return from, from, true
+	}
+	to, ok := parsePostNumber(toText)
+	if !ok {
+		return 0, 0, false
+	}
+	return min(from, to), max(from, to), true
+}
+
+// selectRerenderPosts picks the posts of the scope from posts, which come
+// newest first. A range selects posts by number when byNumber is set and by
+// ID otherwise.
+func selectRerenderPosts(posts []*models.PublishedPost, scope string, current int, byNumber bool) []*models.PublishedPost {
+	switch scope {
+	case rerenderScopeAll:
+		return posts
+	case rerenderScopeLast10:
+		return posts[:min(len(posts), 10)]
+	case rerenderScopeLast50:
+		return posts[:min(len(posts), 50)]
+	}
+
+	var selected []*models.PublishedPost
+	from, to, isRange := parseRerenderRange(strings.TrimPrefix(scope, rerenderScopeRangeMark))
+	for _, post := range posts {
+		key := post.ID
+		if byNumber {
+			key = post.Number
+		}
+		switch {
+		case scope == rerenderScopeOld && post.TypeVersion < int64(current):
+		case scope != rerenderScopeOld && isRange && key >= from && key <= to:
+		default:
+			continue
+		}
+		selected = append(selected, post)
+	}
+	return selected
+}
+
+// recordTypeVersion saves a new version of the type when its image,
+// template, header or footer differs from prev.
+func (h *ForumAdminHandler) recordTypeVersion(prev *models.PostType, userID int64) {
+	postType, err := h.postTypeRepo.GetByID(prev.ID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	prevVersion, next := models.NewPostTypeVersion(prev), models.NewPostTypeVersion(postType)
+	if len(next.Changes(prevVersion)) == 0 {
+		return
+	}
+	next.ChangedBy = userID
+	version, err := h.typeVersionRepo.Record(prevVersion, next)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to record version of type %d: %v", prev.ID, err)
+		return
+	}
+	log.Printf("[FORUM_ADMIN] Type %d version %d recorded by user %d", prev.ID, version, userID)
+}
+
+// sendTypeUpdated reports a change of the type's look and offers to apply it
+// to the posts already published.
+func (h *ForumAdminHandler) sendTypeUpdated(ctx context.Context, chatID, typeID int64, text string) {
+	params := &bot.SendMessageParams{ChatID: chatID, Text: text}
+	if posts, err := h.publishedPostRepo.GetByType(typeID); err == nil && len(posts) > 0 {
+		params.ReplyMarkup = &tgmodels.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+				{{Text: "♻️ Применить к опубликованным постам", CallbackData: fmt.Sprintf("type_rerender:%d:%s", typeID, rerenderScopeAll)}},
+			},
+		}
+	}
+	h.bot.SendMessage(ctx, params)
+}
+
+func (h *ForumAdminHandler) handleTypeVersions(ctx context.Context, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	versions, err := h.typeVersionRepo.GetByType(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get versions of type %d: %v", typeID, err)
+		return
+	}
+	posts, err := h.publishedPostRepo.GetByType(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get posts of type %d: %v", typeID, err)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🕘 История версий типа «%s»\n\n", postType.Name)
+	if len(versions) == 0 {
+		b.WriteString("Изображение, шаблон, шапка и подвал типа еще не менялись.")
+	}
+	postsByVersion := make(map[int64]int)
+	for _, post := range posts {
+		postsByVersion[post.TypeVersion]++
+	}
+	for i, v := range versions[:min(len(versions), typeVersionsShown)] {
+		fmt.Fprintf(&b, "v%d — %s", v.Version, v.CreatedAt.Format("02.01.2006 15:04"))
+		if i+1 < len(versions) {
+			b.WriteString(", " + strings.Join(v.Changes(versions[i+1]), ", "))
+		} else {
+			b.WriteString(", до первого изменения")
+		}
+		if v.ChangedBy != 0 {
+			b.WriteString(" (" + h.userManager.DisplayName(v.ChangedBy) + ")")
+		}
+		if n := postsByVersion[int64(v.Version)]; n > 0 {
+			fmt.Fprintf(&b, " — постов: %d", n)
+		}
+		b.WriteString("\n")
+	}
+	if n := postsByVersion[0]; n > 0 && len(versions) > 0 {
+		fmt.Fprintf(&b, "\nПостов, опубликованных до начала истории: %d", n)
+	}
+
+	rows := [][]tgmodels.InlineKeyboardButton{}
+	if len(posts) > 0 {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "♻️ Применить к опубликованным постам", CallbackData: fmt.Sprintf("type_rerender:%d:%s", typeID, rerenderScopeAll)}})
+	}
+	rows = append(rows, []tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: fmt.Sprintf("manage_type:%d", typeID)}})
+	h.editOrSendMenu(ctx, chatID, messageID, b.String(), &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+type rerenderItem struct {
+	post *models.PublishedPost
+	plan services.PostRerender
+}
+
+// planRerender works out what changes in each post of the scope and returns
+// the type's current version.
+func (h *ForumAdminHandler) planRerender(postType *models.PostType, scope string) ([]rerenderItem, int, error) {
+	versions, err := h.typeVersionRepo.GetByType(postType.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get versions: %w", err)
+	}
+	current := 0
+	var oldPhotos []string
+	for _, v := range versions {
+		current = max(current, v.Version)
+		if v.PhotoID != "" {
+			oldPhotos = append(oldPhotos, v.PhotoID)
+		}
+	}
+	posts, err := h.publishedPostRepo.GetByType(postType.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get posts: %w", err)
+	}
+
+	signatures := make(map[int64]*models.AdminSignature)
+	var items []rerenderItem
+	for _, post := range selectRerenderPosts(posts, scope, current, postType.HasCounter()) {
+		signature, ok := signatures[post.AuthorID]
+		if !ok {
+			signature = h.adminSignature(post.AuthorID)
+			signatures[post.AuthorID] = signature
+		}
+		items = append(items, rerenderItem{post: post, plan: services.RerenderPost(post, postType, signature, oldPhotos)})
+	}
+	return items, current, nil
+}
+
+// handleRerender shows how many posts of the scope would change, without
+// changing them.
+func (h *ForumAdminHandler) handleRerender(ctx context.Context, chatID int64, messageID int, typeID int64, scope string) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	items, current, err := h.planRerender(postType, scope)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to plan re-render of type %d: %v", typeID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Ошибка получения постов типа"})
+		return
+	}
+
+	var photos, frames, changed int
+	for _, item := range items {
+		if item.plan.PhotoID != "" {
+			photos++
+		}
+		if item.plan.FrameChanged {
+			frames++
+		}
+		if item.plan.Changed() {
+			changed++
+		}
+	}
+
+	versionLabel := ""
+	if current > 0 {
+		versionLabel = fmt.Sprintf(" (v%d)", current)
+	}
+	text := fmt.Sprintf("♻️ Текущий вид типа «%s»%s для опубликованных постов\n\nВыборка: %s — %d\nИзменятся: %d\n• изображение: %d\n• шапка, подвал и подпись: %d\nБез изменений: %d\n\nТекст постов не меняется: шаблон используется только при создании поста. Изображение меняется только у постов с прежним изображением типа, а не из пула; текстовые посты изображение не получают. Посты пока не изменены.",
+		postType.Name, versionLabel, rerenderScopeLabel(scope, postType.HasCounter()), len(items), changed, photos, frames, len(items)-changed)
+
+	scopeButton := func(label, s string) tgmodels.InlineKeyboardButton {
+		if s == scope {
+			label = "• " + label
+		}
+		return tgmodels.InlineKeyboardButton{Text: label, CallbackData: fmt.Sprintf("type_rerender:%d:%s", typeID, s)}
+	}
+	rows := [][]tgmodels.InlineKeyboardButton{
+		{scopeButton("Все", rerenderScopeAll), scopeButton("Старые версии", rerenderScopeOld)},
+		{scopeButton("Последние 10", rerenderScopeLast10), scopeButton("Последние 50", rerenderScopeLast50)},
+		{{Text: "🔢 Диапазон", CallbackData: fmt.Sprintf("type_rerender_range:%d", typeID)}},
+	}
+	if changed > 0 {
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: fmt.Sprintf("▶️ Применить к %d постам", changed), CallbackData: fmt.Sprintf("type_rerender_run:%d:%s", typeID, scope)},
+		})
+	}
+	rows = append(rows,
+		[]tgmodels.InlineKeyboardButton{{Text: "🕘 История версий", CallbackData: fmt.Sprintf("type_versions:%d", typeID)}},
+		[]tgmodels.InlineKeyboardButton{{Text: "← Назад", CallbackData: fmt.Sprintf("manage_type:%d", typeID)}},
+	)
+	h.editOrSendMenu(ctx, chatID, messageID, text, &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (h *ForumAdminHandler) handleRerenderRangeStart(ctx context.Context, userID, chatID int64, messageID int, typeID int64) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	state := &models.AdminState{UserID: userID, CurrentState: fsm.StateRerenderRange, EditingTypeID: typeID}
+	if err := h.adminStateRepo.Save(state); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		return
+	}
+	text := "Отправьте диапазон постов по их ID из списка постов («Пост #123»), например «120-154», или один ID."
+	if postType.HasCounter() {
+		text = "Отправьте диапазон номеров постов, например «120-154», или один номер."
+	}
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	h.sendCancelablePrompt(ctx, chatID, state, text)
+}
+
+func (h *ForumAdminHandler) handleRerenderRangeInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	from, to, ok := parseRerenderRange(msg.Text)
+	if !ok {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: msg.Chat.ID, Text: "❌ Отправьте диапазон вида «120-154»"})
+		return
+	}
+	h.deletePromptMessage(ctx, msg.Chat.ID, state)
+	h.adminStateRepo.Clear(msg.From.ID)
+	h.handleRerender(ctx, msg.Chat.ID, 0, state.EditingTypeID, fmt.Sprintf("%s%d-%d", rerenderScopeRangeMark, from, to))
+}
+
+// rerenderPost edits the post in the chat to match plan and saves it. The
+// frame goes first: it may move text between the caption and follow-ups,
+// and the image is then sent with the final caption.
+func (h *ForumAdminHandler) rerenderPost(ctx context.Context, post *models.PublishedPost, plan services.PostRerender, version int) error {
+	if plan.FrameChanged {
+		edited := *post
+		edited.Frame = plan.Frame
+		err := h.editPublishedText(ctx, &edited, post.Text, parseEntitiesJSON(post.Entities))
+		if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+			return err
+		}
+		post.Frame = plan.Frame
+	}
+
+	if plan.PhotoID != "" {
+		opts := models.ParseDeliveryOptions(post.DeliveryOptions)
+		text, entities := postText(post)
+		caption := postParts(post, text, entities)[0]
+		_, err := h.bot.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
+			ChatID:    post.ChatID,
+			MessageID: int(post.MessageID),
+			Media: &tgmodels.InputMediaPhoto{
+				Media:                 plan.PhotoID,
+				Caption:               caption.Text,
+				CaptionEntities:       caption.Entities,
+				ShowCaptionAboveMedia: opts.CaptionAboveMedia,
+				HasSpoiler:            opts.Spoiler,
+			},
+		})
+		if err != nil {
+			if plan.FrameChanged {
+				// The new frame is already in the chat.
+				h.publishedPostRepo.Update(post)
+			}
+			return err
+		}
+		post.PhotoID = plan.PhotoID
+	}
+
+	post.TypeVersion = int64(version)
+	return h.publishedPostRepo.Update(post)
+}
+
+// sleepContext waits for d and reports false if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// handleRerenderRun applies the type's current look to the posts of the
+// scope one by one, reporting progress in a message that also lets the
+// admin stop the run. Only one run per type is allowed at a time.
+func (h *ForumAdminHandler) handleRerenderRun(ctx context.Context, userID, chatID int64, messageID int, typeID int64, scope string) {
+	postType, err := h.postTypeRepo.GetByID(typeID)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get post type: %v", err)
+		return
+	}
+	if !h.checkTypeAccess(ctx, userID, chatID, typeID) {
+		return
+	}
+
+	h.rerenderMu.Lock()
+	if _, running := h.rerenderJobs[typeID]; running {
+		h.rerenderMu.Unlock()
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "⏳ Посты этого типа уже обновляются"})
+		return
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	h.rerenderJobs[typeID] = cancel
+	h.rerenderMu.Unlock()
+	defer func() {
+		h.rerenderMu.Lock()
+		delete(h.rerenderJobs, typeID)
+		h.rerenderMu.Unlock()
+		cancel()
+	}()
+
+	items, version, err := h.planRerender(postType, scope)
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to plan re-render of type %d: %v", typeID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Ошибка получения постов типа"})
+		return
+	}
+	total := 0
+	for _, item := range items {
+		if item.plan.Changed() {
+			total++
+		}
+	}
+
+	if messageID > 0 {
+		h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID})
+	}
+	title := fmt.Sprintf("Обновление постов типа «%s»", postType.Name)
+	stopKeyboard := &tgmodels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+			{{Text: "⏹ Остановить", CallbackData: fmt.Sprintf("type_rerender_stop:%d", typeID)}},
+		},
+	}
+	progress, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        fmt.Sprintf("♻️ %s: 0/%d", title, total),
+		ReplyMarkup: stopKeyboard,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send re-render progress: %v", err)
+		return
+	}
+	log.Printf("[FORUM_ADMIN] Re-render of %d posts of type %d started by user %d", total, typeID, userID)
+
+	var done, processed int
+	var failures []string
+	for _, item := range items {
+		if jobCtx.Err() != nil {
+			break
+		}
+		if !item.plan.Changed() {
+			if item.post.TypeVersion != int64(version) {
+				item.post.TypeVersion = int64(version)
+				if err := h.publishedPostRepo.Update(item.post); err != nil {
+					log.Printf("[FORUM_ADMIN] Failed to update version of post %d: %v", item.post.ID, err)
+				}
+			}
+			continue
+		}
+		if processed > 0 && !sleepContext(jobCtx, rerenderDelay) {
+			break
+		}
+
+		err := h.rerenderPost(jobCtx, item.post, item.plan, version)
+		var tooMany *bot.TooManyRequestsError
+		if errors.As(err, &tooMany) && sleepContext(jobCtx, time.Duration(tooMany.RetryAfter)*time.Second) {
+			err = h.rerenderPost(jobCtx, item.post, item.plan, version)
+		}
+		processed++
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to re-render post %d: %v", item.post.ID, err)
+			label := fmt.Sprintf("Пост #%d", item.post.ID)
+			if link := h.postPermalink(ctx, item.post); link != "" {
+				label += " " + link
+			}
+			failures = append(failures, fmt.Sprintf("• %s: %v", label, err))
+		} else {
+			done++
+		}
+
+		if processed%rerenderProgressEvery == 0 && processed < total {
+			h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+				ChatID:      chatID,
+				MessageID:   progress.ID,
+				Text:        fmt.Sprintf("♻️ %s: %d/%d, ошибок: %d", title, processed, total, len(failures)),
+				ReplyMarkup: stopKeyboard,
+			})
+		}
+	}
+
+	summary := fmt.Sprintf("✅ %s завершено: обновлено %d из %d", title, done, total)
+	if jobCtx.Err() != nil {
+		summary = fmt.Sprintf("⏹ %s остановлено: обновлено %d из %d", title, done, total)
+	}
+	if len(failures) > 0 {
+		summary += fmt.Sprintf(", ошибок: %d", len(failures))
+	}
+	h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{ChatID: chatID, MessageID: progress.ID, Text: summary})
+
+	if len(failures) > 0 {
+		report := "❌ Не удалось обновить:\n" + strings.Join(failures[:min(len(failures), rerenderFailuresShown)], "\n")
+		if len(failures) > rerenderFailuresShown {
+			report += fmt.Sprintf("\n…и еще %d", len(failures)-rerenderFailuresShown)
+		}
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:             chatID,
+			Text:               report,
+			LinkPreviewOptions: &tgmodels.LinkPreviewOptions{IsDisabled: bot.True()},
+		})
+	}
+
+	log.Printf("[FORUM_ADMIN] Re-render of type %d finished: %d of %d posts updated, %d failed", typeID, done, total, len(failures))
+}
+
+func (h *ForumAdminHandler) handleRerenderStop(ctx context.Context, chatID int64, typeID int64) {
+	h.rerenderMu.Lock()
+	cancel, running := h.rerenderJobs[typeID]
+	h.rerenderMu.Unlock()
+	if !running {
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "Обновление постов уже завершено"})
+		return
+	}
+	cancel()
+}
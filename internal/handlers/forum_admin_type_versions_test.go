@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestSelectRerenderPosts(t *testing.T) {
+	var posts []*models.PublishedPost
+	for i := range 60 {
+		posts = append(posts, &models.PublishedPost{ID: int64(60 - i), Number: int64(160 - i), TypeVersion: int64(i % 3)})
+	}
+
+	for scope, want := range map[string]int{
+		rerenderScopeAll:    60,
+		rerenderScopeLast10: 10,
+		rerenderScopeLast50: 50,
+		rerenderScopeOld:    40,
+		"r10-19":            10,
+		"r19-10":            10,
+		"bogus":             0,
+	} {
+		if got := selectRerenderPosts(posts, scope, 2, false); len(got) != want {
+			t.Errorf("selectRerenderPosts(%q) = %d posts, want %d", scope, len(got), want)
+		}
+	}
+	if got := selectRerenderPosts(posts, "r150-154", 2, true); len(got) != 5 || got[0].Number != 154 {
+		t.Errorf("selectRerenderPosts() by number = %d posts", len(got))
+	}
+
+	if from, to, ok := parseRerenderRange(" #154 – 120 "); !ok || from != 120 || to != 154 {
+		t.Errorf("parseRerenderRange() = %d, %d, %v", from, to, ok)
+	}
+	if _, _, ok := parseRerenderRange("120-"); ok {
+		t.Error("parseRerenderRange() accepted an open range")
+	}
+}
+
+func TestPlanRerender(t *testing.T) {
+	h, testDB := setupForumAdminHandler(t)
+	defer testDB.Close()
+
+	postType := &models.PostType{Name: "Новости", Template: "шаблон", PhotoID: "photo-1", IsActive: true}
+	if err := h.postTypeRepo.Create(postType); err != nil {
+		t.Fatalf("Failed to create post type: %v", err)
+	}
+	for i, photoID := range []string{"photo-1", "pool-1", ""} {
+		post := &models.PublishedPost{PostTypeID: postType.ID, ChatID: -100, MessageID: int64(i + 1), Text: "пост", PhotoID: photoID}
+		if err := h.publishedPostRepo.Create(post); err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+	}
+
+	// An unchanged look records no version.
+	h.recordTypeVersion(postType, 1)
+	if version, _ := h.typeVersionRepo.Current(postType.ID); version != 0 {
+		t.Fatalf("Version after no change = %d", version)
+	}
+
+	prev := *postType
+	if err := h.postTypeManager.UpdateTypePhoto(postType.ID, "photo-2"); err != nil {
+		t.Fatal(err)
+	}
+	h.recordTypeVersion(&prev, 1)
+
+	postType, err := h.postTypeRepo.GetByID(postType.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, current, err := h.planRerender(postType, rerenderScopeOld)
+	if err != nil {
+		t.Fatalf("planRerender() error = %v", err)
+	}
+	if current != 2 || len(items) != 3 {
+		t.Fatalf("planRerender() = %d items, version %d", len(items), current)
+	}
+	for _, item := range items {
+		wantPhoto := ""
+		if item.post.PhotoID == "photo-1" {
+			wantPhoto = "photo-2"
+		}
+		if item.plan.PhotoID != wantPhoto || item.plan.FrameChanged {
+			t.Errorf("Plan of post with %q = %+v", item.post.PhotoID, item.plan)
+		}
+	}
+}
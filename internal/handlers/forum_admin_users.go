@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/fsm"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// formatAdminListHTML renders admins as HTML mentions, one per line, from
+// stored profiles; unknown admins are looked up in the background.
+func (h *ForumAdminHandler) formatAdminListHTML(adminIDs []int64) string {
+	if len(adminIDs) == 0 {
+		return "не настроены\n"
+	}
+
+	h.userManager.RefreshMissing(adminIDs)
+	var sb strings.Builder
+	for _, id := range adminIDs {
+		sb.WriteString(fmt.Sprintf("• %s (<code>%d</code>)\n", h.userManager.MentionHTML(id), id))
+	}
+	return sb.String()
+}
+
+func (h *ForumAdminHandler) showAdminList(ctx context.Context, userID, chatID int64, messageID int) {
+	config, err := h.adminConfigRepo.Get()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get config: %v", err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      "❌ Ошибка получения конфигурации",
+		})
+		return
+	}
+
+	text := fmt.Sprintf("👥 Администраторы (%d):\n\n%s\nНажмите на администратора, чтобы удалить его.",
+		len(config.AdminIDs), h.formatAdminListHTML(config.AdminIDs))
+
+	var rows [][]tgmodels.InlineKeyboardButton
+	for _, id := range config.AdminIDs {
+		label := h.userManager.DisplayName(id)
+		if id == userID {
+			label += " (вы)"
+		}
+		rows = append(rows, []tgmodels.InlineKeyboardButton{
+			{Text: "🗑 " + label, CallbackData: fmt.Sprintf("access_remove_admin:%d", id)},
+		})
+	}
+	rows = append(rows,
+		[]tgmodels.InlineKeyboardButton{
+			{Text: "➕ Добавить", CallbackData: "access_add_admin"},
+			{Text: "✏️ Изменить список", CallbackData: "access_edit_admins"},
+		},
+		[]tgmodels.InlineKeyboardButton{
+			{Text: "🔄 Обновить профили", CallbackData: "access_refresh_admins"},
+		},
+		[]tgmodels.InlineKeyboardButton{
+			{Text: "◀️ Назад", CallbackData: "settings_access"},
+		},
+	)
+
+	_, err = h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ParseMode:   tgmodels.ParseModeHTML,
+		ReplyMarkup: &tgmodels.InlineKeyboardMarkup{InlineKeyboard: rows},
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{
+			IsDisabled: bot.True(),
+		},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send admin list: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) showRemoveAdminConfirm(ctx context.Context, chatID int64, messageID int, adminID int64) {
+	text := fmt.Sprintf("Удалить администратора %s (<code>%d</code>)?", h.userManager.MentionHTML(adminID), adminID)
+
+	keyboard := &tgmodels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+			{
+				{Text: "✅ Да, удалить", CallbackData: fmt.Sprintf("access_remove_admin_confirm:%d", adminID)},
+				{Text: "❌ Отмена", CallbackData: "access_admins"},
+			},
+		},
+	}
+
+	_, err := h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ParseMode:   tgmodels.ParseModeHTML,
+		ReplyMarkup: keyboard,
+		LinkPreviewOptions: &tgmodels.LinkPreviewOptions{
+			IsDisabled: bot.True(),
+		},
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send remove admin confirmation: %v", err)
+	}
+}
+
+func (h *ForumAdminHandler) handleRemoveAdmin(ctx context.Context, userID, chatID int64, messageID int, adminID int64) {
+	backKeyboard := &tgmodels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+			{
+				{Text: "◀️ Назад", CallbackData: "access_admins"},
+			},
+		},
+	}
+
+	if adminID == userID {
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        "❌ Нельзя удалить самого себя, иначе вы потеряете доступ к боту",
+			ReplyMarkup: backKeyboard,
+		})
+		return
+	}
+
+	admins, err := h.settingsManager.GetAdmins()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get admins: %v", err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      "❌ Ошибка получения конфигурации",
+		})
+		return
+	}
+	if len(admins) <= 1 {
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        "❌ Нельзя удалить последнего администратора",
+			ReplyMarkup: backKeyboard,
+		})
+		return
+	}
+
+	if err := h.settingsManager.RemoveAdmin(adminID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to remove admin %d: %v", adminID, err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        "❌ Ошибка сохранения конфигурации",
+			ReplyMarkup: backKeyboard,
+		})
+		return
+	}
+
+	log.Printf("[FORUM_ADMIN] Admin %d removed by user %d", adminID, userID)
+
+	h.showAdminList(ctx, userID, chatID, messageID)
+}
+
+func (h *ForumAdminHandler) handleRefreshAdminProfiles(ctx context.Context, userID, chatID int64, messageID int) {
+	admins, err := h.settingsManager.GetAdmins()
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to get admins: %v", err)
+		return
+	}
+
+	refreshed := h.userManager.RefreshAll(ctx, admins)
+	log.Printf("[FORUM_ADMIN] Refreshed %d/%d admin profiles for user %d", refreshed, len(admins), userID)
+
+	h.showAdminList(ctx, userID, chatID, messageID)
+}
+
+func (h *ForumAdminHandler) handleAddAdminStart(ctx context.Context, userID, chatID int64, messageID int) {
+	err := h.adminStateRepo.Save(&models.AdminState{
+		UserID:       userID,
+		CurrentState: fsm.StateAddAdminID,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to save state: %v", err)
+		h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: messageID,
+			Text:      "❌ Ошибка сохранения состояния",
+		})
+		return
+	}
+
+	keyboard := &tgmodels.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgmodels.InlineKeyboardButton{
+			{
+				{Text: "❌ Отмена", CallbackData: "cancel"},
+			},
+		},
+	}
+
+	sentMsg, err := h.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: messageID,
+		Text: "Отправьте ID нового администратора или перешлите любое его сообщение.\n\n" +
+			"Если у пользователя скрыт аккаунт в пересылаемых сообщениях, отправьте его ID.",
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to send add admin prompt: %v", err)
+	} else if sentMsg != nil {
+		state, _ := h.adminStateRepo.Get(userID)
+		if state != nil {
+			state.LastBotMessageID = sentMsg.ID
+			h.adminStateRepo.Save(state)
+		}
+	}
+
+	log.Printf("[FORUM_ADMIN] Add admin started for user %d", userID)
+}
+
+func (h *ForumAdminHandler) handleAddAdminInput(ctx context.Context, msg *tgmodels.Message, state *models.AdminState) {
+	if state.LastBotMessageID > 0 {
+		_, err := h.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    msg.Chat.ID,
+			MessageID: state.LastBotMessageID,
+		})
+		if err != nil {
+			log.Printf("[FORUM_ADMIN] Failed to delete prompt message: %v", err)
+		}
+		state.LastBotMessageID = 0
+	}
+
+	sendError := func(text string) {
+		sentMsg, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   text,
+		})
+		if err == nil && sentMsg != nil {
+			state.LastBotMessageID = sentMsg.ID
+			h.adminStateRepo.Save(state)
+		}
+	}
+
+	var adminID int64
+	if msg.ForwardOrigin != nil {
+		switch {
+		case msg.ForwardOrigin.MessageOriginUser != nil:
+			sender := msg.ForwardOrigin.MessageOriginUser.SenderUser
+			h.userManager.Track(&sender)
+			adminID = sender.ID
+		case msg.ForwardOrigin.MessageOriginHiddenUser != nil:
+			sendError("❌ Пользователь скрыл свой аккаунт в пересылаемых сообщениях. Отправьте его ID")
+			return
+		default:
+			sendError("❌ Перешлите сообщение от пользователя, а не от чата или канала")
+			return
+		}
+	} else {
+		id, err := strconv.ParseInt(strings.TrimSpace(msg.Text), 10, 64)
+		if err != nil || id <= 0 {
+			sendError("❌ Неверный формат ID. Отправьте числовой ID или перешлите сообщение пользователя")
+			return
+		}
+		adminID = id
+	}
+
+	if err := h.settingsManager.AddAdmin(adminID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to add admin %d: %v", adminID, err)
+		h.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Ошибка сохранения конфигурации",
+		})
+		return
+	}
+
+	if err := h.adminStateRepo.Clear(msg.From.ID); err != nil {
+		log.Printf("[FORUM_ADMIN] Failed to clear state: %v", err)
+	}
+
+	h.userManager.GetOrRefresh(ctx, adminID)
+
+	h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    msg.Chat.ID,
+		Text:      fmt.Sprintf("✅ Администратор %s добавлен", h.userManager.MentionHTML(adminID)),
+		ParseMode: tgmodels.ParseModeHTML,
+	})
+
+	h.showAdminMenu(ctx, msg.Chat.ID, 0)
+
+	log.Printf("[FORUM_ADMIN] Admin %d added by user %d", adminID, msg.From.ID)
+}
@@ -4,4 +4,12 @@ type AdminConfig struct {
 	AdminIDs    []int64
 	ForumChatID int64
 	TopicID     int64
+
+	// Sandbox is where test publications go; zero SandboxChatID disables it.
+	SandboxChatID  int64
+	SandboxTopicID int64
+
+	// TypeTwoColumns pairs short type names into two-column rows in the type
+	// picker.
+	TypeTwoColumns bool
 }
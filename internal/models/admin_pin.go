@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type AdminPIN struct {
+	UserID    int64
+	Hash      string
+	Salt      string
+	UpdatedAt time.Time
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// AdminSignature is added below the footer of every post the admin
+// publishes.
+type AdminSignature struct {
+	UserID    int64
+	Text      string
+	Entities  string
+	UpdatedAt time.Time
+}
@@ -17,4 +17,15 @@ type AdminState struct {
 	ReplyTargetChatID     int64
 	ReplyTargetMessageID  int64
 	DraftUserPhotoID      string
+	ReplyTemplateID       int64
+	DraftOptions          string
+	DraftLayout           string
+	// DraftTopicID overrides the configured topic for the new post.
+	DraftTopicID          int64
+	// TypeSelection is a comma-separated list of type IDs picked for export.
+	TypeSelection string
+	// ImportFileID is the uploaded type bundle awaiting a conflict choice.
+	ImportFileID string
+	// DraftFrame is the header and footer of the new post.
+	DraftFrame string
 }
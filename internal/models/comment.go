@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Comment is a forum message that replies to one of the bot's posts or replies.
+type Comment struct {
+	ID               int64
+	ChatID           int64
+	MessageID        int64
+	ThreadID         int64
+	ReplyToMessageID int64
+	PostID           int64
+	ReplyID          int64
+	UserID           int64
+	Text             string
+	IsRead           bool
+	CreatedAt        time.Time
+}
@@ -0,0 +1,31 @@
+package models
+
+import "encoding/json"
+
+// DeliveryOptions are the send options of a post. The zero value matches
+// Telegram's defaults; options are stored as JSON, an empty string for none.
+type DeliveryOptions struct {
+	Silent            bool `json:"silent,omitempty" yaml:"silent"`
+	Protected         bool `json:"protected,omitempty" yaml:"protected"`
+	Spoiler           bool `json:"spoiler,omitempty" yaml:"spoiler"`
+	CaptionAboveMedia bool `json:"caption_above_media,omitempty" yaml:"caption_above_media"`
+	NoLinkPreview     bool `json:"no_link_preview,omitempty" yaml:"no_link_preview"`
+	LargePreview      bool `json:"large_preview,omitempty" yaml:"large_preview"`
+	PreviewAboveText  bool `json:"preview_above_text,omitempty" yaml:"preview_above_text"`
+}
+
+func ParseDeliveryOptions(s string) DeliveryOptions {
+	var opts DeliveryOptions
+	if s != "" {
+		json.Unmarshal([]byte(s), &opts)
+	}
+	return opts
+}
+
+func (o DeliveryOptions) String() string {
+	if o == (DeliveryOptions{}) {
+		return ""
+	}
+	data, _ := json.Marshal(o)
+	return string(data)
+}
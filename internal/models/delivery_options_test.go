@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+func TestDeliveryOptionsRoundTrip(t *testing.T) {
+	if s := (DeliveryOptions{}).String(); s != "" {
+		t.Errorf("Expected empty string for default options, got %q", s)
+	}
+
+	rapid.Check(t, func(rt *rapid.T) {
+		opts := DeliveryOptions{
+			Silent:            rapid.Bool().Draw(rt, "silent"),
+			Protected:         rapid.Bool().Draw(rt, "protected"),
+			Spoiler:           rapid.Bool().Draw(rt, "spoiler"),
+			CaptionAboveMedia: rapid.Bool().Draw(rt, "captionAboveMedia"),
+			NoLinkPreview:     rapid.Bool().Draw(rt, "noLinkPreview"),
+			LargePreview:      rapid.Bool().Draw(rt, "largePreview"),
+			PreviewAboveText:  rapid.Bool().Draw(rt, "previewAboveText"),
+		}
+		if got := ParseDeliveryOptions(opts.String()); got != opts {
+			rt.Fatalf("Round trip mismatch: %+v != %+v", got, opts)
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Actions of a PostEvent.
+const (
+	PostEventPostEdit    = "post_edit"
+	PostEventPostDelete  = "post_delete"
+	PostEventReplyEdit   = "reply_edit"
+	PostEventReplyDelete = "reply_delete"
+)
+
+// PostEvent is an edit or deletion of a published post or reply by an admin.
+// Deleted posts leave published_posts, so the events are what statistics
+// count them by.
+type PostEvent struct {
+	ID         int64
+	Action     string
+	AdminID    int64
+	PostTypeID int64
+	CreatedAt  time.Time
+}
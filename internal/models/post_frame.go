@@ -0,0 +1,30 @@
+package models
+
+import "encoding/json"
+
+// PostFrame is the text put around the body of a post: the type's header,
+// and the type's footer followed by the author's signature. Entities are
+// JSON like the entities of the body. It is stored as JSON, an empty string
+// for none.
+type PostFrame struct {
+	Header         string `json:"header,omitempty"`
+	HeaderEntities string `json:"header_entities,omitempty"`
+	Footer         string `json:"footer,omitempty"`
+	FooterEntities string `json:"footer_entities,omitempty"`
+}
+
+func ParsePostFrame(s string) PostFrame {
+	var frame PostFrame
+	if s != "" {
+		json.Unmarshal([]byte(s), &frame)
+	}
+	return frame
+}
+
+func (f PostFrame) String() string {
+	if f == (PostFrame{}) {
+		return ""
+	}
+	data, _ := json.Marshal(f)
+	return string(data)
+}
@@ -1,6 +1,33 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Input modes of post bodies: Telegram formatting of the message itself, or
+// HTML or MarkdownV2 markup sent as plain text.
+const (
+	InputModeEntities = ""
+	InputModeHTML     = "html"
+	InputModeMarkdown = "markdown"
+)
+
+// Strategies for picking the image of a new post from the type's images.
+const (
+	ImageStrategyFixed      = ""
+	ImageStrategyRandom     = "random"
+	ImageStrategyRoundRobin = "round_robin"
+	ImageStrategyWeekday    = "weekday"
+)
+
+// Name and emoji of the archive type that keeps posts of deleted types.
+const (
+	ArchiveTypeName  = "Удалённые типы"
+	ArchiveTypeEmoji = "🗄"
+)
 
 type PostType struct {
 	ID               int64
@@ -10,5 +37,67 @@ type PostType struct {
 	Template         string
 	TemplateEntities string
 	IsActive         bool
-	CreatedAt        time.Time
+	DeliveryOptions  string
+	PhotoURL         string
+	InputMode        string
+	SortOrder        int
+	Category         string
+	// AllowedAdmins is a comma-separated list of admin IDs allowed to
+	// publish and edit posts of the type; empty allows every admin.
+	AllowedAdmins string
+	// ImageStrategy picks the image of a new post among PhotoID and the
+	// type's image pool; ImageCursor is the round-robin position.
+	ImageStrategy string
+	ImageCursor   int
+	// Header and Footer are put around the text of every post of the type.
+	Header         string
+	HeaderEntities string
+	Footer         string
+	FooterEntities string
+	// CounterFormat turns on numbering of the type's posts; {n} in it is the
+	// number. CounterNext is the number the next post gets.
+	CounterFormat string
+	CounterNext   int64
+	// IsArchive marks the hidden type that keeps posts of deleted types.
+	IsArchive bool
+	CreatedAt time.Time
+}
+
+// CounterPlaceholder is replaced with the number in CounterFormat.
+const CounterPlaceholder = "{n}"
+
+func (pt *PostType) HasCounter() bool {
+	return pt.CounterFormat != ""
+}
+
+// FormatNumber renders a post number in the type's format.
+func (pt *PostType) FormatNumber(n int64) string {
+	return strings.ReplaceAll(pt.CounterFormat, CounterPlaceholder, strconv.FormatInt(n, 10))
+}
+
+// AllowedAdminIDs returns the admins the type is restricted to, or nil when
+// every admin may use it.
+func (pt *PostType) AllowedAdminIDs() []int64 {
+	var ids []int64
+	for _, part := range strings.Split(pt.AllowedAdmins, ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (pt *PostType) SetAllowedAdminIDs(ids []int64) {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	pt.AllowedAdmins = strings.Join(parts, ",")
+}
+
+// AllowsAdmin reports whether the admin may publish and edit posts of the
+// type.
+func (pt *PostType) AllowsAdmin(userID int64) bool {
+	ids := pt.AllowedAdminIDs()
+	return len(ids) == 0 || slices.Contains(ids, userID)
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// PostTypeImage is an extra image in a post type's pool, used alongside
+// the type's own PhotoID.
+type PostTypeImage struct {
+	ID         int64
+	PostTypeID int64
+	PhotoID    string
+	CreatedAt  time.Time
+}
@@ -29,3 +29,23 @@ func TestProperty3_PostTypeCreationPersistence(t *testing.T) {
 		}
 	})
 }
+
+func TestPostTypeAllowsAdmin(t *testing.T) {
+	pt := &PostType{}
+	if !pt.AllowsAdmin(1) {
+		t.Error("Expected an unrestricted type to allow every admin")
+	}
+
+	pt.SetAllowedAdminIDs([]int64{1, 2})
+	if pt.AllowedAdmins != "1,2" {
+		t.Errorf("AllowedAdmins = %q, want %q", pt.AllowedAdmins, "1,2")
+	}
+	if !pt.AllowsAdmin(2) || pt.AllowsAdmin(3) {
+		t.Error("Expected only listed admins to be allowed")
+	}
+
+	pt.SetAllowedAdminIDs(nil)
+	if pt.AllowedAdmins != "" || !pt.AllowsAdmin(3) {
+		t.Error("Expected clearing the list to allow every admin")
+	}
+}
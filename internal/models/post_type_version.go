@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// PostTypeVersion is a snapshot of how posts of a type look: the image, the
+// template and the frame. A new version is recorded on every change of them.
+type PostTypeVersion struct {
+	ID               int64
+	PostTypeID       int64
+	Version          int
+	PhotoID          string
+	Template         string
+	TemplateEntities string
+	Header           string
+	HeaderEntities   string
+	Footer           string
+	FooterEntities   string
+	ChangedBy        int64
+	CreatedAt        time.Time
+}
+
+func NewPostTypeVersion(pt *PostType) *PostTypeVersion {
+	return &PostTypeVersion{
+		PostTypeID:       pt.ID,
+		PhotoID:          pt.PhotoID,
+		Template:         pt.Template,
+		TemplateEntities: pt.TemplateEntities,
+		Header:           pt.Header,
+		HeaderEntities:   pt.HeaderEntities,
+		Footer:           pt.Footer,
+		FooterEntities:   pt.FooterEntities,
+	}
+}
+
+// Changes lists what differs between the version and the previous one.
+func (v *PostTypeVersion) Changes(prev *PostTypeVersion) []string {
+	var changes []string
+	if v.PhotoID != prev.PhotoID {
+		changes = append(changes, "изображение")
+	}
+	if v.Template != prev.Template || v.TemplateEntities != prev.TemplateEntities {
+		changes = append(changes, "шаблон")
+	}
+	if v.Header != prev.Header || v.HeaderEntities != prev.HeaderEntities {
+		changes = append(changes, "шапка")
+	}
+	if v.Footer != prev.Footer || v.FooterEntities != prev.FooterEntities {
+		changes = append(changes, "подвал")
+	}
+	return changes
+}
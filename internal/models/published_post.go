@@ -2,6 +2,14 @@ package models
 
 import "time"
 
+// Layouts of a post whose text does not fit in a photo caption: the photo
+// with the start of the text followed by text messages, or a text message
+// with a link preview of the type's image.
+const (
+	PostLayoutSplit   = "split"
+	PostLayoutPreview = "preview"
+)
+
 type PublishedPost struct {
 	ID         int64
 	PostTypeID int64
@@ -13,5 +21,17 @@ type PublishedPost struct {
 	Entities           string
 	UserPhotoID        string
 	UserPhotoMessageID int64
-	CreatedAt          time.Time
+	AuthorID           int64
+	DeliveryOptions    string
+	PreviewURL         string
+	// Frame holds the header and footer the post was published with; Text
+	// and Entities are only the body written by the admin.
+	Frame string
+	// Number is the post's number within its type, 0 when the type had no
+	// counter.
+	Number int64
+	// TypeVersion is the version of the type the post was last rendered
+	// with, 0 for posts published before the type had versions.
+	TypeVersion int64
+	CreatedAt   time.Time
 }
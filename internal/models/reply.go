@@ -10,5 +10,6 @@ type Reply struct {
 	Text             string
 	PhotoID          string
 	Entities         string
+	AuthorID         int64
 	CreatedAt        time.Time
 }
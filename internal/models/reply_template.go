@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type ReplyTemplate struct {
+	ID         int64
+	Name       string
+	Text       string
+	Entities   string
+	PhotoID    string
+	UsageCount int64
+	CreatedAt  time.Time
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// SandboxMessage is a message sent by a test publication, kept until the
+// sandbox is cleaned.
+type SandboxMessage struct {
+	ChatID    int64
+	MessageID int64
+	CreatedAt time.Time
+}
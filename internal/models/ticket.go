@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	TicketStatusOpen   = "open"
+	TicketStatusClosed = "closed"
+
+	TicketDirectionIn  = "in"
+	TicketDirectionOut = "out"
+
+	TicketMediaText     = "text"
+	TicketMediaPhoto    = "photo"
+	TicketMediaDocument = "document"
+)
+
+// Ticket is a support conversation started by a user writing to the bot.
+type Ticket struct {
+	ID         int64
+	UserID     int64
+	Status     string
+	AssignedTo int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TicketMessage is one message of a ticket: incoming from the user or an
+// admin's answer delivered by the bot.
+type TicketMessage struct {
+	ID        int64
+	TicketID  int64
+	Direction string
+	AdminID   int64
+	MediaType string
+	Text      string
+	FileID    string
+	CreatedAt time.Time
+}
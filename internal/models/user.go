@@ -0,0 +1,29 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type User struct {
+	ID           int64
+	FirstName    string
+	LastName     string
+	Username     string
+	LanguageCode string
+	UpdatedAt    time.Time
+}
+
+// DisplayName returns the best human-readable name for the user:
+// "First Last", then "@username", then the numeric ID.
+func (u *User) DisplayName() string {
+	name := strings.TrimSpace(strings.TrimSpace(u.FirstName) + " " + strings.TrimSpace(u.LastName))
+	if name != "" {
+		return name
+	}
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	return strconv.FormatInt(u.ID, 10)
+}
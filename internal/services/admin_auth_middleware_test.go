@@ -151,3 +151,27 @@ func TestAdminAuthWithEmptyList(t *testing.T) {
 		t.Errorf("Expected user %d to be ignored with empty admin list", userID)
 	}
 }
+
+func BenchmarkShouldIgnore(b *testing.B) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer testDB.Close()
+
+	if err := db.InitSchema(testDB); err != nil {
+		b.Fatal(err)
+	}
+
+	repo := db.NewAdminConfigRepository(db.NewDBQueueForTest(testDB))
+	if err := repo.Save(&models.AdminConfig{AdminIDs: []int64{1, 2, 3}, ForumChatID: -100, TopicID: 1}); err != nil {
+		b.Fatal(err)
+	}
+	middleware := NewAdminAuthMiddleware(repo)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		middleware.ShouldIgnore(int64(i % 5))
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+var adminRightCheckers = map[string]func(a *tgmodels.ChatMemberAdministrator) bool{
+	"can_manage_chat":            func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanManageChat },
+	"can_delete_messages":        func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanDeleteMessages },
+	"can_manage_video_chats":     func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanManageVideoChats },
+	"can_restrict_members":       func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanRestrictMembers },
+	"can_promote_members":        func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanPromoteMembers },
+	"can_change_info":            func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanChangeInfo },
+	"can_invite_users":           func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanInviteUsers },
+	"can_post_messages":          func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanPostMessages },
+	"can_edit_messages":          func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanEditMessages },
+	"can_pin_messages":           func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanPinMessages },
+	"can_post_stories":           func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanPostStories },
+	"can_edit_stories":           func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanEditStories },
+	"can_delete_stories":         func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanDeleteStories },
+	"can_manage_topics":          func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanManageTopics },
+	"can_manage_direct_messages": func(a *tgmodels.ChatMemberAdministrator) bool { return a.CanManageDirectMessages },
+}
+
+// ParseAdminRights parses a comma-separated list of Telegram admin rights
+// such as "can_post_messages,can_manage_topics".
+func ParseAdminRights(s string) ([]string, error) {
+	rights := []string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if _, ok := adminRightCheckers[part]; !ok {
+			return nil, fmt.Errorf("unknown admin right: %s", part)
+		}
+		rights = append(rights, part)
+	}
+	return rights, nil
+}
+
+// MemberQualifies reports whether a chat administrator should get bot access.
+// The chat owner always qualifies; other admins need at least one of the
+// allowed rights, or any admin status when no rights are configured.
+func MemberQualifies(member tgmodels.ChatMember, allowedRights []string) (int64, bool) {
+	switch {
+	case member.Owner != nil && member.Owner.User != nil:
+		if member.Owner.User.IsBot {
+			return 0, false
+		}
+		return member.Owner.User.ID, true
+	case member.Administrator != nil:
+		admin := member.Administrator
+		if admin.User.IsBot {
+			return 0, false
+		}
+		if len(allowedRights) == 0 {
+			return admin.User.ID, true
+		}
+		for _, right := range allowedRights {
+			if check, ok := adminRightCheckers[right]; ok && check(admin) {
+				return admin.User.ID, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// ComputeSyncedAdmins returns the new admin list: owners first, then every
+// qualifying chat administrator, without duplicates.
+func ComputeSyncedAdmins(members []tgmodels.ChatMember, ownerIDs []int64, allowedRights []string) []int64 {
+	seen := make(map[int64]bool)
+	result := []int64{}
+	for _, id := range ownerIDs {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	for _, member := range members {
+		id, ok := MemberQualifies(member, allowedRights)
+		if ok && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+type AdminSyncer struct {
+	bot           *bot.Bot
+	configRepo    *db.AdminConfigRepository
+	userManager   *UserManager
+	ownerIDs      []int64
+	allowedRights []string
+}
+
+func NewAdminSyncer(b *bot.Bot, configRepo *db.AdminConfigRepository, userManager *UserManager, ownerIDs []int64, allowedRights []string) *AdminSyncer {
+	return &AdminSyncer{
+		bot:           b,
+		configRepo:    configRepo,
+		userManager:   userManager,
+		ownerIDs:      ownerIDs,
+		allowedRights: allowedRights,
+	}
+}
+
+// Sync replaces the admin list with the forum's administrators and returns
+// the granted and revoked user IDs.
+func (s *AdminSyncer) Sync(ctx context.Context) (added, removed []int64, err error) {
+	config, err := s.configRepo.Get()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	if config.ForumChatID == 0 {
+		return nil, nil, fmt.Errorf("forum chat is not configured")
+	}
+
+	members, err := s.bot.GetChatAdministrators(ctx, &bot.GetChatAdministratorsParams{ChatID: config.ForumChatID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get chat administrators: %w", err)
+	}
+
+	if s.userManager != nil {
+		for _, member := range members {
+			if member.Owner != nil {
+				s.userManager.Track(member.Owner.User)
+			}
+			if member.Administrator != nil {
+				s.userManager.Track(&member.Administrator.User)
+			}
+		}
+	}
+
+	synced := ComputeSyncedAdmins(members, s.ownerIDs, s.allowedRights)
+	if len(synced) == 0 {
+		return nil, nil, fmt.Errorf("sync would leave the bot without admins")
+	}
+
+	added, removed = diffAdminIDs(config.AdminIDs, synced)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil, nil, nil
+	}
+
+	config.AdminIDs = synced
+	if err := s.configRepo.Save(config); err != nil {
+		return nil, nil, fmt.Errorf("failed to save config: %w", err)
+	}
+	return added, removed, nil
+}
+
+// Run syncs immediately and then on every tick until ctx is cancelled.
+func (s *AdminSyncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		added, removed, err := s.Sync(ctx)
+		if err != nil {
+			log.Printf("[ADMIN_SYNC] Sync failed: %v", err)
+		} else if len(added) > 0 || len(removed) > 0 {
+			log.Printf("[ADMIN_SYNC] Granted access: %v, revoked access: %v", added, removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func diffAdminIDs(current, synced []int64) (added, removed []int64) {
+	currentSet := make(map[int64]bool, len(current))
+	for _, id := range current {
+		currentSet[id] = true
+	}
+	syncedSet := make(map[int64]bool, len(synced))
+	for _, id := range synced {
+		syncedSet[id] = true
+		if !currentSet[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range current {
+		if !syncedSet[id] {
+			removed = append(removed, id)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+	sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+	return added, removed
+}
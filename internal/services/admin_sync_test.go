@@ -0,0 +1,59 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+func adminMember(id int64, rights tgmodels.ChatMemberAdministrator) tgmodels.ChatMember {
+	rights.User = tgmodels.User{ID: id}
+	return tgmodels.ChatMember{Type: tgmodels.ChatMemberTypeAdministrator, Administrator: &rights}
+}
+
+func TestParseAdminRights(t *testing.T) {
+	rights, err := ParseAdminRights(" can_post_messages, can_manage_topics ,")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(rights, []string{"can_post_messages", "can_manage_topics"}) {
+		t.Errorf("Unexpected rights: %v", rights)
+	}
+
+	if _, err := ParseAdminRights("can_fly"); err == nil {
+		t.Error("Expected error for unknown right")
+	}
+}
+
+func TestComputeSyncedAdmins(t *testing.T) {
+	members := []tgmodels.ChatMember{
+		{Type: tgmodels.ChatMemberTypeOwner, Owner: &tgmodels.ChatMemberOwner{User: &tgmodels.User{ID: 1}}},
+		adminMember(2, tgmodels.ChatMemberAdministrator{CanManageTopics: true}),
+		adminMember(3, tgmodels.ChatMemberAdministrator{CanDeleteMessages: true}),
+		adminMember(4, tgmodels.ChatMemberAdministrator{CanPostMessages: true}),
+	}
+	members[3].Administrator.User.IsBot = true
+
+	got := ComputeSyncedAdmins(members, []int64{100, 2}, []string{"can_post_messages", "can_manage_topics"})
+	want := []int64{100, 2, 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = ComputeSyncedAdmins(members, nil, nil)
+	want = []int64{1, 2, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected all human admins %v, got %v", want, got)
+	}
+}
+
+func TestDiffAdminIDs(t *testing.T) {
+	added, removed := diffAdminIDs([]int64{1, 5, 3}, []int64{3, 4, 1})
+	if !reflect.DeepEqual(added, []int64{4}) {
+		t.Errorf("Unexpected added: %v", added)
+	}
+	if !reflect.DeepEqual(removed, []int64{5}) {
+		t.Errorf("Unexpected removed: %v", removed)
+	}
+}
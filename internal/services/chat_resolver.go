@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+var ErrNoDiscussionGroup = errors.New("channel has no discussion group")
+
+type cachedChat struct {
+	chat     *tgmodels.ChatFullInfo
+	loadedAt time.Time
+}
+
+// ChatResolver looks chats up with getChat and caches the answers, so that
+// @username links can be resolved and public permalinks built without a
+// request per message.
+type ChatResolver struct {
+	bot *bot.Bot
+	ttl time.Duration
+
+	mu         sync.Mutex
+	byID       map[int64]cachedChat
+	byUsername map[string]cachedChat
+}
+
+func NewChatResolver(b *bot.Bot, ttl time.Duration) *ChatResolver {
+	return &ChatResolver{
+		bot:        b,
+		ttl:        ttl,
+		byID:       make(map[int64]cachedChat),
+		byUsername: make(map[string]cachedChat),
+	}
+}
+
+func (r *ChatResolver) cached(chatID int64, username string) *tgmodels.ChatFullInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.byID[chatID]
+	if username != "" {
+		entry, ok = r.byUsername[strings.ToLower(username)]
+	}
+	if !ok || time.Since(entry.loadedAt) >= r.ttl {
+		return nil
+	}
+	return entry.chat
+}
+
+func (r *ChatResolver) store(chat *tgmodels.ChatFullInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := cachedChat{chat: chat, loadedAt: time.Now()}
+	r.byID[chat.ID] = entry
+	if chat.Username != "" {
+		r.byUsername[strings.ToLower(chat.Username)] = entry
+	}
+}
+
+// Chat returns the chat by ID or, when username is set, by @username.
+func (r *ChatResolver) Chat(ctx context.Context, chatID int64, username string) (*tgmodels.ChatFullInfo, error) {
+	if chat := r.cached(chatID, username); chat != nil {
+		return chat, nil
+	}
+	if r.bot == nil {
+		return nil, fmt.Errorf("chat lookup is not available")
+	}
+
+	var id any = chatID
+	if username != "" {
+		id = "@" + username
+	}
+	chat, err := r.bot.GetChat(ctx, &bot.GetChatParams{ChatID: id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat %v: %w", id, err)
+	}
+	r.store(chat)
+	return chat, nil
+}
+
+// ResolveLink parses a message link and fills in the chat ID of @username
+// links. A link to a comment is turned into the comment message in the
+// channel's discussion group.
+func (r *ChatResolver) ResolveLink(ctx context.Context, link string) (*MessageLinkTarget, error) {
+	target, err := ParseMessageLink(link)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Username != "" {
+		chat, err := r.Chat(ctx, 0, target.Username)
+		if err != nil {
+			return nil, err
+		}
+		target.ChatID = chat.ID
+	}
+
+	if target.CommentID != 0 {
+		chat, err := r.Chat(ctx, target.ChatID, "")
+		if err != nil {
+			return nil, err
+		}
+		if chat.LinkedChatID == 0 {
+			return nil, ErrNoDiscussionGroup
+		}
+		target.ChatID = chat.LinkedChatID
+		target.MessageID = target.CommentID
+		target.ThreadID = 0
+		target.CommentID = 0
+	}
+	return target, nil
+}
+
+// Permalink builds a link to a message: t.me/<username>/… for public chats
+// and t.me/c/… otherwise. When the chat cannot be looked up the private form
+// is used, which still works for members.
+func (r *ChatResolver) Permalink(ctx context.Context, chatID, threadID, messageID int64) string {
+	chat, err := r.Chat(ctx, chatID, "")
+	if err == nil && chat.Username != "" {
+		return PublicMessageLink(chat.Username, threadID, messageID)
+	}
+	return MessageLink(chatID, threadID, messageID)
+}
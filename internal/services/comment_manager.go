@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+type CommentManager struct {
+	commentRepo *db.CommentRepository
+	postRepo    *db.PublishedPostRepository
+	replyRepo   *db.ReplyRepository
+	configRepo  *db.AdminConfigRepository
+}
+
+func NewCommentManager(commentRepo *db.CommentRepository, postRepo *db.PublishedPostRepository, replyRepo *db.ReplyRepository, configRepo *db.AdminConfigRepository) *CommentManager {
+	return &CommentManager{
+		commentRepo: commentRepo,
+		postRepo:    postRepo,
+		replyRepo:   replyRepo,
+		configRepo:  configRepo,
+	}
+}
+
+// Capture stores msg as a comment when it is a forum member's reply to one
+// of the bot's posts or replies. It returns nil for any other message and
+// for updates that were already stored.
+func (cm *CommentManager) Capture(msg *tgmodels.Message) (*models.Comment, error) {
+	if msg == nil || msg.From == nil || msg.From.IsBot || msg.ReplyToMessage == nil {
+		return nil, nil
+	}
+
+	config, err := cm.configRepo.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	if config.ForumChatID == 0 || msg.Chat.ID != config.ForumChatID {
+		return nil, nil
+	}
+	if isAdmin, err := cm.configRepo.IsAdmin(msg.From.ID); err != nil || isAdmin {
+		return nil, err
+	}
+
+	comment := &models.Comment{
+		ChatID:           msg.Chat.ID,
+		MessageID:        int64(msg.ID),
+		ThreadID:         int64(msg.MessageThreadID),
+		ReplyToMessageID: int64(msg.ReplyToMessage.ID),
+		UserID:           msg.From.ID,
+		Text:             CommentText(msg),
+	}
+
+	// In forum topics every message carries the topic's first message as
+	// reply_to_message, so only explicit replies to our messages match.
+	if post, err := cm.postRepo.GetByMessageID(msg.Chat.ID, comment.ReplyToMessageID); err == nil {
+		comment.PostID = post.ID
+	} else if reply, err := cm.replyRepo.GetByMessageID(msg.Chat.ID, comment.ReplyToMessageID); err == nil {
+		comment.ReplyID = reply.ID
+	} else {
+		return nil, nil
+	}
+
+	if err := cm.commentRepo.Create(comment); err != nil {
+		return nil, fmt.Errorf("failed to save comment: %w", err)
+	}
+	if comment.ID == 0 {
+		return nil, nil
+	}
+	return comment, nil
+}
+
+// CommentText returns the text of a comment, or a short label for media
+// without a caption.
+func CommentText(msg *tgmodels.Message) string {
+	switch {
+	case msg.Text != "":
+		return msg.Text
+	case msg.Caption != "":
+		return msg.Caption
+	case len(msg.Photo) > 0:
+		return "[фото]"
+	case msg.Sticker != nil:
+		return "[стикер " + msg.Sticker.Emoji + "]"
+	case msg.Video != nil:
+		return "[видео]"
+	case msg.Voice != nil:
+		return "[голосовое сообщение]"
+	case msg.Document != nil:
+		return "[файл " + msg.Document.FileName + "]"
+	default:
+		return "[вложение]"
+	}
+}
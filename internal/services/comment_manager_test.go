@@ -0,0 +1,109 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+	_ "modernc.org/sqlite"
+)
+
+func TestCommentManagerCapture(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer testDB.Close()
+
+	if err := db.InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+
+	queue := db.NewDBQueueForTest(testDB)
+	commentRepo := db.NewCommentRepository(queue)
+	postRepo := db.NewPublishedPostRepository(queue)
+	replyRepo := db.NewReplyRepository(queue)
+	configRepo := db.NewAdminConfigRepository(queue)
+	cm := NewCommentManager(commentRepo, postRepo, replyRepo, configRepo)
+
+	const forumID, adminID, userID = int64(-1001234567890), int64(1), int64(2)
+	if err := configRepo.SetForumConfig(forumID, 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := configRepo.AddAdmin(adminID); err != nil {
+		t.Fatal(err)
+	}
+
+	post := &models.PublishedPost{ChatID: forumID, TopicID: 42, MessageID: 100, Text: "Post"}
+	if err := postRepo.Create(post); err != nil {
+		t.Fatal(err)
+	}
+	reply := &models.Reply{ChatID: forumID, ReplyToMessageID: 50, MessageID: 101, Text: "Reply"}
+	if err := replyRepo.Create(reply); err != nil {
+		t.Fatal(err)
+	}
+
+	message := func(id int, from int64, chatID int64, replyTo int) *tgmodels.Message {
+		return &tgmodels.Message{
+			ID:              id,
+			From:            &tgmodels.User{ID: from},
+			Chat:            tgmodels.Chat{ID: chatID, Type: tgmodels.ChatTypeSupergroup},
+			MessageThreadID: 42,
+			Text:            "Вопрос",
+			ReplyToMessage:  &tgmodels.Message{ID: replyTo},
+		}
+	}
+
+	comment, err := cm.Capture(message(200, userID, forumID, 100))
+	if err != nil || comment == nil {
+		t.Fatalf("Expected a comment on the post, got %+v, %v", comment, err)
+	}
+	if comment.PostID != post.ID || comment.ThreadID != 42 || comment.Text != "Вопрос" {
+		t.Errorf("Unexpected comment %+v", comment)
+	}
+
+	if again, err := cm.Capture(message(200, userID, forumID, 100)); err != nil || again != nil {
+		t.Errorf("Expected a repeated update to be ignored, got %+v, %v", again, err)
+	}
+
+	comment, err = cm.Capture(message(201, userID, forumID, 101))
+	if err != nil || comment == nil || comment.ReplyID != reply.ID {
+		t.Fatalf("Expected a comment on the reply, got %+v, %v", comment, err)
+	}
+
+	ignored := []*tgmodels.Message{
+		message(202, adminID, forumID, 100),
+		message(203, userID, -100999, 100),
+		message(204, userID, forumID, 42),
+	}
+	for _, msg := range ignored {
+		if got, err := cm.Capture(msg); err != nil || got != nil {
+			t.Errorf("Expected message %d to be ignored, got %+v, %v", msg.ID, got, err)
+		}
+	}
+
+	if count, _ := commentRepo.CountByPost(post.ID); count != 1 {
+		t.Errorf("Expected 1 comment on the post, got %d", count)
+	}
+	if unread, _ := commentRepo.CountUnread(); unread != 2 {
+		t.Errorf("Expected 2 unread comments, got %d", unread)
+	}
+}
+
+func TestMessageLink(t *testing.T) {
+	cases := []struct {
+		chatID, threadID, messageID int64
+		want                        string
+	}{
+		{-1001234567890, 42, 7, "https://t.me/c/1234567890/42/7"},
+		{-1001234567890, 0, 7, "https://t.me/c/1234567890/7"},
+		{-12345, 0, 7, ""},
+	}
+	for _, c := range cases {
+		if got := MessageLink(c.chatID, c.threadID, c.messageID); got != c.want {
+			t.Errorf("MessageLink(%d, %d, %d) = %q, want %q", c.chatID, c.threadID, c.messageID, got, c.want)
+		}
+	}
+}
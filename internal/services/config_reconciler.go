@@ -0,0 +1,389 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+// Modes of applying a ContentConfig:
+//   - create adds what is missing and fills unset destinations;
+//   - update also brings existing types and destinations in line;
+//   - prune also deletes types and removes admins the config does not list.
+const (
+	ReconcileCreate = "create"
+	ReconcileUpdate = "update"
+	ReconcilePrune  = "prune"
+)
+
+// ParseReconcileMode returns the mode, update when s is empty.
+func ParseReconcileMode(s string) (string, error) {
+	switch s {
+	case "":
+		return ReconcileUpdate, nil
+	case ReconcileCreate, ReconcileUpdate, ReconcilePrune:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown mode %q", s)
+}
+
+// ConfigChange is one planned change.
+type ConfigChange struct {
+	Action  string
+	Target  string
+	Details []string
+}
+
+func (c ConfigChange) String() string {
+	if len(c.Details) == 0 {
+		return c.Action + " " + c.Target
+	}
+	return fmt.Sprintf("%s %s: %s", c.Action, c.Target, strings.Join(c.Details, ", "))
+}
+
+// ConfigPlan is what applying a config would change. A dry run prints
+// Changes; Apply carries them out.
+type ConfigPlan struct {
+	Mode    string
+	Changes []ConfigChange
+
+	// config is the new admin config, nil when it stays.
+	config       *models.AdminConfig
+	types        []typeChange
+	imagesChatID int64
+}
+
+type typeChange struct {
+	entry   *ConfigType
+	current *models.PostType
+	next    *models.PostType
+	// pool is the new image pool when poolChanged is set.
+	pool        []*ConfigImage
+	poolChanged bool
+	delete      bool
+}
+
+// ConfigReconciler applies a ContentConfig to the bot's database. Images are
+// uploaded once and reused by the hash of the file afterwards.
+type ConfigReconciler struct {
+	bundles         *TypeBundleManager
+	adminConfigRepo *db.AdminConfigRepository
+	postTypeRepo    *db.PostTypeRepository
+	typeVersionRepo *db.PostTypeVersionRepository
+	imageRepo       *db.UploadedImageRepository
+}
+
+func NewConfigReconciler(bundles *TypeBundleManager, adminConfigRepo *db.AdminConfigRepository, postTypeRepo *db.PostTypeRepository, typeVersionRepo *db.PostTypeVersionRepository, imageRepo *db.UploadedImageRepository) *ConfigReconciler {
+	return &ConfigReconciler{
+		bundles:         bundles,
+		adminConfigRepo: adminConfigRepo,
+		postTypeRepo:    postTypeRepo,
+		typeVersionRepo: typeVersionRepo,
+		imageRepo:       imageRepo,
+	}
+}
+
+// Plan compares the config with the database. It changes nothing.
+func (r *ConfigReconciler) Plan(cfg *ContentConfig, mode string) (*ConfigPlan, error) {
+	plan := &ConfigPlan{Mode: mode}
+
+	current, err := r.adminConfigRepo.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin config: %w", err)
+	}
+	next := *current
+	next.AdminIDs = slices.Clone(current.AdminIDs)
+	configChanged := false
+
+	setDestination := func(target string, dest *ConfigDestination, chatID, topicID *int64) {
+		if dest == nil || (mode == ReconcileCreate && *chatID != 0) {
+			return
+		}
+		var details []string
+		if *chatID != dest.ChatID {
+			details = append(details, fmt.Sprintf("chat %d → %d", *chatID, dest.ChatID))
+		}
+		if *topicID != dest.TopicID {
+			details = append(details, fmt.Sprintf("topic %d → %d", *topicID, dest.TopicID))
+		}
+		if len(details) > 0 {
+			*chatID, *topicID = dest.ChatID, dest.TopicID
+			plan.Changes = append(plan.Changes, ConfigChange{Action: "set", Target: target, Details: details})
+			configChanged = true
+		}
+	}
+	setDestination("forum", cfg.Forum, &next.ForumChatID, &next.TopicID)
+	setDestination("sandbox", cfg.Sandbox, &next.SandboxChatID, &next.SandboxTopicID)
+
+	if cfg.Admins != nil {
+		listed := make(map[int64]bool)
+		for _, admin := range cfg.Admins {
+			listed[admin.ID] = true
+			if slices.Contains(next.AdminIDs, admin.ID) {
+				continue
+			}
+			next.AdminIDs = append(next.AdminIDs, admin.ID)
+			change := ConfigChange{Action: "add", Target: fmt.Sprintf("admin %d", admin.ID)}
+			if admin.Name != "" {
+				change.Target += fmt.Sprintf(" (%s)", admin.Name)
+			}
+			plan.Changes = append(plan.Changes, change)
+			configChanged = true
+		}
+		if mode == ReconcilePrune {
+			next.AdminIDs = slices.DeleteFunc(next.AdminIDs, func(id int64) bool {
+				if listed[id] {
+					return false
+				}
+				plan.Changes = append(plan.Changes, ConfigChange{Action: "remove", Target: fmt.Sprintf("admin %d", id)})
+				configChanged = true
+				return true
+			})
+		}
+	}
+	if configChanged {
+		plan.config = &next
+	}
+
+	plan.imagesChatID = cfg.ImagesChatID
+	if plan.imagesChatID == 0 {
+		plan.imagesChatID = next.SandboxChatID
+	}
+	if plan.imagesChatID == 0 && len(next.AdminIDs) > 0 {
+		plan.imagesChatID = next.AdminIDs[0]
+	}
+
+	if cfg.Types != nil {
+		if err := r.planTypes(plan, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+func (r *ConfigReconciler) planTypes(plan *ConfigPlan, cfg *ContentConfig) error {
+	types, err := r.postTypeRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to get post types: %w", err)
+	}
+	existing := make(map[string]*models.PostType, len(types))
+	for _, pt := range types {
+		if !pt.IsArchive {
+			existing[strings.ToLower(pt.Name)] = pt
+		}
+	}
+
+	uploads := false
+	for i := range cfg.Types {
+		entry := &cfg.Types[i]
+		current := existing[strings.ToLower(entry.Name)]
+		delete(existing, strings.ToLower(entry.Name))
+		if current != nil && plan.Mode == ReconcileCreate {
+			continue
+		}
+
+		change := typeChange{entry: entry, current: current, next: &models.PostType{}}
+		if current != nil {
+			copied := *current
+			change.next = &copied
+		}
+		entry.apply(change.next)
+		change.next.SortOrder = i + 1
+		if entry.Roles != nil {
+			change.next.SetAllowedAdminIDs(entry.allowedAdmins)
+		}
+
+		var details []string
+		if entry.image != nil {
+			photoID, err := r.imageRepo.Get(entry.image.Hash)
+			if err != nil {
+				return fmt.Errorf("failed to get uploaded image: %w", err)
+			}
+			if photoID == "" || current == nil || photoID != current.PhotoID {
+				details = append(details, "image")
+				uploads = uploads || photoID == ""
+			}
+		}
+		if entry.Pool != nil {
+			changed, missing, err := r.poolChanged(entry, current)
+			if err != nil {
+				return err
+			}
+			if changed {
+				change.poolChanged = true
+				for j := range entry.pool {
+					change.pool = append(change.pool, &entry.pool[j])
+				}
+				details = append(details, "image pool")
+				uploads = uploads || missing
+			}
+		}
+
+		if current == nil {
+			plan.types = append(plan.types, change)
+			plan.Changes = append(plan.Changes, ConfigChange{Action: "create", Target: fmt.Sprintf("type %q", entry.Name)})
+			continue
+		}
+		details = append(typeDiff(current, change.next), details...)
+		if len(details) > 0 {
+			plan.types = append(plan.types, change)
+			plan.Changes = append(plan.Changes, ConfigChange{Action: "update", Target: fmt.Sprintf("type %q", current.Name), Details: details})
+		}
+	}
+
+	if plan.Mode == ReconcilePrune {
+		for _, pt := range types {
+			if pt.IsArchive || existing[strings.ToLower(pt.Name)] != pt {
+				continue
+			}
+			count, err := r.postTypeRepo.CountPosts(pt.ID)
+			if err != nil {
+				return fmt.Errorf("failed to count posts of %q: %w", pt.Name, err)
+			}
+			change := ConfigChange{Action: "delete", Target: fmt.Sprintf("type %q", pt.Name)}
+			if count > 0 {
+				change.Details = []string{fmt.Sprintf("%d posts move to %q", count, models.ArchiveTypeName)}
+			}
+			plan.types = append(plan.types, typeChange{current: pt, delete: true})
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	if uploads && plan.imagesChatID == 0 {
+		return fmt.Errorf("%w: images_chat_id is needed to upload images", ErrInvalidContentConfig)
+	}
+	return nil
+}
+
+// poolChanged reports whether the type's image pool differs from the
+// config's and whether some of the config's images were never uploaded.
+func (r *ConfigReconciler) poolChanged(entry *ConfigType, current *models.PostType) (bool, bool, error) {
+	var want []string
+	for _, image := range entry.pool {
+		photoID, err := r.imageRepo.Get(image.Hash)
+		if err != nil {
+			return false, false, fmt.Errorf("failed to get uploaded image: %w", err)
+		}
+		if photoID == "" {
+			return true, true, nil
+		}
+		want = append(want, photoID)
+	}
+	if current == nil {
+		return len(want) > 0, false, nil
+	}
+	pool, err := r.postTypeRepo.GetImages(current.ID)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to get images of %q: %w", current.Name, err)
+	}
+	var have []string
+	for _, image := range pool {
+		have = append(have, image.PhotoID)
+	}
+	return !slices.Equal(want, have), false, nil
+}
+
+// typeDiff names the settings of next that differ from pt.
+func typeDiff(pt, next *models.PostType) []string {
+	var diff []string
+	for _, field := range []struct {
+		name    string
+		changed bool
+	}{
+		{"name", pt.Name != next.Name},
+		{"emoji", pt.Emoji != next.Emoji},
+		{"category", pt.Category != next.Category},
+		{"template", pt.Template != next.Template || pt.TemplateEntities != next.TemplateEntities},
+		{"header", pt.Header != next.Header || pt.HeaderEntities != next.HeaderEntities},
+		{"footer", pt.Footer != next.Footer || pt.FooterEntities != next.FooterEntities},
+		{"input mode", pt.InputMode != next.InputMode},
+		{"photo URL", pt.PhotoURL != next.PhotoURL},
+		{"image strategy", pt.ImageStrategy != next.ImageStrategy},
+		{"counter", pt.CounterFormat != next.CounterFormat},
+		{"active", pt.IsActive != next.IsActive},
+		{"delivery", pt.DeliveryOptions != next.DeliveryOptions},
+		{"access", pt.AllowedAdmins != next.AllowedAdmins},
+		{"order", pt.SortOrder != next.SortOrder},
+	} {
+		if field.changed {
+			diff = append(diff, field.name)
+		}
+	}
+	return diff
+}
+
+// photoID returns the file ID of the image, uploading it when this bot has
+// not seen the file yet.
+func (r *ConfigReconciler) photoID(ctx context.Context, chatID int64, image *ConfigImage) (string, error) {
+	photoID, err := r.imageRepo.Get(image.Hash)
+	if err != nil || photoID != "" {
+		return photoID, err
+	}
+	photoID, err = r.bundles.uploadImage(ctx, chatID, image.Path, image.Data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", image.Path, err)
+	}
+	return photoID, r.imageRepo.Set(image.Hash, photoID)
+}
+
+// Apply carries out the plan. It stops at the first error; what was applied
+// before it stays.
+func (r *ConfigReconciler) Apply(ctx context.Context, plan *ConfigPlan) error {
+	if plan.config != nil {
+		if err := r.adminConfigRepo.Save(plan.config); err != nil {
+			return fmt.Errorf("failed to save admin config: %w", err)
+		}
+	}
+
+	for _, change := range plan.types {
+		if change.delete {
+			if _, err := r.postTypeRepo.DeleteMovingPosts(change.current.ID, 0); err != nil {
+				return fmt.Errorf("failed to delete post type %q: %w", change.current.Name, err)
+			}
+			continue
+		}
+
+		next := change.next
+		if change.entry.image != nil {
+			photoID, err := r.photoID(ctx, plan.imagesChatID, change.entry.image)
+			if err != nil {
+				return fmt.Errorf("type %q: %w", next.Name, err)
+			}
+			next.PhotoID = photoID
+		}
+		var pool []string
+		for _, image := range change.pool {
+			photoID, err := r.photoID(ctx, plan.imagesChatID, image)
+			if err != nil {
+				return fmt.Errorf("type %q: %w", next.Name, err)
+			}
+			pool = append(pool, photoID)
+		}
+
+		if change.current == nil {
+			if err := r.postTypeRepo.Create(next); err != nil {
+				return fmt.Errorf("failed to create post type %q: %w", next.Name, err)
+			}
+		} else {
+			if err := r.postTypeRepo.Update(next); err != nil {
+				return fmt.Errorf("failed to update post type %q: %w", next.Name, err)
+			}
+			prevVersion, nextVersion := models.NewPostTypeVersion(change.current), models.NewPostTypeVersion(next)
+			if len(nextVersion.Changes(prevVersion)) > 0 {
+				if _, err := r.typeVersionRepo.Record(prevVersion, nextVersion); err != nil {
+					return fmt.Errorf("failed to record version of %q: %w", next.Name, err)
+				}
+			}
+		}
+		if change.poolChanged {
+			if err := r.bundles.replacePool(next.ID, pool); err != nil {
+				return fmt.Errorf("failed to save images of %q: %w", next.Name, err)
+			}
+		}
+	}
+	return nil
+}
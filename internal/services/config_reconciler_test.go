@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+func writeConfig(t *testing.T, dir, config string) string {
+	t.Helper()
+	path := filepath.Join(dir, "bot.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadContentConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "news.jpg"), []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadContentConfig(writeConfig(t, dir, `
+forum: {chat_id: -100, topic_id: 5}
+admins:
+  - {id: 1, name: anna, roles: [news]}
+  - {id: 2, roles: [news, jobs]}
+types:
+  - name: Новости
+    input_mode: html
+    template: "<b>Новость</b>"
+    image: news.jpg
+    roles: [news]
+    delivery: {silent: true}
+`))
+	if err != nil {
+		t.Fatalf("LoadContentConfig() error = %v", err)
+	}
+	pt := &models.PostType{}
+	typ := &cfg.Types[0]
+	typ.apply(pt)
+	if pt.Template != "Новость" || pt.TemplateEntities == "" || !pt.IsActive || pt.DeliveryOptions != `{"silent":true}` {
+		t.Errorf("Type = %+v", pt)
+	}
+	if typ.image == nil || string(typ.image.Data) != "jpeg" || len(typ.allowedAdmins) != 2 {
+		t.Errorf("Image %+v, admins %v", typ.image, typ.allowedAdmins)
+	}
+
+	// JSON is YAML too.
+	if _, err := LoadContentConfig(writeConfig(t, dir, `{"types": [{"name": "A", "template": "t"}]}`)); err != nil {
+		t.Errorf("LoadContentConfig() of JSON error = %v", err)
+	}
+
+	for name, config := range map[string]string{
+		"no template":    `types: [{name: A}]`,
+		"duplicate type": `types: [{name: A, template: t}, {name: a, template: t}]`,
+		"unknown role":   `types: [{name: A, template: t, roles: [news]}]`,
+		"missing image":  `types: [{name: A, template: t, image: missing.jpg}]`,
+		"bad markup":     `types: [{name: A, template: "<b>t", input_mode: html}]`,
+		"empty admins":   `admins: []`,
+		"no forum chat":  `forum: {topic_id: 5}`,
+	} {
+		if _, err := LoadContentConfig(writeConfig(t, dir, config)); !errors.Is(err, ErrInvalidContentConfig) {
+			t.Errorf("%s: LoadContentConfig() error = %v, want ErrInvalidContentConfig", name, err)
+		}
+	}
+}
+
+func TestConfigReconciler(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if err := db.InitSchema(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	queue := db.NewDBQueueForTest(sqlDB)
+	adminConfigRepo := db.NewAdminConfigRepository(queue)
+	postTypeRepo := db.NewPostTypeRepository(queue)
+	typeVersionRepo := db.NewPostTypeVersionRepository(queue)
+	imageRepo := db.NewUploadedImageRepository(queue)
+	r := NewConfigReconciler(NewTypeBundleManager(nil, postTypeRepo), adminConfigRepo, postTypeRepo, typeVersionRepo, imageRepo)
+
+	if err := adminConfigRepo.Save(&models.AdminConfig{AdminIDs: []int64{1, 3}, ForumChatID: -100}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Новости", "Старое"} {
+		if err := postTypeRepo.Create(&models.PostType{Name: name, Template: "старый", IsActive: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "news.jpg"), []byte("jpeg"), 0o644)
+	cfg, err := LoadContentConfig(writeConfig(t, dir, `
+forum: {chat_id: -200}
+admins: [{id: 1}, {id: 2}]
+types:
+  - {name: Новости, template: новый, image: news.jpg}
+  - {name: Анонсы, template: анонс}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The image was uploaded on an earlier start.
+	if err := imageRepo.Set(cfg.Types[0].image.Hash, "photo-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := r.Plan(cfg, ReconcileCreate)
+	if err != nil {
+		t.Fatalf("Plan(create) error = %v", err)
+	}
+	if got := planText(plan); got != `add admin 2; create type "Анонсы"` {
+		t.Errorf("Plan(create) = %s", got)
+	}
+
+	plan, err = r.Plan(cfg, ReconcilePrune)
+	if err != nil {
+		t.Fatalf("Plan(prune) error = %v", err)
+	}
+	want := `set forum: chat -100 → -200; add admin 2; remove admin 3; update type "Новости": template, order, image; create type "Анонсы"; delete type "Старое"`
+	if got := planText(plan); got != want {
+		t.Errorf("Plan(prune) = %s, want %s", got, want)
+	}
+	if types, _ := postTypeRepo.GetAll(); len(types) != 2 {
+		t.Fatalf("Plan() changed the types: %d", len(types))
+	}
+
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	config, _ := adminConfigRepo.Get()
+	if config.ForumChatID != -200 || len(config.AdminIDs) != 2 || config.AdminIDs[1] != 2 {
+		t.Errorf("Admin config = %+v", config)
+	}
+	types, _ := postTypeRepo.GetAll()
+	if len(types) != 2 {
+		t.Fatalf("Types after prune = %d, want 2", len(types))
+	}
+	for _, pt := range types {
+		if pt.Name == "Новости" && (pt.Template != "новый" || pt.PhotoID != "photo-1") {
+			t.Errorf("Updated type = %+v", pt)
+		}
+	}
+	if version, _ := typeVersionRepo.Current(types[0].ID); version == 0 {
+		if version, _ = typeVersionRepo.Current(types[1].ID); version != 2 {
+			t.Errorf("No version recorded for the updated type")
+		}
+	}
+
+	if plan, err := r.Plan(cfg, ReconcilePrune); err != nil || len(plan.Changes) != 0 {
+		t.Errorf("Plan() after Apply() = %s, %v, want no changes", planText(plan), err)
+	}
+}
+
+func planText(plan *ConfigPlan) string {
+	var lines []string
+	for _, change := range plan.Changes {
+		lines = append(lines, change.String())
+	}
+	return strings.Join(lines, "; ")
+}
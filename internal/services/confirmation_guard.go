@@ -0,0 +1,311 @@
+package services
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+const (
+	pinHashIterations = 100000
+	pinMaxFailures    = 5
+	pinLockout        = 15 * time.Minute
+	approvalTTL       = 10 * time.Minute
+)
+
+var (
+	ErrInvalidPIN       = errors.New("PIN must be 4 to 12 digits")
+	ErrPINLocked        = errors.New("too many wrong PIN attempts")
+	ErrApprovalNotFound = errors.New("approval request not found or expired")
+	ErrSelfApproval     = errors.New("admins cannot approve their own requests")
+)
+
+type ConfirmMethod int
+
+const (
+	ConfirmNone ConfirmMethod = iota
+	ConfirmPIN
+	ConfirmSecondAdmin
+)
+
+type ApprovalRequest struct {
+	Token       string
+	RequesterID int64
+	ChatID      int64
+	Action      string
+	CreatedAt   time.Time
+}
+
+// ConfirmationGuard decides whether a sensitive action needs a PIN or a
+// second admin's approval. A correct PIN opens a session that stays valid
+// while the admin keeps interacting with the bot and expires after
+// idleTimeout of inactivity. A second admin's approval only allows the one
+// action it was given for.
+type ConfirmationGuard struct {
+	pinRepo            *db.AdminPINRepository
+	idleTimeout        time.Duration
+	requireSecondAdmin bool
+
+	mu          sync.Mutex
+	sessions    map[int64]time.Time
+	pending     map[int64]string
+	failures    map[int64]int
+	lockedUntil map[int64]time.Time
+	approvals   map[string]*ApprovalRequest
+	grants      map[int64]map[string]time.Time
+	now         func() time.Time
+}
+
+func NewConfirmationGuard(pinRepo *db.AdminPINRepository, idleTimeout time.Duration, requireSecondAdmin bool) *ConfirmationGuard {
+	return &ConfirmationGuard{
+		pinRepo:            pinRepo,
+		idleTimeout:        idleTimeout,
+		requireSecondAdmin: requireSecondAdmin,
+		sessions:           make(map[int64]time.Time),
+		pending:            make(map[int64]string),
+		failures:           make(map[int64]int),
+		lockedUntil:        make(map[int64]time.Time),
+		approvals:          make(map[string]*ApprovalRequest),
+		grants:             make(map[int64]map[string]time.Time),
+		now:                time.Now,
+	}
+}
+
+func (g *ConfirmationGuard) IdleTimeout() time.Duration {
+	return g.idleTimeout
+}
+
+func ValidatePIN(pin string) error {
+	if len(pin) < 4 || len(pin) > 12 {
+		return ErrInvalidPIN
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return ErrInvalidPIN
+		}
+	}
+	return nil
+}
+
+func hashPIN(pin string, salt []byte) (string, error) {
+	key, err := pbkdf2.Key(sha256.New, pin, salt, pinHashIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+func (g *ConfirmationGuard) HasPIN(userID int64) bool {
+	_, err := g.pinRepo.Get(userID)
+	return err == nil
+}
+
+func (g *ConfirmationGuard) SetPIN(userID int64, pin string) error {
+	if err := ValidatePIN(pin); err != nil {
+		return err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	hash, err := hashPIN(pin, salt)
+	if err != nil {
+		return fmt.Errorf("failed to hash PIN: %w", err)
+	}
+
+	if err := g.pinRepo.Set(&models.AdminPIN{
+		UserID: userID,
+		Hash:   hash,
+		Salt:   hex.EncodeToString(salt),
+	}); err != nil {
+		return fmt.Errorf("failed to save PIN: %w", err)
+	}
+
+	g.MarkVerified(userID)
+	return nil
+}
+
+func (g *ConfirmationGuard) RemovePIN(userID int64) error {
+	return g.pinRepo.Delete(userID)
+}
+
+// Method returns how the admin has to confirm a sensitive action right now.
+func (g *ConfirmationGuard) Method(userID int64, adminCount int) ConfirmMethod {
+	g.mu.Lock()
+	lastSeen, ok := g.sessions[userID]
+	fresh := ok && g.now().Sub(lastSeen) < g.idleTimeout
+	g.mu.Unlock()
+	if fresh {
+		return ConfirmNone
+	}
+
+	if g.HasPIN(userID) {
+		return ConfirmPIN
+	}
+	if g.requireSecondAdmin && adminCount > 1 {
+		return ConfirmSecondAdmin
+	}
+	return ConfirmNone
+}
+
+// Touch extends a confirmed session; expired sessions are dropped.
+func (g *ConfirmationGuard) Touch(userID int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	lastSeen, ok := g.sessions[userID]
+	if !ok {
+		return
+	}
+	if g.now().Sub(lastSeen) >= g.idleTimeout {
+		delete(g.sessions, userID)
+		return
+	}
+	g.sessions[userID] = g.now()
+}
+
+func (g *ConfirmationGuard) MarkVerified(userID int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sessions[userID] = g.now()
+	delete(g.failures, userID)
+}
+
+func (g *ConfirmationGuard) SetPending(userID int64, action string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pending[userID] = action
+}
+
+func (g *ConfirmationGuard) Pending(userID int64) (string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	action, ok := g.pending[userID]
+	return action, ok
+}
+
+func (g *ConfirmationGuard) ClearPending(userID int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.pending, userID)
+}
+
+// VerifyPIN checks the PIN and opens a session on success. After
+// pinMaxFailures wrong attempts the admin is locked out for pinLockout.
+func (g *ConfirmationGuard) VerifyPIN(userID int64, pin string) (bool, error) {
+	g.mu.Lock()
+	if until, ok := g.lockedUntil[userID]; ok && g.now().Before(until) {
+		g.mu.Unlock()
+		return false, ErrPINLocked
+	}
+	g.mu.Unlock()
+
+	stored, err := g.pinRepo.Get(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("PIN is not set")
+		}
+		return false, err
+	}
+	salt, err := hex.DecodeString(stored.Salt)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode salt: %w", err)
+	}
+	hash, err := hashPIN(pin, salt)
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(stored.Hash)) == 1 {
+		g.MarkVerified(userID)
+		return true, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failures[userID]++
+	if g.failures[userID] >= pinMaxFailures {
+		g.lockedUntil[userID] = g.now().Add(pinLockout)
+		delete(g.failures, userID)
+		delete(g.pending, userID)
+		return false, ErrPINLocked
+	}
+	return false, nil
+}
+
+func (g *ConfirmationGuard) RequestApproval(requesterID, chatID int64, action string) (*ApprovalRequest, error) {
+	tokenBytes := make([]byte, 8)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	req := &ApprovalRequest{
+		Token:       hex.EncodeToString(tokenBytes),
+		RequesterID: requesterID,
+		ChatID:      chatID,
+		Action:      action,
+		CreatedAt:   g.now(),
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for token, existing := range g.approvals {
+		if g.now().Sub(existing.CreatedAt) >= approvalTTL {
+			delete(g.approvals, token)
+		}
+	}
+	g.approvals[req.Token] = req
+	return req, nil
+}
+
+// ResolveApproval approves or rejects a pending request. Approval lets the
+// requester run the approved action once, see ConsumeGrant.
+func (g *ConfirmationGuard) ResolveApproval(token string, approverID int64, approve bool) (*ApprovalRequest, error) {
+	g.mu.Lock()
+	req, ok := g.approvals[token]
+	if !ok || g.now().Sub(req.CreatedAt) >= approvalTTL {
+		delete(g.approvals, token)
+		g.mu.Unlock()
+		return nil, ErrApprovalNotFound
+	}
+	if req.RequesterID == approverID {
+		g.mu.Unlock()
+		return nil, ErrSelfApproval
+	}
+	delete(g.approvals, token)
+	if approve {
+		if g.grants[req.RequesterID] == nil {
+			g.grants[req.RequesterID] = make(map[string]time.Time)
+		}
+		g.grants[req.RequesterID][req.Action] = g.now()
+	}
+	g.mu.Unlock()
+	return req, nil
+}
+
+// ConsumeGrant reports whether a second admin approved exactly this action
+// for the user within approvalTTL, and uses the approval up.
+func (g *ConfirmationGuard) ConsumeGrant(userID int64, action string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	grantedAt, ok := g.grants[userID][action]
+	if !ok {
+		return false
+	}
+	delete(g.grants[userID], action)
+	if len(g.grants[userID]) == 0 {
+		delete(g.grants, userID)
+	}
+	return g.now().Sub(grantedAt) < approvalTTL
+}
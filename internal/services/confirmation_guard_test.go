@@ -0,0 +1,155 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	_ "modernc.org/sqlite"
+)
+
+func setupConfirmationGuard(t *testing.T, requireSecondAdmin bool) (*ConfirmationGuard, *time.Time) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	t.Cleanup(func() { testDB.Close() })
+
+	if err := db.InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+
+	guard := NewConfirmationGuard(db.NewAdminPINRepository(db.NewDBQueueForTest(testDB)), 10*time.Minute, requireSecondAdmin)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+	return guard, &now
+}
+
+func TestConfirmationGuardWithoutPIN(t *testing.T) {
+	guard, _ := setupConfirmationGuard(t, false)
+
+	if m := guard.Method(1, 3); m != ConfirmNone {
+		t.Errorf("Expected no confirmation without PIN, got %v", m)
+	}
+}
+
+func TestConfirmationGuardPINAndIdleTimeout(t *testing.T) {
+	guard, now := setupConfirmationGuard(t, false)
+
+	if err := guard.SetPIN(1, "12a4"); !errors.Is(err, ErrInvalidPIN) {
+		t.Fatalf("Expected ErrInvalidPIN, got %v", err)
+	}
+	if err := guard.SetPIN(1, "1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := guard.Method(1, 1); m != ConfirmNone {
+		t.Errorf("Expected setting a PIN to open a session, got %v", m)
+	}
+
+	*now = now.Add(5 * time.Minute)
+	guard.Touch(1)
+	*now = now.Add(9 * time.Minute)
+	if m := guard.Method(1, 1); m != ConfirmNone {
+		t.Errorf("Expected activity to extend the session, got %v", m)
+	}
+
+	*now = now.Add(11 * time.Minute)
+	if m := guard.Method(1, 1); m != ConfirmPIN {
+		t.Fatalf("Expected PIN after idle timeout, got %v", m)
+	}
+
+	if ok, err := guard.VerifyPIN(1, "0000"); ok || err != nil {
+		t.Errorf("Expected wrong PIN to fail without error, got %v %v", ok, err)
+	}
+	if ok, err := guard.VerifyPIN(1, "1234"); !ok || err != nil {
+		t.Fatalf("Expected correct PIN to pass, got %v %v", ok, err)
+	}
+	if m := guard.Method(1, 1); m != ConfirmNone {
+		t.Errorf("Expected verified session, got %v", m)
+	}
+}
+
+func TestConfirmationGuardPINLockout(t *testing.T) {
+	guard, now := setupConfirmationGuard(t, false)
+	if err := guard.SetPIN(1, "1234"); err != nil {
+		t.Fatal(err)
+	}
+	guard.SetPending(1, "settings_backup")
+
+	var err error
+	for i := 0; i < pinMaxFailures; i++ {
+		_, err = guard.VerifyPIN(1, "9999")
+	}
+	if !errors.Is(err, ErrPINLocked) {
+		t.Fatalf("Expected lockout after %d failures, got %v", pinMaxFailures, err)
+	}
+	if _, ok := guard.Pending(1); ok {
+		t.Error("Expected lockout to drop the pending action")
+	}
+	if _, err := guard.VerifyPIN(1, "1234"); !errors.Is(err, ErrPINLocked) {
+		t.Errorf("Expected correct PIN to be rejected while locked, got %v", err)
+	}
+
+	*now = now.Add(pinLockout)
+	if ok, err := guard.VerifyPIN(1, "1234"); !ok || err != nil {
+		t.Errorf("Expected PIN to work after lockout, got %v %v", ok, err)
+	}
+}
+
+func TestConfirmationGuardSecondAdminApproval(t *testing.T) {
+	guard, now := setupConfirmationGuard(t, true)
+
+	if m := guard.Method(1, 1); m != ConfirmNone {
+		t.Errorf("Expected a single admin not to need approval, got %v", m)
+	}
+	if m := guard.Method(1, 2); m != ConfirmSecondAdmin {
+		t.Fatalf("Expected second admin approval, got %v", m)
+	}
+
+	req, err := guard.RequestApproval(1, 100, "settings_backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guard.ResolveApproval(req.Token, 1, true); !errors.Is(err, ErrSelfApproval) {
+		t.Errorf("Expected self approval to fail, got %v", err)
+	}
+
+	resolved, err := guard.ResolveApproval(req.Token, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Action != "settings_backup" || resolved.ChatID != 100 {
+		t.Errorf("Unexpected request %+v", resolved)
+	}
+	if m := guard.Method(1, 2); m != ConfirmSecondAdmin {
+		t.Errorf("Expected approval not to open a session, got %v", m)
+	}
+	if guard.ConsumeGrant(1, "settings_security") {
+		t.Error("Expected the approval not to cover other actions")
+	}
+	if !guard.ConsumeGrant(1, "settings_backup") {
+		t.Error("Expected the approved action to be allowed")
+	}
+	if guard.ConsumeGrant(1, "settings_backup") {
+		t.Error("Expected the approval to be used up")
+	}
+	if _, err := guard.ResolveApproval(req.Token, 2, true); !errors.Is(err, ErrApprovalNotFound) {
+		t.Errorf("Expected approval to be single-use, got %v", err)
+	}
+
+	expired, _ := guard.RequestApproval(3, 100, "access_edit_admins")
+	*now = now.Add(approvalTTL)
+	if _, err := guard.ResolveApproval(expired.Token, 2, true); !errors.Is(err, ErrApprovalNotFound) {
+		t.Errorf("Expected expired request to be rejected, got %v", err)
+	}
+
+	stale, _ := guard.RequestApproval(1, 100, "settings_backup")
+	guard.ResolveApproval(stale.Token, 2, true)
+	*now = now.Add(approvalTTL)
+	if guard.ConsumeGrant(1, "settings_backup") {
+		t.Error("Expected an expired approval not to be allowed")
+	}
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxConfigImageSize is Telegram's limit for uploaded photos.
+const MaxConfigImageSize = 10 << 20
+
+var ErrInvalidContentConfig = errors.New("invalid content config")
+
+// ContentConfig describes post types, admins and destinations of a bot. It
+// is read from YAML or JSON. A section left out of the file is not managed:
+// the bot keeps what it has.
+type ContentConfig struct {
+	Forum   *ConfigDestination `yaml:"forum"`
+	Sandbox *ConfigDestination `yaml:"sandbox"`
+	// ImagesChatID is where type images are uploaded to get file IDs; the
+	// sandbox or the first admin's chat is used when it is zero.
+	ImagesChatID int64         `yaml:"images_chat_id"`
+	Admins       []ConfigAdmin `yaml:"admins"`
+	Types        []ConfigType  `yaml:"types"`
+}
+
+type ConfigDestination struct {
+	ChatID  int64 `yaml:"chat_id"`
+	TopicID int64 `yaml:"topic_id"`
+}
+
+// ConfigAdmin is an admin of the bot. Roles name groups of admins that types
+// can be restricted to.
+type ConfigAdmin struct {
+	ID    int64    `yaml:"id"`
+	Name  string   `yaml:"name"`
+	Roles []string `yaml:"roles"`
+}
+
+// ConfigType is a post type. Template, Header and Footer are written in the
+// markup of InputMode, plain text by default. Image and Pool are paths
+// relative to the config file; they and Roles are not managed when left
+// out, so images and access set in the bot stay.
+type ConfigType struct {
+	Name          string                 `yaml:"name"`
+	Emoji         string                 `yaml:"emoji"`
+	Category      string                 `yaml:"category"`
+	Template      string                 `yaml:"template"`
+	Header        string                 `yaml:"header"`
+	Footer        string                 `yaml:"footer"`
+	InputMode     string                 `yaml:"input_mode"`
+	PhotoURL      string                 `yaml:"photo_url"`
+	ImageStrategy string                 `yaml:"image_strategy"`
+	CounterFormat string                 `yaml:"counter_format"`
+	Active        *bool                  `yaml:"active"`
+	Delivery      models.DeliveryOptions `yaml:"delivery"`
+	Image         string                 `yaml:"image"`
+	Pool          []string               `yaml:"pool"`
+	Roles         []string               `yaml:"roles"`
+
+	// Filled in by LoadContentConfig.
+	template, templateEntities string
+	header, headerEntities     string
+	footer, footerEntities     string
+	image                      *ConfigImage
+	pool                       []ConfigImage
+	allowedAdmins              []int64
+}
+
+// ConfigImage is a local image file.
+type ConfigImage struct {
+	Path string
+	Hash string
+	Data []byte
+}
+
+func loadConfigImage(dir, name string) (*ConfigImage, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, name)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxConfigImageSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", name, MaxConfigImageSize>>20)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return &ConfigImage{Path: name, Hash: hex.EncodeToString(sum[:]), Data: data}, nil
+}
+
+func parseConfigMarkup(mode, input string) (string, string, error) {
+	if input == "" {
+		return "", "", nil
+	}
+	text, entities, err := ParseMarkup(mode, strings.TrimRight(input, "\n"), nil)
+	if err != nil {
+		return "", "", err
+	}
+	return text, entitiesJSON(entities), nil
+}
+
+// LoadContentConfig reads and validates the config file, loading the images
+// it refers to.
+func LoadContentConfig(path string) (*ContentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg ContentConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContentConfig, err)
+	}
+	if err := cfg.validate(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContentConfig, err)
+	}
+	return &cfg, nil
+}
+
+func (cfg *ContentConfig) validate(dir string) error {
+	if cfg.Forum != nil && cfg.Forum.ChatID == 0 {
+		return errors.New("forum has no chat_id")
+	}
+	if cfg.Sandbox != nil && cfg.Sandbox.ChatID == 0 {
+		return errors.New("sandbox has no chat_id")
+	}
+
+	// An empty list would leave the bot without admins in prune mode.
+	if cfg.Admins != nil && len(cfg.Admins) == 0 {
+		return errors.New("admins is empty; leave it out to keep the current admins")
+	}
+
+	roles := make(map[string][]int64)
+	seenAdmins := make(map[int64]bool)
+	for _, admin := range cfg.Admins {
+		if admin.ID == 0 {
+			return errors.New("admin without id")
+		}
+		if seenAdmins[admin.ID] {
+			return fmt.Errorf("admin %d is listed twice", admin.ID)
+		}
+		seenAdmins[admin.ID] = true
+		for _, role := range admin.Roles {
+			roles[role] = append(roles[role], admin.ID)
+		}
+	}
+
+	seenTypes := make(map[string]bool)
+	for i := range cfg.Types {
+		t := &cfg.Types[i]
+		t.Name = strings.TrimSpace(t.Name)
+		if t.Name == "" || t.Template == "" {
+			return fmt.Errorf("type %d has no name or template", i+1)
+		}
+		key := strings.ToLower(t.Name)
+		if seenTypes[key] || key == strings.ToLower(models.ArchiveTypeName) {
+			return fmt.Errorf("type name %q is taken", t.Name)
+		}
+		seenTypes[key] = true
+
+		switch t.InputMode {
+		case models.InputModeEntities, models.InputModeHTML, models.InputModeMarkdown:
+		default:
+			return fmt.Errorf("type %q has unknown input mode %q", t.Name, t.InputMode)
+		}
+		switch t.ImageStrategy {
+		case models.ImageStrategyFixed, models.ImageStrategyRandom, models.ImageStrategyRoundRobin, models.ImageStrategyWeekday:
+		default:
+			return fmt.Errorf("type %q has unknown image strategy %q", t.Name, t.ImageStrategy)
+		}
+		if t.CounterFormat != "" && !strings.Contains(t.CounterFormat, models.CounterPlaceholder) {
+			return fmt.Errorf("type %q has counter format %q without %s", t.Name, t.CounterFormat, models.CounterPlaceholder)
+		}
+
+		var err error
+		if t.template, t.templateEntities, err = parseConfigMarkup(t.InputMode, t.Template); err != nil {
+			return fmt.Errorf("template of type %q: %v", t.Name, err)
+		}
+		if strings.TrimSpace(t.template) == "" {
+			return fmt.Errorf("template of type %q is empty", t.Name)
+		}
+		if t.header, t.headerEntities, err = parseConfigMarkup(t.InputMode, t.Header); err != nil {
+			return fmt.Errorf("header of type %q: %v", t.Name, err)
+		}
+		if t.footer, t.footerEntities, err = parseConfigMarkup(t.InputMode, t.Footer); err != nil {
+			return fmt.Errorf("footer of type %q: %v", t.Name, err)
+		}
+
+		if t.Image != "" {
+			if t.image, err = loadConfigImage(dir, t.Image); err != nil {
+				return fmt.Errorf("image of type %q: %v", t.Name, err)
+			}
+		}
+		for _, name := range t.Pool {
+			image, err := loadConfigImage(dir, name)
+			if err != nil {
+				return fmt.Errorf("image of type %q: %v", t.Name, err)
+			}
+			t.pool = append(t.pool, *image)
+		}
+
+		for _, role := range t.Roles {
+			ids, ok := roles[role]
+			if !ok {
+				return fmt.Errorf("type %q refers to role %q that no admin has", t.Name, role)
+			}
+			for _, id := range ids {
+				if !slices.Contains(t.allowedAdmins, id) {
+					t.allowedAdmins = append(t.allowedAdmins, id)
+				}
+			}
+		}
+		slices.Sort(t.allowedAdmins)
+	}
+	return nil
+}
+
+// apply copies the type's settings to pt, leaving its ID, images, access
+// and counter position alone.
+func (t *ConfigType) apply(pt *models.PostType) {
+	pt.Name = t.Name
+	pt.Emoji = t.Emoji
+	pt.Category = t.Category
+	pt.Template, pt.TemplateEntities = t.template, t.templateEntities
+	pt.Header, pt.HeaderEntities = t.header, t.headerEntities
+	pt.Footer, pt.FooterEntities = t.footer, t.footerEntities
+	pt.InputMode = t.InputMode
+	pt.PhotoURL = t.PhotoURL
+	pt.ImageStrategy = t.ImageStrategy
+	pt.CounterFormat = t.CounterFormat
+	pt.IsActive = t.Active == nil || *t.Active
+	pt.DeliveryOptions = t.Delivery.String()
+}
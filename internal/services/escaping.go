@@ -29,3 +29,17 @@ func SafeConcat(parts ...string) string {
 	}
 	return sb.String()
 }
+
+// UTF16Length returns the length of s in UTF-16 code units, which is how
+// Telegram measures message entity offsets and lengths.
+func UTF16Length(s string) int {
+	length := 0
+	for _, r := range s {
+		if r <= 0xFFFF {
+			length++
+		} else {
+			length += 2
+		}
+	}
+	return length
+}
@@ -0,0 +1,666 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// MarkupError reports invalid HTML or MarkdownV2 input. Line and Column are
+// 1-based and count characters of the input.
+type MarkupError struct {
+	Line   int
+	Column int
+	Tag    string
+	Reason string
+}
+
+func (e *MarkupError) Error() string {
+	return fmt.Sprintf("строка %d, символ %d: %s", e.Line, e.Column, e.Reason)
+}
+
+func markupError(before, tag, reason string) *MarkupError {
+	line := strings.Count(before, "\n") + 1
+	column := len([]rune(before[strings.LastIndexByte(before, '\n')+1:])) + 1
+	return &MarkupError{Line: line, Column: column, Tag: tag, Reason: reason}
+}
+
+// ParseMarkup converts input in the given mode to text and entities. In
+// the entities mode the input is returned as is.
+func ParseMarkup(mode, input string, entities []tgmodels.MessageEntity) (string, []tgmodels.MessageEntity, error) {
+	switch mode {
+	case models.InputModeHTML:
+		return ParseHTML(input)
+	case models.InputModeMarkdown:
+		return ParseMarkdownV2(input)
+	}
+	return input, entities, nil
+}
+
+// RenderMarkup is the reverse of ParseMarkup.
+func RenderMarkup(mode, text string, entities []tgmodels.MessageEntity) string {
+	switch mode {
+	case models.InputModeHTML:
+		return RenderHTML(text, entities)
+	case models.InputModeMarkdown:
+		return RenderMarkdownV2(text, entities)
+	}
+	return text
+}
+
+type openEntity struct {
+	name   string
+	pos    int // byte offset of the opening tag or marker in the input
+	entity tgmodels.MessageEntity
+	skip   bool
+}
+
+// ParseHTML parses the HTML subset supported by Telegram.
+func ParseHTML(s string) (string, []tgmodels.MessageEntity, error) {
+	var text strings.Builder
+	var entities []tgmodels.MessageEntity
+	var stack []openEntity
+	offset := 0
+
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			j := strings.IndexByte(s[i:], '<')
+			if j < 0 {
+				j = len(s) - i
+			}
+			chunk := html.UnescapeString(s[i : i+j])
+			text.WriteString(chunk)
+			offset += UTF16Length(chunk)
+			i += j
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '>')
+		if end < 0 {
+			return "", nil, markupError(s[:i], "", "символ < нужно записывать как &lt;")
+		}
+		raw := s[i+1 : i+end]
+		closing := strings.HasPrefix(raw, "/")
+		raw = strings.TrimPrefix(raw, "/")
+		name, rest, _ := strings.Cut(strings.TrimSpace(raw), " ")
+		name = strings.ToLower(name)
+		if name == "" || strings.ContainsAny(name, "<\n") {
+			return "", nil, markupError(s[:i], "", "символ < нужно записывать как &lt;")
+		}
+		tag := "<" + name + ">"
+
+		if closing {
+			if len(stack) == 0 {
+				return "", nil, markupError(s[:i], "</"+name+">", fmt.Sprintf("лишний закрывающий тег </%s>", name))
+			}
+			top := stack[len(stack)-1]
+			if top.name != name {
+				return "", nil, markupError(s[:i], "</"+name+">", fmt.Sprintf("ожидался </%s>, а найден </%s>", top.name, name))
+			}
+			stack = stack[:len(stack)-1]
+			if !top.skip && offset > top.entity.Offset {
+				top.entity.Length = offset - top.entity.Offset
+				entities = append(entities, top.entity)
+			}
+			i += end + 1
+			continue
+		}
+
+		attrs := parseHTMLAttrs(rest)
+		open := openEntity{name: name, pos: i, entity: tgmodels.MessageEntity{Offset: offset}}
+		switch name {
+		case "b", "strong":
+			open.entity.Type = tgmodels.MessageEntityTypeBold
+		case "i", "em":
+			open.entity.Type = tgmodels.MessageEntityTypeItalic
+		case "u", "ins":
+			open.entity.Type = tgmodels.MessageEntityTypeUnderline
+		case "s", "strike", "del":
+			open.entity.Type = tgmodels.MessageEntityTypeStrikethrough
+		case "tg-spoiler":
+			open.entity.Type = tgmodels.MessageEntityTypeSpoiler
+		case "span":
+			if attrs["class"] != "tg-spoiler" {
+				return "", nil, markupError(s[:i], tag, "тег <span> поддерживается только с class=\"tg-spoiler\"")
+			}
+			open.entity.Type = tgmodels.MessageEntityTypeSpoiler
+		case "a":
+			href := attrs["href"]
+			if href == "" {
+				return "", nil, markupError(s[:i], tag, "у ссылки <a> нет атрибута href")
+			}
+			open.entity.Type = tgmodels.MessageEntityTypeTextLink
+			open.entity.URL = href
+		case "code":
+			if len(stack) > 0 && stack[len(stack)-1].name == "pre" && stack[len(stack)-1].entity.Offset == offset {
+				pre := &stack[len(stack)-1]
+				pre.entity.Language = strings.TrimPrefix(attrs["class"], "language-")
+				open.skip = true
+			} else {
+				open.entity.Type = tgmodels.MessageEntityTypeCode
+			}
+		case "pre":
+			open.entity.Type = tgmodels.MessageEntityTypePre
+		case "blockquote":
+			open.entity.Type = tgmodels.MessageEntityTypeBlockquote
+			if _, ok := attrs["expandable"]; ok {
+				open.entity.Type = tgmodels.MessageEntityTypeExpandableBlockquote
+			}
+		case "tg-emoji":
+			if attrs["emoji-id"] == "" {
+				return "", nil, markupError(s[:i], tag, "у тега <tg-emoji> нет атрибута emoji-id")
+			}
+			open.entity.Type = tgmodels.MessageEntityTypeCustomEmoji
+			open.entity.CustomEmojiID = attrs["emoji-id"]
+		default:
+			return "", nil, markupError(s[:i], tag, fmt.Sprintf("тег %s не поддерживается", tag))
+		}
+		stack = append(stack, open)
+		i += end + 1
+	}
+
+	if len(stack) > 0 {
+		top := stack[len(stack)-1]
+		return "", nil, markupError(s[:top.pos], "<"+top.name+">", fmt.Sprintf("тег <%s> не закрыт", top.name))
+	}
+
+	sortEntities(entities)
+	return text.String(), entities, nil
+}
+
+// parseHTMLAttrs reads attributes written as key="value", key='value',
+// key=value or a bare key.
+func parseHTMLAttrs(s string) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t\n/")
+		if s == "" {
+			return attrs
+		}
+		end := strings.IndexAny(s, "= \t\n")
+		if end < 0 {
+			attrs[strings.ToLower(s)] = ""
+			return attrs
+		}
+		key := strings.ToLower(s[:end])
+		s = s[end:]
+		if s[0] != '=' {
+			attrs[key] = ""
+			continue
+		}
+		s = s[1:]
+		var value string
+		if s != "" && (s[0] == '"' || s[0] == '\'') {
+			quote := s[0]
+			closeAt := strings.IndexByte(s[1:], quote)
+			if closeAt < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:closeAt+1], s[closeAt+2:]
+			}
+		} else {
+			end = strings.IndexAny(s, " \t\n")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		attrs[key] = html.UnescapeString(value)
+	}
+}
+
+// ParseMarkdownV2 parses Telegram's MarkdownV2. Reserved characters that do
+// not form markup are taken literally instead of being rejected.
+func ParseMarkdownV2(s string) (string, []tgmodels.MessageEntity, error) {
+	runes := []rune(s)
+	var text strings.Builder
+	var entities []tgmodels.MessageEntity
+	var stack []openEntity
+	var quote *openEntity
+	offset := 0
+
+	before := func(i int) string { return string(runes[:i]) }
+	write := func(r rune) {
+		text.WriteRune(r)
+		offset += UTF16Length(string(r))
+	}
+	closeQuote := func(end int) {
+		if end > quote.entity.Offset {
+			quote.entity.Length = end - quote.entity.Offset
+			entities = append(entities, quote.entity)
+		}
+		quote = nil
+	}
+	toggle := func(i int, marker string, entityType tgmodels.MessageEntityType) error {
+		if len(stack) > 0 && stack[len(stack)-1].name == marker {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if offset > top.entity.Offset {
+				top.entity.Length = offset - top.entity.Offset
+				entities = append(entities, top.entity)
+			}
+			return nil
+		}
+		for _, open := range stack {
+			if open.name == marker {
+				top := stack[len(stack)-1]
+				return markupError(before(i), marker, fmt.Sprintf("%s закрывается раньше, чем вложенная разметка %s", marker, top.name))
+			}
+		}
+		stack = append(stack, openEntity{name: marker, pos: i, entity: tgmodels.MessageEntity{Type: entityType, Offset: offset}})
+		return nil
+	}
+	// readUntil returns the position of the unescaped closing sequence and
+	// the unescaped content before it, or -1.
+	readUntil := func(i int, closing string) (int, string) {
+		var content strings.Builder
+		for j := i; j < len(runes); j++ {
+			if runes[j] == '\\' && j+1 < len(runes) {
+				j++
+				content.WriteRune(runes[j])
+				continue
+			}
+			if strings.HasPrefix(string(runes[j:min(j+len(closing), len(runes))]), closing) {
+				return j, content.String()
+			}
+			content.WriteRune(runes[j])
+		}
+		return -1, ""
+	}
+
+	lineStart := true
+	for i := 0; i < len(runes); {
+		if lineStart {
+			lineStart = false
+			expandable := strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), "**>")
+			if runes[i] == '>' || expandable {
+				if quote == nil {
+					quote = &openEntity{name: ">", pos: i, entity: tgmodels.MessageEntity{Type: tgmodels.MessageEntityTypeBlockquote, Offset: offset}}
+					if expandable {
+						quote.entity.Type = tgmodels.MessageEntityTypeExpandableBlockquote
+					}
+				}
+				if expandable {
+					i += 3
+				} else {
+					i++
+				}
+				continue
+			}
+			if quote != nil {
+				// The quote ended on the previous line; drop its line break.
+				closeQuote(offset - 1)
+			}
+		}
+
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 == len(runes) {
+				return "", nil, markupError(before(i), "\\", "текст заканчивается одиночным \\")
+			}
+			write(runes[i+1])
+			i += 2
+		case r == '`' && strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), "```"):
+			end, content := readUntil(i+3, "```")
+			if end < 0 {
+				return "", nil, markupError(before(i), "```", "блок ``` не закрыт")
+			}
+			language := ""
+			if first, rest, ok := strings.Cut(content, "\n"); ok {
+				language, content = strings.TrimSpace(first), rest
+			}
+			if content != "" {
+				entities = append(entities, tgmodels.MessageEntity{Type: tgmodels.MessageEntityTypePre, Offset: offset, Length: UTF16Length(content), Language: language})
+				for _, c := range content {
+					write(c)
+				}
+			}
+			i = end + 3
+		case r == '`':
+			end, content := readUntil(i+1, "`")
+			if end < 0 {
+				return "", nil, markupError(before(i), "`", "код ` не закрыт")
+			}
+			if content != "" {
+				entities = append(entities, tgmodels.MessageEntity{Type: tgmodels.MessageEntityTypeCode, Offset: offset, Length: UTF16Length(content)})
+				for _, c := range content {
+					write(c)
+				}
+			}
+			i = end + 1
+		case r == '*':
+			if err := toggle(i, "*", tgmodels.MessageEntityTypeBold); err != nil {
+				return "", nil, err
+			}
+			i++
+		case r == '_' && i+1 < len(runes) && runes[i+1] == '_' && (len(stack) == 0 || stack[len(stack)-1].name != "_"):
+			if err := toggle(i, "__", tgmodels.MessageEntityTypeUnderline); err != nil {
+				return "", nil, err
+			}
+			i += 2
+		case r == '_':
+			if err := toggle(i, "_", tgmodels.MessageEntityTypeItalic); err != nil {
+				return "", nil, err
+			}
+			i++
+		case r == '~':
+			if err := toggle(i, "~", tgmodels.MessageEntityTypeStrikethrough); err != nil {
+				return "", nil, err
+			}
+			i++
+		case r == '|' && i+1 < len(runes) && runes[i+1] == '|':
+			if quote != nil && quote.entity.Type == tgmodels.MessageEntityTypeExpandableBlockquote && (i+2 == len(runes) || runes[i+2] == '\n') {
+				closeQuote(offset)
+				i += 2
+				continue
+			}
+			if err := toggle(i, "||", tgmodels.MessageEntityTypeSpoiler); err != nil {
+				return "", nil, err
+			}
+			i += 2
+		case r == '[' || (r == '!' && i+1 < len(runes) && runes[i+1] == '['):
+			marker := "["
+			if r == '!' {
+				marker = "!["
+			}
+			stack = append(stack, openEntity{name: marker, pos: i, entity: tgmodels.MessageEntity{Offset: offset}})
+			i += len(marker)
+		case r == ']':
+			if len(stack) == 0 || (stack[len(stack)-1].name != "[" && stack[len(stack)-1].name != "![") {
+				write(r)
+				i++
+				continue
+			}
+			top := stack[len(stack)-1]
+			if i+1 == len(runes) || runes[i+1] != '(' {
+				return "", nil, markupError(before(i), "]", "после ] ожидается адрес ссылки в скобках")
+			}
+			end, url := readUntil(i+2, ")")
+			if end < 0 {
+				return "", nil, markupError(before(i), "(", "адрес ссылки не закрыт скобкой )")
+			}
+			stack = stack[:len(stack)-1]
+			top.entity.Type = tgmodels.MessageEntityTypeTextLink
+			top.entity.URL = url
+			if top.name == "![" {
+				id, ok := strings.CutPrefix(url, "tg://emoji?id=")
+				if !ok {
+					return "", nil, markupError(before(top.pos), "![", "адрес эмодзи должен начинаться с tg://emoji?id=")
+				}
+				top.entity.Type = tgmodels.MessageEntityTypeCustomEmoji
+				top.entity.URL = ""
+				top.entity.CustomEmojiID = id
+			}
+			if offset > top.entity.Offset {
+				top.entity.Length = offset - top.entity.Offset
+				entities = append(entities, top.entity)
+			}
+			i = end + 1
+		case r == '\n':
+			write(r)
+			lineStart = true
+			i++
+		default:
+			write(r)
+			i++
+		}
+	}
+
+	if len(stack) > 0 {
+		top := stack[len(stack)-1]
+		reason := fmt.Sprintf("разметка %s не закрыта", top.name)
+		if top.name == "[" || top.name == "![" {
+			reason = "ссылка [ не закрыта"
+		}
+		return "", nil, markupError(before(top.pos), top.name, reason)
+	}
+	if quote != nil {
+		closeQuote(offset)
+	}
+
+	sortEntities(entities)
+	return text.String(), entities, nil
+}
+
+func sortEntities(entities []tgmodels.MessageEntity) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		return entities[i].Length > entities[j].Length
+	})
+}
+
+// markupStyle describes how RenderHTML and RenderMarkdownV2 write entities.
+// open returns "" for entities the markup cannot express.
+type markupStyle struct {
+	open   func(e tgmodels.MessageEntity) string
+	close  func(e tgmodels.MessageEntity) string
+	escape func(r rune, inCode bool) string
+	// quoteLine is written after each line break inside a blockquote.
+	quoteLine string
+}
+
+// RenderHTML converts text and entities to Telegram HTML.
+func RenderHTML(text string, entities []tgmodels.MessageEntity) string {
+	return renderMarkup(text, entities, markupStyle{
+		open: func(e tgmodels.MessageEntity) string {
+			switch e.Type {
+			case tgmodels.MessageEntityTypeBold:
+				return "<b>"
+			case tgmodels.MessageEntityTypeItalic:
+				return "<i>"
+			case tgmodels.MessageEntityTypeUnderline:
+				return "<u>"
+			case tgmodels.MessageEntityTypeStrikethrough:
+				return "<s>"
+			case tgmodels.MessageEntityTypeSpoiler:
+				return "<tg-spoiler>"
+			case tgmodels.MessageEntityTypeCode:
+				return "<code>"
+			case tgmodels.MessageEntityTypePre:
+				if e.Language != "" {
+					return fmt.Sprintf("<pre><code class=\"language-%s\">", html.EscapeString(e.Language))
+				}
+				return "<pre>"
+			case tgmodels.MessageEntityTypeTextLink:
+				return fmt.Sprintf("<a href=\"%s\">", html.EscapeString(e.URL))
+			case tgmodels.MessageEntityTypeTextMention:
+				if e.User != nil {
+					return fmt.Sprintf("<a href=\"tg://user?id=%d\">", e.User.ID)
+				}
+			case tgmodels.MessageEntityTypeCustomEmoji:
+				return fmt.Sprintf("<tg-emoji emoji-id=\"%s\">", html.EscapeString(e.CustomEmojiID))
+			case tgmodels.MessageEntityTypeBlockquote:
+				return "<blockquote>"
+			case tgmodels.MessageEntityTypeExpandableBlockquote:
+				return "<blockquote expandable>"
+			}
+			return ""
+		},
+		close: func(e tgmodels.MessageEntity) string {
+			switch e.Type {
+			case tgmodels.MessageEntityTypeBold:
+				return "</b>"
+			case tgmodels.MessageEntityTypeItalic:
+				return "</i>"
+			case tgmodels.MessageEntityTypeUnderline:
+				return "</u>"
+			case tgmodels.MessageEntityTypeStrikethrough:
+				return "</s>"
+			case tgmodels.MessageEntityTypeSpoiler:
+				return "</tg-spoiler>"
+			case tgmodels.MessageEntityTypeCode:
+				return "</code>"
+			case tgmodels.MessageEntityTypePre:
+				if e.Language != "" {
+					return "</code></pre>"
+				}
+				return "</pre>"
+			case tgmodels.MessageEntityTypeTextLink, tgmodels.MessageEntityTypeTextMention:
+				return "</a>"
+			case tgmodels.MessageEntityTypeCustomEmoji:
+				return "</tg-emoji>"
+			}
+			return "</blockquote>"
+		},
+		escape: func(r rune, inCode bool) string {
+			return html.EscapeString(string(r))
+		},
+	})
+}
+
+// RenderMarkdownV2 converts text and entities to Telegram MarkdownV2.
+func RenderMarkdownV2(text string, entities []tgmodels.MessageEntity) string {
+	escapeURL := strings.NewReplacer(`\`, `\\`, `)`, `\)`)
+	return renderMarkup(text, entities, markupStyle{
+		open: func(e tgmodels.MessageEntity) string {
+			switch e.Type {
+			case tgmodels.MessageEntityTypeBold:
+				return "*"
+			case tgmodels.MessageEntityTypeItalic:
+				return "_"
+			case tgmodels.MessageEntityTypeUnderline:
+				return "__"
+			case tgmodels.MessageEntityTypeStrikethrough:
+				return "~"
+			case tgmodels.MessageEntityTypeSpoiler:
+				return "||"
+			case tgmodels.MessageEntityTypeCode:
+				return "`"
+			case tgmodels.MessageEntityTypePre:
+				return "```" + e.Language + "\n"
+			case tgmodels.MessageEntityTypeTextLink, tgmodels.MessageEntityTypeTextMention:
+				if e.Type == tgmodels.MessageEntityTypeTextMention && e.User == nil {
+					return ""
+				}
+				return "["
+			case tgmodels.MessageEntityTypeCustomEmoji:
+				return "!["
+			case tgmodels.MessageEntityTypeBlockquote:
+				return ">"
+			case tgmodels.MessageEntityTypeExpandableBlockquote:
+				return "**>"
+			}
+			return ""
+		},
+		close: func(e tgmodels.MessageEntity) string {
+			switch e.Type {
+			case tgmodels.MessageEntityTypeBold:
+				return "*"
+			case tgmodels.MessageEntityTypeItalic:
+				return "_"
+			case tgmodels.MessageEntityTypeUnderline:
+				return "__"
+			case tgmodels.MessageEntityTypeStrikethrough:
+				return "~"
+			case tgmodels.MessageEntityTypeSpoiler, tgmodels.MessageEntityTypeExpandableBlockquote:
+				return "||"
+			case tgmodels.MessageEntityTypeCode:
+				return "`"
+			case tgmodels.MessageEntityTypePre:
+				return "```"
+			case tgmodels.MessageEntityTypeTextLink:
+				return "](" + escapeURL.Replace(e.URL) + ")"
+			case tgmodels.MessageEntityTypeTextMention:
+				return "](tg://user?id=" + strconv.FormatInt(e.User.ID, 10) + ")"
+			case tgmodels.MessageEntityTypeCustomEmoji:
+				return "](tg://emoji?id=" + escapeURL.Replace(e.CustomEmojiID) + ")"
+			}
+			return ""
+		},
+		escape: func(r rune, inCode bool) string {
+			reserved := "_*[]()~`>#+-=|{}.!\\"
+			if inCode {
+				reserved = "`\\"
+			}
+			if strings.ContainsRune(reserved, r) {
+				return "\\" + string(r)
+			}
+			return string(r)
+		},
+		quoteLine: ">",
+	})
+}
+
+func renderMarkup(text string, entities []tgmodels.MessageEntity, style markupStyle) string {
+	var sorted []tgmodels.MessageEntity
+	for _, e := range entities {
+		if e.Length > 0 && style.open(e) != "" {
+			sorted = append(sorted, e)
+		}
+	}
+	sortEntities(sorted)
+
+	var out strings.Builder
+	var stack []tgmodels.MessageEntity
+	next := 0
+	pos := 0
+
+	closeEnded := func() {
+		first := -1
+		for k, e := range stack {
+			if e.Offset+e.Length <= pos {
+				first = k
+				break
+			}
+		}
+		if first < 0 {
+			return
+		}
+		// Entities opened after the ending one are closed with it and
+		// reopened, so the output stays properly nested.
+		var reopen []tgmodels.MessageEntity
+		for k := len(stack) - 1; k >= first; k-- {
+			out.WriteString(style.close(stack[k]))
+			if stack[k].Offset+stack[k].Length > pos {
+				reopen = append([]tgmodels.MessageEntity{stack[k]}, reopen...)
+			}
+		}
+		stack = stack[:first]
+		for _, e := range reopen {
+			out.WriteString(style.open(e))
+			stack = append(stack, e)
+		}
+	}
+	inCode := func() bool {
+		for _, e := range stack {
+			if e.Type == tgmodels.MessageEntityTypeCode || e.Type == tgmodels.MessageEntityTypePre {
+				return true
+			}
+		}
+		return false
+	}
+	inQuote := func() bool {
+		for _, e := range stack {
+			if e.Type == tgmodels.MessageEntityTypeBlockquote || e.Type == tgmodels.MessageEntityTypeExpandableBlockquote {
+				return e.Offset+e.Length > pos
+			}
+		}
+		return false
+	}
+
+	for _, r := range text {
+		closeEnded()
+		for next < len(sorted) && sorted[next].Offset <= pos {
+			out.WriteString(style.open(sorted[next]))
+			stack = append(stack, sorted[next])
+			next++
+		}
+		out.WriteString(style.escape(r, inCode()))
+		pos += UTF16Length(string(r))
+		if r == '\n' && style.quoteLine != "" && inQuote() {
+			out.WriteString(style.quoteLine)
+		}
+	}
+	for k := len(stack) - 1; k >= 0; k-- {
+		out.WriteString(style.close(stack[k]))
+	}
+	return out.String()
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+func TestParseHTML(t *testing.T) {
+	text, entities, err := ParseHTML(`<b>Жирный <i>и курсив</i></b> &lt;3 <a href="https://example.com/?a=1&amp;b=2">ссылка</a>` + "\n" +
+		`<pre><code class="language-go">x := 1</code></pre> <span class="tg-spoiler">тайна</span>`)
+	if err != nil {
+		t.Fatalf("ParseHTML() error = %v", err)
+	}
+	if text != "Жирный и курсив <3 ссылка\nx := 1 тайна" {
+		t.Errorf("ParseHTML() text = %q", text)
+	}
+	want := []tgmodels.MessageEntity{
+		{Type: tgmodels.MessageEntityTypeBold, Offset: 0, Length: 15},
+		{Type: tgmodels.MessageEntityTypeItalic, Offset: 7, Length: 8},
+		{Type: tgmodels.MessageEntityTypeTextLink, Offset: 19, Length: 6, URL: "https://example.com/?a=1&b=2"},
+		{Type: tgmodels.MessageEntityTypePre, Offset: 26, Length: 6, Language: "go"},
+		{Type: tgmodels.MessageEntityTypeSpoiler, Offset: 33, Length: 5},
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Errorf("ParseHTML() entities = %+v, want %+v", entities, want)
+	}
+}
+
+func TestParseHTMLErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+		tag    string
+	}{
+		{"<b>текст", 1, 1, "<b>"},
+		{"<b>a</i>", 1, 5, "</i>"},
+		{"ok\n<p>абзац</p>", 2, 1, "<p>"},
+		{"a < b", 1, 3, ""},
+		{"<a>без адреса</a>", 1, 1, "<a>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, _, err := ParseHTML(tt.input)
+			var markupErr *MarkupError
+			if !errors.As(err, &markupErr) {
+				t.Fatalf("ParseHTML() error = %v, want MarkupError", err)
+			}
+			if markupErr.Line != tt.line || markupErr.Column != tt.column || markupErr.Tag != tt.tag {
+				t.Errorf("ParseHTML() error at %d:%d tag %q, want %d:%d tag %q", markupErr.Line, markupErr.Column, markupErr.Tag, tt.line, tt.column, tt.tag)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownV2(t *testing.T) {
+	text, entities, err := ParseMarkdownV2("*Жирный _и курсив_* 1\\.5 [ссылка](https://example.com/a\\)b) __подчёркнутый__\n>цитата\n>вторая\nпосле `код`")
+	if err != nil {
+		t.Fatalf("ParseMarkdownV2() error = %v", err)
+	}
+	if text != "Жирный и курсив 1.5 ссылка подчёркнутый\nцитата\nвторая\nпосле код" {
+		t.Errorf("ParseMarkdownV2() text = %q", text)
+	}
+	want := []tgmodels.MessageEntity{
+		{Type: tgmodels.MessageEntityTypeBold, Offset: 0, Length: 15},
+		{Type: tgmodels.MessageEntityTypeItalic, Offset: 7, Length: 8},
+		{Type: tgmodels.MessageEntityTypeTextLink, Offset: 20, Length: 6, URL: "https://example.com/a)b"},
+		{Type: tgmodels.MessageEntityTypeUnderline, Offset: 27, Length: 12},
+		{Type: tgmodels.MessageEntityTypeBlockquote, Offset: 40, Length: 13},
+		{Type: tgmodels.MessageEntityTypeCode, Offset: 60, Length: 3},
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Errorf("ParseMarkdownV2() entities = %+v, want %+v", entities, want)
+	}
+}
+
+func TestParseMarkdownV2Errors(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+		tag    string
+	}{
+		{"*жирный", 1, 1, "*"},
+		{"строка\n_a *b_ c*", 2, 6, "_"},
+		{"[ссылка] без адреса", 1, 8, "]"},
+		{"```\nкод", 1, 1, "```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, _, err := ParseMarkdownV2(tt.input)
+			var markupErr *MarkupError
+			if !errors.As(err, &markupErr) {
+				t.Fatalf("ParseMarkdownV2() error = %v, want MarkupError", err)
+			}
+			if markupErr.Line != tt.line || markupErr.Column != tt.column || markupErr.Tag != tt.tag {
+				t.Errorf("ParseMarkdownV2() error at %d:%d tag %q, want %d:%d tag %q", markupErr.Line, markupErr.Column, markupErr.Tag, tt.line, tt.column, tt.tag)
+			}
+		})
+	}
+}
+
+func TestRenderMarkupRoundTrip(t *testing.T) {
+	text := "Жирный и курсив <3 & 1.5 ссылка 🙂\nцитата\nвторая\nx := `1`"
+	entities := []tgmodels.MessageEntity{
+		{Type: tgmodels.MessageEntityTypeBold, Offset: 0, Length: 15},
+		{Type: tgmodels.MessageEntityTypeItalic, Offset: 7, Length: 8},
+		{Type: tgmodels.MessageEntityTypeTextLink, Offset: 25, Length: 9, URL: "https://example.com/?a=(1)"},
+		{Type: tgmodels.MessageEntityTypeBlockquote, Offset: 35, Length: 13},
+		{Type: tgmodels.MessageEntityTypePre, Offset: 49, Length: 8, Language: "go"},
+	}
+
+	for _, mode := range []string{models.InputModeHTML, models.InputModeMarkdown} {
+		t.Run(mode, func(t *testing.T) {
+			source := RenderMarkup(mode, text, entities)
+			gotText, gotEntities, err := ParseMarkup(mode, source, nil)
+			if err != nil {
+				t.Fatalf("ParseMarkup(%q) error = %v", source, err)
+			}
+			if gotText != text || !reflect.DeepEqual(gotEntities, entities) {
+				t.Errorf("round trip of %q = %q %+v", source, gotText, gotEntities)
+			}
+		})
+	}
+}
+
+func TestRenderHTMLOverlapping(t *testing.T) {
+	got := RenderHTML("abcd", []tgmodels.MessageEntity{
+		{Type: tgmodels.MessageEntityTypeBold, Offset: 0, Length: 3},
+		{Type: tgmodels.MessageEntityTypeItalic, Offset: 1, Length: 3},
+	})
+	if want := "<b>a<i>bc</i></b><i>d</i>"; got != want {
+		t.Errorf("RenderHTML() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidMessageLink = errors.New("invalid post link format")
+
+var (
+	messageLinkPattern = regexp.MustCompile(`(?i)(?:https?://)?(?:www\.)?\b(?:t\.me|telegram\.me|telegram\.dog)/\S+|tg://\S+`)
+	usernamePattern    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{3,31}$`)
+)
+
+// MessageLinkTarget is a message referenced by a Telegram link. Links to
+// public chats carry the Username and leave ChatID zero until resolved.
+// CommentID is set for links to a comment under a channel post; the comment
+// lives in the channel's discussion group.
+type MessageLinkTarget struct {
+	ChatID    int64
+	Username  string
+	ThreadID  int64
+	MessageID int64
+	CommentID int64
+}
+
+// ParseMessageLink finds a message link in text and parses it. Supported
+// forms:
+//
+//	t.me/c/<chat>/<message>, t.me/c/<chat>/<topic>/<message>
+//	t.me/<username>/<message>, t.me/<username>/<topic>/<message>
+//	t.me/s/<username>/<message>
+//	tg://privatepost?channel=<chat>&post=<message>
+//	tg://resolve?domain=<username>&post=<message>
+//
+// telegram.me and telegram.dog are accepted as well, and the query
+// parameters thread, topic, comment and single are understood. Topic 1 is
+// the General topic, which the Bot API addresses without a thread ID, so it
+// is returned as 0.
+func ParseMessageLink(text string) (*MessageLinkTarget, error) {
+	raw := messageLinkPattern.FindString(text)
+	if raw == "" {
+		return nil, ErrInvalidMessageLink
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, ErrInvalidMessageLink
+	}
+
+	var target *MessageLinkTarget
+	if strings.EqualFold(u.Scheme, "tg") {
+		target, err = parseTGLink(u)
+	} else {
+		target, err = parseWebLink(u)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+	for _, key := range []string{"thread", "topic"} {
+		if v := query.Get(key); v != "" {
+			if target.ThreadID, err = parseLinkID(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if v := query.Get("comment"); v != "" {
+		if target.CommentID, err = parseLinkID(v); err != nil {
+			return nil, err
+		}
+	}
+	if target.ThreadID == 1 {
+		target.ThreadID = 0
+	}
+	return target, nil
+}
+
+func parseWebLink(u *url.URL) (*MessageLinkTarget, error) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) > 0 && segments[0] == "s" {
+		segments = segments[1:]
+	}
+	if len(segments) < 2 {
+		return nil, ErrInvalidMessageLink
+	}
+
+	target := &MessageLinkTarget{}
+	var ids []string
+	if segments[0] == "c" {
+		ids = segments[1:]
+		if len(ids) != 2 && len(ids) != 3 {
+			return nil, ErrInvalidMessageLink
+		}
+		internalID, err := parseLinkID(ids[0])
+		if err != nil {
+			return nil, err
+		}
+		target.ChatID = internalChatID(internalID)
+		ids = ids[1:]
+	} else {
+		if !usernamePattern.MatchString(segments[0]) {
+			return nil, ErrInvalidMessageLink
+		}
+		target.Username = segments[0]
+		ids = segments[1:]
+		if len(ids) != 1 && len(ids) != 2 {
+			return nil, ErrInvalidMessageLink
+		}
+	}
+
+	var err error
+	if len(ids) == 2 {
+		if target.ThreadID, err = parseLinkID(ids[0]); err != nil {
+			return nil, err
+		}
+	}
+	if target.MessageID, err = parseLinkID(ids[len(ids)-1]); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func parseTGLink(u *url.URL) (*MessageLinkTarget, error) {
+	query := u.Query()
+	target := &MessageLinkTarget{}
+	var err error
+	switch strings.ToLower(u.Host) {
+	case "privatepost":
+		internalID, err := parseLinkID(query.Get("channel"))
+		if err != nil {
+			return nil, err
+		}
+		target.ChatID = internalChatID(internalID)
+	case "resolve":
+		target.Username = query.Get("domain")
+		if !usernamePattern.MatchString(target.Username) {
+			return nil, ErrInvalidMessageLink
+		}
+	default:
+		return nil, ErrInvalidMessageLink
+	}
+	if target.MessageID, err = parseLinkID(query.Get("post")); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func parseLinkID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidMessageLink
+	}
+	return id, nil
+}
+
+// internalChatID converts the chat ID used in t.me/c links to the Bot API
+// chat ID of the supergroup or channel.
+func internalChatID(id int64) int64 {
+	return -1000000000000 - id
+}
+
+// PublicMessageLink builds a t.me link to a message in a public chat.
+func PublicMessageLink(username string, threadID, messageID int64) string {
+	if threadID > 0 {
+		return fmt.Sprintf("https://t.me/%s/%d/%d", username, threadID, messageID)
+	}
+	return fmt.Sprintf("https://t.me/%s/%d", username, messageID)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseMessageLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want MessageLinkTarget
+	}{
+		{"https://t.me/c/1234567890/42", MessageLinkTarget{ChatID: -1001234567890, MessageID: 42}},
+		{"t.me/c/1234567890/7/42/", MessageLinkTarget{ChatID: -1001234567890, ThreadID: 7, MessageID: 42}},
+		{"https://t.me/c/1234567890/1/42", MessageLinkTarget{ChatID: -1001234567890, MessageID: 42}},
+		{"https://t.me/c/1234567890/42?thread=7", MessageLinkTarget{ChatID: -1001234567890, ThreadID: 7, MessageID: 42}},
+		{"https://t.me/c/1234567890/42?single", MessageLinkTarget{ChatID: -1001234567890, MessageID: 42}},
+		{"Вот пост: https://telegram.me/Test_Channel/42 — посмотрите", MessageLinkTarget{Username: "Test_Channel", MessageID: 42}},
+		{"https://t.me/forumchat/7/42", MessageLinkTarget{Username: "forumchat", ThreadID: 7, MessageID: 42}},
+		{"https://t.me/s/news_channel/42", MessageLinkTarget{Username: "news_channel", MessageID: 42}},
+		{"https://t.me/news_channel/42?comment=100", MessageLinkTarget{Username: "news_channel", MessageID: 42, CommentID: 100}},
+		{"tg://privatepost?channel=1234567890&post=42&thread=7", MessageLinkTarget{ChatID: -1001234567890, ThreadID: 7, MessageID: 42}},
+		{"tg://resolve?domain=news_channel&post=42&comment=100", MessageLinkTarget{Username: "news_channel", MessageID: 42, CommentID: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			got, err := ParseMessageLink(tt.link)
+			if err != nil {
+				t.Fatalf("ParseMessageLink() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseMessageLink() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMessageLinkInvalid(t *testing.T) {
+	for _, link := range []string{
+		"",
+		"https://t.me/",
+		"https://t.me/channel",
+		"https://t.me/ab/42",
+		"https://t.me/c/123/abc",
+		"https://t.me/c/123/1/2/3",
+		"https://t.me/joinchat/AAAA",
+		"https://notat.me/channel/42",
+		"tg://privatepost?channel=123",
+		"tg://user?id=123",
+		"https://t.me/c/123/42?comment=x",
+	} {
+		if _, err := ParseMessageLink(link); !errors.Is(err, ErrInvalidMessageLink) {
+			t.Errorf("ParseMessageLink(%q) error = %v, want ErrInvalidMessageLink", link, err)
+		}
+	}
+}
+
+func TestPublicMessageLink(t *testing.T) {
+	if got := PublicMessageLink("forumchat", 7, 42); got != "https://t.me/forumchat/7/42" {
+		t.Errorf("PublicMessageLink() = %q", got)
+	}
+	if got := PublicMessageLink("news", 0, 42); got != "https://t.me/news/42" {
+		t.Errorf("PublicMessageLink() = %q", got)
+	}
+}
+
+func TestChatResolverWithoutBot(t *testing.T) {
+	r := NewChatResolver(nil, time.Hour)
+	ctx := context.Background()
+
+	target, err := r.ResolveLink(ctx, "https://t.me/c/1234567890/42")
+	if err != nil || target.ChatID != -1001234567890 || target.MessageID != 42 {
+		t.Errorf("ResolveLink() = %+v, %v", target, err)
+	}
+	if _, err := r.ResolveLink(ctx, "https://t.me/news_channel/42"); err == nil {
+		t.Error("Expected username link to fail without a bot")
+	}
+
+	if got := r.Permalink(ctx, -1001234567890, 7, 42); got != "https://t.me/c/1234567890/7/42" {
+		t.Errorf("Permalink() = %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// frameSeparator divides the header, the body and the footer of a post.
+const frameSeparator = "\n\n"
+
+// NumberPlaceholder is replaced with the post's number in its text, header
+// and footer when the post is published.
+const NumberPlaceholder = "{number}"
+
+func parseEntities(s string) []tgmodels.MessageEntity {
+	var entities []tgmodels.MessageEntity
+	if s != "" {
+		json.Unmarshal([]byte(s), &entities)
+	}
+	return entities
+}
+
+// joinText appends b to a with sep in between, moving b's entities by the
+// UTF-16 length of what precedes them.
+func joinText(a string, aEntities []tgmodels.MessageEntity, sep, b string, bEntities []tgmodels.MessageEntity) (string, []tgmodels.MessageEntity) {
+	if b == "" {
+		return a, aEntities
+	}
+	if a == "" {
+		return b, bEntities
+	}
+	offset := UTF16Length(a + sep)
+	entities := make([]tgmodels.MessageEntity, 0, len(aEntities)+len(bEntities))
+	entities = append(entities, aEntities...)
+	for _, e := range bEntities {
+		e.Offset += offset
+		entities = append(entities, e)
+	}
+	return a + sep + b, entities
+}
+
+// NewPostFrame builds the frame of a new post from its type and the
+// author's signature, which may be nil. The signature goes on the line
+// below the type's footer.
+func NewPostFrame(pt *models.PostType, signature *models.AdminSignature) models.PostFrame {
+	frame := models.PostFrame{Header: pt.Header, HeaderEntities: pt.HeaderEntities}
+	footer, entities := pt.Footer, parseEntities(pt.FooterEntities)
+	if signature != nil {
+		footer, entities = joinText(footer, entities, "\n", signature.Text, parseEntities(signature.Entities))
+	}
+	frame.Footer = footer
+	frame.FooterEntities = entitiesJSON(entities)
+	return frame
+}
+
+func entitiesJSON(entities []tgmodels.MessageEntity) string {
+	if len(entities) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(entities)
+	return string(data)
+}
+
+// ComposePost puts the body of a post between the frame's header and footer.
+func ComposePost(frame models.PostFrame, text string, entities []tgmodels.MessageEntity) (string, []tgmodels.MessageEntity) {
+	text, entities = joinText(frame.Header, parseEntities(frame.HeaderEntities), frameSeparator, text, entities)
+	return joinText(text, entities, frameSeparator, frame.Footer, parseEntities(frame.FooterEntities))
+}
+
+// FillPostNumber puts the formatted number of a post in place of
+// NumberPlaceholder in its body and frame.
+func FillPostNumber(frame models.PostFrame, text string, entities []tgmodels.MessageEntity, number string) (models.PostFrame, string, []tgmodels.MessageEntity) {
+	values := map[string]string{NumberPlaceholder[1 : len(NumberPlaceholder)-1]: number}
+	text, entities = RenderPlaceholders(text, entities, values)
+
+	header, headerEntities := RenderPlaceholders(frame.Header, parseEntities(frame.HeaderEntities), values)
+	footer, footerEntities := RenderPlaceholders(frame.Footer, parseEntities(frame.FooterEntities), values)
+	frame = models.PostFrame{
+		Header:         header,
+		HeaderEntities: entitiesJSON(headerEntities),
+		Footer:         footer,
+		FooterEntities: entitiesJSON(footerEntities),
+	}
+	return frame, text, entities
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"unicode/utf16"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+func TestComposePost(t *testing.T) {
+	pt := &models.PostType{
+		Header:         "📢 Анонс",
+		HeaderEntities: `[{"type":"bold","offset":0,"length":8}]`,
+		Footer:         "#анонс",
+		FooterEntities: `[{"type":"hashtag","offset":0,"length":6}]`,
+	}
+	signature := &models.AdminSignature{Text: "— Анна", Entities: `[{"type":"italic","offset":2,"length":4}]`}
+	frame := NewPostFrame(pt, signature)
+	if frame.Footer != "#анонс\n— Анна" {
+		t.Fatalf("Footer = %q", frame.Footer)
+	}
+
+	body := "Встреча 😀 в 19:00"
+	text, entities := ComposePost(frame, body, []tgmodels.MessageEntity{{Type: tgmodels.MessageEntityTypeUnderline, Offset: 8, Length: 2}})
+	if want := "📢 Анонс\n\nВстреча 😀 в 19:00\n\n#анонс\n— Анна"; text != want {
+		t.Fatalf("ComposePost() text = %q, want %q", text, want)
+	}
+
+	// The emoji takes two UTF-16 units, so the footer starts after 8+2+18+2.
+	want := []tgmodels.MessageEntity{
+		{Type: tgmodels.MessageEntityTypeBold, Offset: 0, Length: 8},
+		{Type: tgmodels.MessageEntityTypeUnderline, Offset: 18, Length: 2},
+		{Type: tgmodels.MessageEntityTypeHashtag, Offset: 30, Length: 6},
+		{Type: tgmodels.MessageEntityTypeItalic, Offset: 39, Length: 4},
+	}
+	if len(entities) != len(want) {
+		t.Fatalf("ComposePost() entities = %+v", entities)
+	}
+	for i, e := range entities {
+		if e.Type != want[i].Type || e.Offset != want[i].Offset || e.Length != want[i].Length {
+			t.Errorf("entity %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+	units := utf16.Encode([]rune(text))
+	for i, covered := range []string{"📢 Анонс", "😀", "#анонс", "Анна"} {
+		e := entities[i]
+		if got := string(utf16.Decode(units[e.Offset : e.Offset+e.Length])); got != covered {
+			t.Errorf("entity %d covers %q, want %q", i, got, covered)
+		}
+	}
+
+	if text, entities := ComposePost(models.PostFrame{}, body, nil); text != body || len(entities) != 0 {
+		t.Errorf("ComposePost() without frame = %q, %+v", text, entities)
+	}
+	if frame := NewPostFrame(&models.PostType{}, nil); frame != (models.PostFrame{}) {
+		t.Errorf("NewPostFrame() of a plain type = %+v", frame)
+	}
+}
+
+func TestFillPostNumber(t *testing.T) {
+	frame := models.PostFrame{
+		Header:         "Вакансия {number}",
+		HeaderEntities: `[{"type":"bold","offset":0,"length":17}]`,
+		Footer:         "#вакансия",
+	}
+	body := "Ищем {number}-го дизайнера"
+	frame, text, entities := FillPostNumber(frame, body, []tgmodels.MessageEntity{{Type: tgmodels.MessageEntityTypeItalic, Offset: 17, Length: 9}}, "№154")
+
+	if frame.Header != "Вакансия №154" || frame.HeaderEntities != `[{"type":"bold","offset":0,"length":13}]` {
+		t.Errorf("Header = %q %s", frame.Header, frame.HeaderEntities)
+	}
+	if frame.Footer != "#вакансия" || frame.FooterEntities != "" {
+		t.Errorf("Footer = %q %s", frame.Footer, frame.FooterEntities)
+	}
+	if text != "Ищем №154-го дизайнера" || len(entities) != 1 || entities[0].Offset != 13 || entities[0].Length != 9 {
+		t.Errorf("FillPostNumber() = %q %+v", text, entities)
+	}
+}
@@ -3,8 +3,6 @@ package services
 import (
 	"context"
 	"fmt"
-	"regexp"
-	"strconv"
 
 	"github.com/ad/go-telegram-admin/internal/db"
 	"github.com/ad/go-telegram-admin/internal/models"
@@ -65,128 +63,49 @@ func (pm *PostManager) DeletePost(ctx context.Context, postID int64) error {
 	return pm.postRepo.Delete(postID)
 }
 
-func (pm *PostManager) ParsePostLink(link string) (chatID, messageID int64, err error) {
-	privateChannelWithTopicPattern := `(?:t\.me|telegram\.me)/c/(\d+)/\d+/(\d+)`
-	re := regexp.MustCompile(privateChannelWithTopicPattern)
-	matches := re.FindStringSubmatch(link)
-
-	if len(matches) == 3 {
-		chatIDStr := matches[1]
-		messageIDStr := matches[2]
-
-		if chatID, err = strconv.ParseInt(chatIDStr, 10, 64); err == nil {
-			if messageID, err = strconv.ParseInt(messageIDStr, 10, 64); err == nil {
-				if chatID > 0 {
-					chatID = -1000000000000 - chatID
-				}
-				return chatID, messageID, nil
-			}
-		}
+// MessageLink builds a t.me link to a message in a supergroup, pointing into
+// the topic when threadID is set. Basic groups have no message links, so an
+// empty string is returned for them.
+func MessageLink(chatID, threadID, messageID int64) string {
+	internalID := -chatID - 1000000000000
+	if internalID <= 0 {
+		return ""
 	}
-
-	privateChannelPattern := `(?:t\.me|telegram\.me)/c/(\d+)/(\d+)`
-	re = regexp.MustCompile(privateChannelPattern)
-	matches = re.FindStringSubmatch(link)
-
-	if len(matches) == 3 {
-		chatIDStr := matches[1]
-		messageIDStr := matches[2]
-
-		if chatID, err = strconv.ParseInt(chatIDStr, 10, 64); err == nil {
-			if messageID, err = strconv.ParseInt(messageIDStr, 10, 64); err == nil {
-				if chatID > 0 {
-					chatID = -1000000000000 - chatID
-				}
-				return chatID, messageID, nil
-			}
-		}
+	if threadID > 0 {
+		return fmt.Sprintf("https://t.me/c/%d/%d/%d", internalID, threadID, messageID)
 	}
+	return fmt.Sprintf("https://t.me/c/%d/%d", internalID, messageID)
+}
 
-	publicChannelPattern := `(?:t\.me|telegram\.me)/([^/]+)/(\d+)`
-	re = regexp.MustCompile(publicChannelPattern)
-	matches = re.FindStringSubmatch(link)
-
-	if len(matches) == 3 {
-		messageIDStr := matches[2]
-		if messageID, err = strconv.ParseInt(messageIDStr, 10, 64); err == nil {
-			return 0, messageID, nil
-		}
+// ParsePostLink parses a message link. For links to public chats the chat
+// ID is 0; ChatResolver.ResolveLink looks it up by username.
+func (pm *PostManager) ParsePostLink(link string) (chatID, messageID int64, err error) {
+	target, err := ParseMessageLink(link)
+	if err != nil {
+		return 0, 0, err
 	}
-
-	return 0, 0, fmt.Errorf("invalid post link format")
+	return target.ChatID, target.MessageID, nil
 }
 
 // ParsePostLinkFull parses a Telegram message link and returns chatID, messageID and threadID.
 // threadID is non-zero when the link points to a message inside a forum topic.
 func (pm *PostManager) ParsePostLinkFull(link string) (chatID, messageID, threadID int64, err error) {
-	// Private channel with topic: t.me/c/{chatID}/{topicID}/{messageID}
-	privateChannelWithTopicPattern := `(?:t\.me|telegram\.me)/c/(\d+)/(\d+)/(\d+)`
-	re := regexp.MustCompile(privateChannelWithTopicPattern)
-	matches := re.FindStringSubmatch(link)
-
-	if len(matches) == 4 {
-		var cid, tid, mid int64
-		if cid, err = strconv.ParseInt(matches[1], 10, 64); err == nil {
-			if tid, err = strconv.ParseInt(matches[2], 10, 64); err == nil {
-				if mid, err = strconv.ParseInt(matches[3], 10, 64); err == nil {
-					if cid > 0 {
-						cid = -1000000000000 - cid
-					}
-					// topic=1 is the General topic; Bot API expects no MessageThreadID for it
-					if tid == 1 {
-						tid = 0
-					}
-					return cid, mid, tid, nil
-				}
-			}
-		}
-	}
-
-	// Private channel without topic: t.me/c/{chatID}/{messageID}
-	privateChannelPattern := `(?:t\.me|telegram\.me)/c/(\d+)/(\d+)`
-	re = regexp.MustCompile(privateChannelPattern)
-	matches = re.FindStringSubmatch(link)
-
-	if len(matches) == 3 {
-		var cid, mid int64
-		if cid, err = strconv.ParseInt(matches[1], 10, 64); err == nil {
-			if mid, err = strconv.ParseInt(matches[2], 10, 64); err == nil {
-				if cid > 0 {
-					cid = -1000000000000 - cid
-				}
-				return cid, mid, 0, nil
-			}
-		}
-	}
-
-	// Public channel: t.me/{username}/{messageID}
-	publicChannelPattern := `(?:t\.me|telegram\.me)/([^/]+)/(\d+)`
-	re = regexp.MustCompile(publicChannelPattern)
-	matches = re.FindStringSubmatch(link)
-
-	if len(matches) == 3 {
-		var mid int64
-		if mid, err = strconv.ParseInt(matches[2], 10, 64); err == nil {
-			return 0, mid, 0, nil
-		}
+	target, err := ParseMessageLink(link)
+	if err != nil {
+		return 0, 0, 0, err
 	}
-
-	return 0, 0, 0, fmt.Errorf("invalid post link format")
+	return target.ChatID, target.MessageID, target.ThreadID, nil
 }
 
+// GetPostByLink finds a published post by a t.me/c link. Links to public
+// chats have to be resolved with ChatResolver first.
 func (pm *PostManager) GetPostByLink(link string) (*models.PublishedPost, error) {
-	chatID, messageID, err := pm.ParsePostLink(link)
+	target, err := ParseMessageLink(link)
 	if err != nil {
 		return nil, err
 	}
-
-	if chatID == 0 {
-		config, err := pm.configRepo.Get()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get config: %w", err)
-		}
-		chatID = config.ForumChatID
+	if target.Username != "" {
+		return nil, fmt.Errorf("link to @%s is not resolved", target.Username)
 	}
-
-	return pm.postRepo.GetByMessageID(chatID, messageID)
+	return pm.postRepo.GetByMessageID(target.ChatID, target.MessageID)
 }
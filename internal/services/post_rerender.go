@@ -0,0 +1,40 @@
+package services
+
+import (
+	"slices"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+// PostRerender is what changes in a published post when it is rendered
+// with the current version of its type.
+type PostRerender struct {
+	// PhotoID is the type's new image, empty when the image stays.
+	PhotoID      string
+	Frame        string
+	FrameChanged bool
+}
+
+func (r PostRerender) Changed() bool {
+	return r.PhotoID != "" || r.FrameChanged
+}
+
+// RerenderPost works out how the post looks with the type's current image
+// and frame. Only images the type had in earlier versions, oldPhotos, are
+// replaced, so images picked from the pool stay; a post without an image
+// gets none, since Telegram cannot add a photo to a text message. The body
+// is the admin's own text and is kept as is.
+func RerenderPost(post *models.PublishedPost, pt *models.PostType, signature *models.AdminSignature, oldPhotos []string) PostRerender {
+	var r PostRerender
+	if post.PhotoID != "" && pt.PhotoID != "" && post.PhotoID != pt.PhotoID && slices.Contains(oldPhotos, post.PhotoID) {
+		r.PhotoID = pt.PhotoID
+	}
+
+	frame := NewPostFrame(pt, signature)
+	if pt.HasCounter() && post.Number > 0 {
+		frame, _, _ = FillPostNumber(frame, "", nil, pt.FormatNumber(post.Number))
+	}
+	r.Frame = frame.String()
+	r.FrameChanged = r.Frame != post.Frame
+	return r
+}
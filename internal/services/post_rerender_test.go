@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestRerenderPost(t *testing.T) {
+	pt := &models.PostType{PhotoID: "photo-2", Header: "Вакансия {number}", CounterFormat: "№{n}"}
+	oldPhotos := []string{"photo-1", "photo-2"}
+
+	published := &models.PublishedPost{PhotoID: "photo-1", Number: 7, Frame: models.PostFrame{Header: "Вакансия №7"}.String()}
+	if r := RerenderPost(published, pt, nil, oldPhotos); r.PhotoID != "photo-2" || r.FrameChanged {
+		t.Errorf("RerenderPost() with the old image = %+v, want the new image and the same frame", r)
+	}
+
+	pooled := &models.PublishedPost{PhotoID: "pool-1", Number: 8}
+	r := RerenderPost(pooled, pt, &models.AdminSignature{Text: "— Анна"}, oldPhotos)
+	if r.PhotoID != "" || !r.FrameChanged {
+		t.Fatalf("RerenderPost() with a pool image = %+v, want the frame only", r)
+	}
+	if frame := models.ParsePostFrame(r.Frame); frame.Header != "Вакансия №8" || frame.Footer != "— Анна" {
+		t.Errorf("Frame = %+v", frame)
+	}
+
+	text := &models.PublishedPost{Number: 7, Frame: published.Frame}
+	if r := RerenderPost(text, pt, nil, oldPhotos); r.Changed() {
+		t.Errorf("RerenderPost() of an unchanged text post = %+v", r)
+	}
+}
@@ -86,6 +86,8 @@ func (ptm *PostTypeManager) SetTypeActive(id int64, active bool) error {
 	return ptm.repo.SetActive(id, active)
 }
 
+// DeleteType deletes a type, moving its posts to the archive type.
 func (ptm *PostTypeManager) DeleteType(id int64) error {
-	return ptm.repo.Delete(id)
+	_, err := ptm.repo.DeleteMovingPosts(id, 0)
+	return err
 }
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"strings"
+
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// ReplyPlaceholders lists the placeholders supported in reply templates with
+// their descriptions for the admin UI.
+var ReplyPlaceholders = []struct {
+	Key         string
+	Description string
+}{
+	{"{name}", "имя автора сообщения, на которое отвечаем"},
+	{"{first_name}", "только имя автора"},
+	{"{username}", "@username автора"},
+	{"{admin}", "ваше имя"},
+	{"{date}", "сегодняшняя дата"},
+}
+
+type placeholderReplacement struct {
+	start  int
+	oldLen int
+	newLen int
+}
+
+// RenderPlaceholders substitutes {key} placeholders in text with values and
+// shifts entity offsets so formatting stays on the same words. Offsets are
+// in UTF-16 code units like Telegram's. Placeholders without a value are
+// left untouched so the admin can see and fix them before sending.
+func RenderPlaceholders(text string, entities []tgmodels.MessageEntity, values map[string]string) (string, []tgmodels.MessageEntity) {
+	var sb strings.Builder
+	var replacements []placeholderReplacement
+
+	pos := 0
+	rest := text
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			break
+		}
+		closeIdx := strings.IndexByte(rest[open:], '}')
+		if closeIdx < 0 {
+			break
+		}
+		closeIdx += open
+
+		key := rest[open+1 : closeIdx]
+		value, ok := values[key]
+		if !ok || value == "" {
+			sb.WriteString(rest[:open+1])
+			pos += UTF16Length(rest[:open+1])
+			rest = rest[open+1:]
+			continue
+		}
+
+		sb.WriteString(rest[:open])
+		pos += UTF16Length(rest[:open])
+		placeholder := rest[open : closeIdx+1]
+		replacements = append(replacements, placeholderReplacement{
+			start:  pos,
+			oldLen: UTF16Length(placeholder),
+			newLen: UTF16Length(value),
+		})
+		sb.WriteString(value)
+		pos += UTF16Length(placeholder)
+		rest = rest[closeIdx+1:]
+	}
+	sb.WriteString(rest)
+
+	if len(entities) == 0 || len(replacements) == 0 {
+		return sb.String(), entities
+	}
+
+	// mapPos translates an offset in the original text to the rendered text.
+	// Offsets inside a placeholder snap to the start or end of its value.
+	mapPos := func(p int, isEnd bool) int {
+		shift := 0
+		for _, r := range replacements {
+			switch {
+			case p <= r.start:
+				return p + shift
+			case p >= r.start+r.oldLen:
+				shift += r.newLen - r.oldLen
+			default:
+				if isEnd {
+					return r.start + shift + r.newLen
+				}
+				return r.start + shift
+			}
+		}
+		return p + shift
+	}
+
+	rendered := make([]tgmodels.MessageEntity, 0, len(entities))
+	for _, e := range entities {
+		start := mapPos(e.Offset, false)
+		end := mapPos(e.Offset+e.Length, true)
+		if end <= start {
+			continue
+		}
+		e.Offset = start
+		e.Length = end - start
+		rendered = append(rendered, e)
+	}
+	return sb.String(), rendered
+}
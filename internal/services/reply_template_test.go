@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+func TestRenderPlaceholdersShiftsEntities(t *testing.T) {
+	text := "Привет, {name}! Смотрите правила 📌 тут"
+	entities := []tgmodels.MessageEntity{
+		{Type: tgmodels.MessageEntityTypeBold, Offset: 0, Length: UTF16Length("Привет, {name}")},
+		{Type: tgmodels.MessageEntityTypeItalic, Offset: UTF16Length("Привет, {name}! Смотрите "), Length: UTF16Length("правила")},
+	}
+
+	got, gotEntities := RenderPlaceholders(text, entities, map[string]string{"name": "😀 Алиса"})
+
+	if got != "Привет, 😀 Алиса! Смотрите правила 📌 тут" {
+		t.Fatalf("Unexpected text %q", got)
+	}
+	if gotEntities[0].Offset != 0 || gotEntities[0].Length != UTF16Length("Привет, 😀 Алиса") {
+		t.Errorf("Expected the bold entity to grow with the name, got %+v", gotEntities[0])
+	}
+	if gotEntities[1].Offset != UTF16Length("Привет, 😀 Алиса! Смотрите ") || gotEntities[1].Length != UTF16Length("правила") {
+		t.Errorf("Expected the italic entity to shift, got %+v", gotEntities[1])
+	}
+}
+
+func TestRenderPlaceholdersKeepsUnknownAndEmpty(t *testing.T) {
+	text := "{name}, {unknown} {username} {"
+	got, _ := RenderPlaceholders(text, nil, map[string]string{"name": "Боб", "username": ""})
+	if got != "Боб, {unknown} {username} {" {
+		t.Errorf("Unexpected text %q", got)
+	}
+}
+
+func TestRenderPlaceholdersEntityInsidePlaceholder(t *testing.T) {
+	text := "Hi {admin}"
+	entities := []tgmodels.MessageEntity{
+		{Type: tgmodels.MessageEntityTypeCode, Offset: 4, Length: 5},
+	}
+	got, gotEntities := RenderPlaceholders(text, entities, map[string]string{"admin": "Ann"})
+	if got != "Hi Ann" {
+		t.Fatalf("Unexpected text %q", got)
+	}
+	if len(gotEntities) != 1 || gotEntities[0].Offset != 3 || gotEntities[0].Length != 3 {
+		t.Errorf("Expected the entity to cover the value, got %+v", gotEntities)
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// SupportInbox keeps private messages from users as tickets. Messages are
+// delivered to chatID when it is set, otherwise to every admin privately.
+type SupportInbox struct {
+	ticketRepo *db.TicketRepository
+	chatID     int64
+}
+
+func NewSupportInbox(ticketRepo *db.TicketRepository, chatID int64) *SupportInbox {
+	return &SupportInbox{
+		ticketRepo: ticketRepo,
+		chatID:     chatID,
+	}
+}
+
+func (s *SupportInbox) ChatID() int64 {
+	return s.chatID
+}
+
+// TicketContent extracts what is stored for a ticket message. ok is false
+// for content that cannot be sent back with SendMessage, SendPhoto or
+// SendDocument; such messages are stored with a text label.
+func TicketContent(msg *tgmodels.Message) (mediaType, text, fileID string, ok bool) {
+	switch {
+	case len(msg.Photo) > 0:
+		return models.TicketMediaPhoto, msg.Caption, msg.Photo[len(msg.Photo)-1].FileID, true
+	case msg.Document != nil:
+		return models.TicketMediaDocument, msg.Caption, msg.Document.FileID, true
+	case msg.Text != "":
+		return models.TicketMediaText, msg.Text, "", true
+	default:
+		return models.TicketMediaText, CommentText(msg), "", false
+	}
+}
+
+// Receive stores a user's message in their open ticket, opening a new
+// ticket when there is none.
+func (s *SupportInbox) Receive(msg *tgmodels.Message) (*models.Ticket, bool, error) {
+	isNew := false
+	ticket, err := s.ticketRepo.GetOpenByUser(msg.From.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		ticket = &models.Ticket{UserID: msg.From.ID, Status: models.TicketStatusOpen}
+		if err := s.ticketRepo.Create(ticket); err != nil {
+			return nil, false, fmt.Errorf("failed to create ticket: %w", err)
+		}
+		isNew = true
+	} else if err != nil {
+		return nil, false, fmt.Errorf("failed to get open ticket: %w", err)
+	}
+
+	mediaType, text, fileID, _ := TicketContent(msg)
+	if err := s.ticketRepo.AddMessage(&models.TicketMessage{
+		TicketID:  ticket.ID,
+		Direction: models.TicketDirectionIn,
+		MediaType: mediaType,
+		Text:      text,
+		FileID:    fileID,
+	}); err != nil {
+		return nil, false, fmt.Errorf("failed to save ticket message: %w", err)
+	}
+	return ticket, isNew, nil
+}
+
+func (s *SupportInbox) LinkMessage(chatID, messageID, ticketID int64) error {
+	return s.ticketRepo.LinkMessage(chatID, messageID, ticketID)
+}
+
+// TicketForMessage returns the ticket an admin-side message belongs to.
+func (s *SupportInbox) TicketForMessage(chatID, messageID int64) (*models.Ticket, error) {
+	ticketID, err := s.ticketRepo.GetLinkedTicketID(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ticketRepo.GetByID(ticketID)
+}
+
+// RecordAnswer stores an admin's answer. An unassigned ticket is assigned to
+// the admin who answered first.
+func (s *SupportInbox) RecordAnswer(ticket *models.Ticket, adminID int64, mediaType, text, fileID string) error {
+	if err := s.ticketRepo.AddMessage(&models.TicketMessage{
+		TicketID:  ticket.ID,
+		Direction: models.TicketDirectionOut,
+		AdminID:   adminID,
+		MediaType: mediaType,
+		Text:      text,
+		FileID:    fileID,
+	}); err != nil {
+		return fmt.Errorf("failed to save answer: %w", err)
+	}
+	if ticket.AssignedTo == 0 {
+		if err := s.ticketRepo.Assign(ticket.ID, adminID); err != nil {
+			return fmt.Errorf("failed to assign ticket: %w", err)
+		}
+		ticket.AssignedTo = adminID
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+	_ "modernc.org/sqlite"
+)
+
+func TestSupportInbox(t *testing.T) {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer testDB.Close()
+
+	if err := db.InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+
+	ticketRepo := db.NewTicketRepository(db.NewDBQueueForTest(testDB))
+	inbox := NewSupportInbox(ticketRepo, 0)
+
+	const userID, adminID, adminChatID = int64(2), int64(1), int64(1)
+	message := func(text string) *tgmodels.Message {
+		return &tgmodels.Message{
+			From: &tgmodels.User{ID: userID},
+			Chat: tgmodels.Chat{ID: userID, Type: tgmodels.ChatTypePrivate},
+			Text: text,
+		}
+	}
+
+	ticket, isNew, err := inbox.Receive(message("Помогите"))
+	if err != nil || !isNew {
+		t.Fatalf("Expected new ticket, got isNew=%v err=%v", isNew, err)
+	}
+	again, isNew, err := inbox.Receive(message("Ещё вопрос"))
+	if err != nil || isNew || again.ID != ticket.ID {
+		t.Fatalf("Expected open ticket %d to be reused, got %+v isNew=%v err=%v", ticket.ID, again, isNew, err)
+	}
+
+	if err := inbox.LinkMessage(adminChatID, 500, ticket.ID); err != nil {
+		t.Fatal(err)
+	}
+	linked, err := inbox.TicketForMessage(adminChatID, 500)
+	if err != nil || linked.ID != ticket.ID {
+		t.Fatalf("Expected linked ticket %d, got %+v err=%v", ticket.ID, linked, err)
+	}
+	if _, err := inbox.TicketForMessage(adminChatID, 501); err == nil {
+		t.Error("Expected error for unlinked message")
+	}
+
+	if err := inbox.RecordAnswer(linked, adminID, models.TicketMediaPhoto, "Ответ", "photo-id"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ticketRepo.GetByID(ticket.ID)
+	if err != nil || stored.AssignedTo != adminID {
+		t.Fatalf("Expected ticket assigned to %d, got %+v err=%v", adminID, stored, err)
+	}
+
+	messages, err := ticketRepo.GetMessages(ticket.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0].Text != "Ещё вопрос" || messages[1].Direction != models.TicketDirectionOut {
+		t.Fatalf("Expected last two messages in order, got %+v", messages)
+	}
+
+	if err := ticketRepo.SetStatus(ticket.ID, models.TicketStatusClosed); err != nil {
+		t.Fatal(err)
+	}
+	reopened, isNew, err := inbox.Receive(message("Снова я"))
+	if err != nil || !isNew || reopened.ID == ticket.ID {
+		t.Fatalf("Expected a new ticket after closing, got %+v isNew=%v err=%v", reopened, isNew, err)
+	}
+}
+
+func TestTicketContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       *tgmodels.Message
+		mediaType string
+		fileID    string
+		ok        bool
+	}{
+		{"text", &tgmodels.Message{Text: "hi"}, models.TicketMediaText, "", true},
+		{"photo", &tgmodels.Message{Photo: []tgmodels.PhotoSize{{FileID: "small"}, {FileID: "big"}}}, models.TicketMediaPhoto, "big", true},
+		{"document", &tgmodels.Message{Document: &tgmodels.Document{FileID: "doc"}}, models.TicketMediaDocument, "doc", true},
+		{"sticker", &tgmodels.Message{Sticker: &tgmodels.Sticker{}}, models.TicketMediaText, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mediaType, _, fileID, ok := TicketContent(tt.msg)
+			if mediaType != tt.mediaType || fileID != tt.fileID || ok != tt.ok {
+				t.Errorf("TicketContent() = %q, %q, %v", mediaType, fileID, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"unicode/utf16"
+
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// Telegram limits in UTF-16 code units.
+const (
+	CaptionLimit = 1024
+	MessageLimit = 4096
+)
+
+type TextPart struct {
+	Text     string
+	Entities []tgmodels.MessageEntity
+}
+
+// SplitText splits text into parts of at most firstLimit UTF-16 units for the
+// first part and limit for the rest. Cuts are made at a paragraph, line or
+// word boundary when possible; whitespace at a cut is dropped. Entities are
+// clipped to the parts they cover.
+func SplitText(text string, entities []tgmodels.MessageEntity, firstLimit, limit int) []TextPart {
+	units := utf16.Encode([]rune(text))
+
+	var parts []TextPart
+	start := 0
+	for {
+		partLimit := limit
+		if len(parts) == 0 {
+			partLimit = firstLimit
+		}
+		if len(units)-start <= partLimit {
+			parts = append(parts, textPart(units, entities, start, len(units)))
+			return parts
+		}
+
+		end := cutPosition(units, start, start+partLimit)
+		parts = append(parts, textPart(units, entities, start, end))
+
+		start = end
+		for start < len(units) && isSpace(units[start]) {
+			start++
+		}
+		if start == len(units) {
+			return parts
+		}
+	}
+}
+
+// cutPosition finds where to end a part that must not extend past limit.
+func cutPosition(units []uint16, start, limit int) int {
+	for _, sep := range [][]uint16{{'\n', '\n'}, {'\n'}, {' '}} {
+		for i := limit - len(sep); i > start+(limit-start)/2; i-- {
+			if matchAt(units, i, sep) {
+				return i
+			}
+		}
+	}
+	// Do not cut a surrogate pair in half.
+	if utf16.IsSurrogate(rune(units[limit-1])) && units[limit-1] < 0xDC00 {
+		return limit - 1
+	}
+	return limit
+}
+
+func matchAt(units []uint16, i int, sep []uint16) bool {
+	for j, u := range sep {
+		if units[i+j] != u {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(u uint16) bool {
+	return u == ' ' || u == '\n' || u == '\t'
+}
+
+func textPart(units []uint16, entities []tgmodels.MessageEntity, start, end int) TextPart {
+	for end > start && isSpace(units[end-1]) {
+		end--
+	}
+	part := TextPart{Text: string(utf16.Decode(units[start:end]))}
+	for _, e := range entities {
+		from, to := e.Offset, e.Offset+e.Length
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+		if from >= to {
+			continue
+		}
+		clipped := e
+		clipped.Offset = from - start
+		clipped.Length = to - from
+		part.Entities = append(part.Entities, clipped)
+	}
+	return part
+}
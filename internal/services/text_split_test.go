@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	tgmodels "github.com/go-telegram/bot/models"
+	"pgregory.net/rapid"
+)
+
+func TestSplitTextBoundaries(t *testing.T) {
+	text := strings.Repeat("a", 8) + "\n\n" + strings.Repeat("b", 8) + " " + strings.Repeat("c", 4)
+	entities := []tgmodels.MessageEntity{{Type: tgmodels.MessageEntityTypeBold, Offset: 6, Length: 8}}
+
+	parts := SplitText(text, entities, 12, 10)
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d: %+v", len(parts), parts)
+	}
+	if parts[0].Text != strings.Repeat("a", 8) || parts[1].Text != strings.Repeat("b", 8) || parts[2].Text != "cccc" {
+		t.Errorf("Unexpected parts: %q %q %q", parts[0].Text, parts[1].Text, parts[2].Text)
+	}
+	if len(parts[0].Entities) != 1 || parts[0].Entities[0].Offset != 6 || parts[0].Entities[0].Length != 2 {
+		t.Errorf("Unexpected first part entities: %+v", parts[0].Entities)
+	}
+	if len(parts[1].Entities) != 1 || parts[1].Entities[0].Offset != 0 || parts[1].Entities[0].Length != 4 {
+		t.Errorf("Unexpected second part entities: %+v", parts[1].Entities)
+	}
+	if len(parts[2].Entities) != 0 {
+		t.Errorf("Expected no entities in the last part, got %+v", parts[2].Entities)
+	}
+}
+
+func TestSplitTextKeepsSurrogatePairs(t *testing.T) {
+	parts := SplitText(strings.Repeat("😀", 5), nil, 3, 4)
+	for _, p := range parts {
+		if strings.ContainsRune(p.Text, '�') {
+			t.Fatalf("Surrogate pair was cut: %q", p.Text)
+		}
+	}
+	if strings.Join([]string{parts[0].Text, parts[1].Text, parts[2].Text}, "") != strings.Repeat("😀", 5) {
+		t.Errorf("Unexpected parts: %+v", parts)
+	}
+}
+
+func TestSplitTextLimits(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		text := rapid.StringMatching(`[a-zа-я😀 \n]{0,300}`).Draw(rt, "text")
+		firstLimit := rapid.IntRange(2, 50).Draw(rt, "firstLimit")
+		limit := rapid.IntRange(2, 80).Draw(rt, "limit")
+
+		parts := SplitText(text, nil, firstLimit, limit)
+		var joined string
+		for i, p := range parts {
+			max := limit
+			if i == 0 {
+				max = firstLimit
+			}
+			if UTF16Length(p.Text) > max {
+				rt.Fatalf("Part %d is %d units long, limit %d", i, UTF16Length(p.Text), max)
+			}
+			joined += p.Text
+		}
+		strip := func(s string) string { return strings.NewReplacer(" ", "", "\n", "").Replace(s) }
+		if strip(joined) != strip(text) {
+			rt.Fatalf("Text changed: %q != %q", joined, text)
+		}
+	})
+}
@@ -0,0 +1,527 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// TypeBundleVersion is the format version written to the bundle manifest.
+const TypeBundleVersion = 1
+
+const (
+	typeBundleManifest = "manifest.json"
+	// MaxTypeBundleSize bounds the bundle and every file in it; the Bot API
+	// does not let bots download larger files anyway.
+	MaxTypeBundleSize = 20 << 20
+	// MaxTypeBundleUnpacked bounds the decompressed size of the files read
+	// from a bundle together.
+	MaxTypeBundleUnpacked = 100 << 20
+)
+
+// Strategies for imported types whose name is already taken.
+const (
+	ImportRename  = "rename"
+	ImportReplace = "replace"
+	ImportSkip    = "skip"
+)
+
+var ErrInvalidTypeBundle = errors.New("invalid type bundle")
+
+// TypeBundleEntry is a post type in a bundle. Bot-specific values are left
+// out: the image travels as a file instead of a file ID, and access lists
+// refer to admins of the exporting bot.
+type TypeBundleEntry struct {
+	Name             string                 `json:"name"`
+	Emoji            string                 `json:"emoji,omitempty"`
+	Template         string                 `json:"template"`
+	TemplateEntities json.RawMessage        `json:"template_entities,omitempty"`
+	IsActive         bool                   `json:"is_active"`
+	DeliveryOptions  models.DeliveryOptions `json:"delivery_options"`
+	PhotoURL         string                 `json:"photo_url,omitempty"`
+	InputMode        string                 `json:"input_mode,omitempty"`
+	Category         string                 `json:"category,omitempty"`
+	ImageStrategy    string                 `json:"image_strategy,omitempty"`
+	Header           string                 `json:"header,omitempty"`
+	HeaderEntities   json.RawMessage        `json:"header_entities,omitempty"`
+	Footer           string                 `json:"footer,omitempty"`
+	FooterEntities   json.RawMessage        `json:"footer_entities,omitempty"`
+	// CounterFormat is exported without the counter: numbering starts from 1
+	// in the importing bot.
+	CounterFormat string `json:"counter_format,omitempty"`
+	// Image is the path of the type's image inside the archive and Pool the
+	// paths of its additional images.
+	Image string   `json:"image,omitempty"`
+	Pool  []string `json:"pool,omitempty"`
+}
+
+// TypeBundle is a set of post types that can be moved between bots as a ZIP
+// archive of manifest.json and the images.
+type TypeBundle struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Types      []TypeBundleEntry `json:"types"`
+
+	// Images maps image paths to their contents.
+	Images map[string][]byte `json:"-"`
+}
+
+func newTypeBundleEntry(pt *models.PostType) TypeBundleEntry {
+	entry := TypeBundleEntry{
+		Name:            pt.Name,
+		Emoji:           pt.Emoji,
+		Template:        pt.Template,
+		IsActive:        pt.IsActive,
+		DeliveryOptions: models.ParseDeliveryOptions(pt.DeliveryOptions),
+		PhotoURL:        pt.PhotoURL,
+		InputMode:       pt.InputMode,
+		Category:        pt.Category,
+		ImageStrategy:   pt.ImageStrategy,
+		Header:          pt.Header,
+		Footer:          pt.Footer,
+		CounterFormat:   pt.CounterFormat,
+	}
+	entry.TemplateEntities = rawEntities(pt.TemplateEntities)
+	entry.HeaderEntities = rawEntities(pt.HeaderEntities)
+	entry.FooterEntities = rawEntities(pt.FooterEntities)
+	return entry
+}
+
+func rawEntities(s string) json.RawMessage {
+	if s == "" || !json.Valid([]byte(s)) {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+func compactEntities(raw json.RawMessage) string {
+	var entities bytes.Buffer
+	if len(raw) == 0 || json.Compact(&entities, raw) != nil {
+		return ""
+	}
+	return entities.String()
+}
+
+// apply copies the entry's settings to pt, leaving its ID, image, order and
+// access list alone.
+func (e *TypeBundleEntry) apply(pt *models.PostType) {
+	pt.Name = e.Name
+	pt.Emoji = e.Emoji
+	pt.Template = e.Template
+	pt.TemplateEntities = compactEntities(e.TemplateEntities)
+	pt.Header = e.Header
+	pt.HeaderEntities = compactEntities(e.HeaderEntities)
+	pt.Footer = e.Footer
+	pt.FooterEntities = compactEntities(e.FooterEntities)
+	pt.IsActive = e.IsActive
+	pt.DeliveryOptions = e.DeliveryOptions.String()
+	pt.PhotoURL = e.PhotoURL
+	pt.InputMode = e.InputMode
+	pt.Category = e.Category
+	pt.ImageStrategy = e.ImageStrategy
+	pt.CounterFormat = e.CounterFormat
+}
+
+// images returns the archive paths of all the entry's images.
+func (e *TypeBundleEntry) images() []string {
+	if e.Image == "" {
+		return e.Pool
+	}
+	return append([]string{e.Image}, e.Pool...)
+}
+
+// WriteZip writes the bundle as a ZIP archive.
+func (b *TypeBundle) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	manifest, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := zw.Create(typeBundleManifest)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(manifest); err != nil {
+		return err
+	}
+	for _, entry := range b.Types {
+		for _, name := range entry.images() {
+			f, err := zw.Create(name)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(b.Images[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+// readBundleFile decompresses f, failing if it is larger than limit.
+func readBundleFile(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidTypeBundle, f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTypeBundle, err)
+	}
+	defer rc.Close()
+	// The header size is not trusted: the limit is enforced while reading.
+	content, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTypeBundle, err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidTypeBundle, f.Name)
+	}
+	return content, nil
+}
+
+// ReadTypeBundle parses and validates a ZIP archive written by WriteZip. Only
+// the manifest and the images it names are decompressed, and together they
+// may not exceed MaxTypeBundleUnpacked.
+func ReadTypeBundle(data []byte) (*TypeBundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTypeBundle, err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			files[path.Clean(f.Name)] = f
+		}
+	}
+
+	remaining := int64(MaxTypeBundleUnpacked)
+	read := func(f *zip.File) ([]byte, error) {
+		content, err := readBundleFile(f, min(MaxTypeBundleSize, remaining))
+		if err != nil {
+			if remaining < MaxTypeBundleSize {
+				return nil, fmt.Errorf("%w: unpacked files are too large", ErrInvalidTypeBundle)
+			}
+			return nil, err
+		}
+		remaining -= int64(len(content))
+		return content, nil
+	}
+
+	manifestFile, ok := files[typeBundleManifest]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s not found", ErrInvalidTypeBundle, typeBundleManifest)
+	}
+	manifest, err := read(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var bundle TypeBundle
+	if err := json.Unmarshal(manifest, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTypeBundle, err)
+	}
+	if bundle.Version != TypeBundleVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidTypeBundle, bundle.Version)
+	}
+	if len(bundle.Types) == 0 {
+		return nil, fmt.Errorf("%w: no types", ErrInvalidTypeBundle)
+	}
+
+	bundle.Images = make(map[string][]byte)
+	for i := range bundle.Types {
+		entry := &bundle.Types[i]
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.Name == "" || entry.Template == "" {
+			return nil, fmt.Errorf("%w: type %d has no name or template", ErrInvalidTypeBundle, i+1)
+		}
+		switch entry.InputMode {
+		case models.InputModeEntities, models.InputModeHTML, models.InputModeMarkdown:
+		default:
+			return nil, fmt.Errorf("%w: type %q has unknown input mode %q", ErrInvalidTypeBundle, entry.Name, entry.InputMode)
+		}
+		switch entry.ImageStrategy {
+		case models.ImageStrategyFixed, models.ImageStrategyRandom, models.ImageStrategyRoundRobin, models.ImageStrategyWeekday:
+		default:
+			return nil, fmt.Errorf("%w: type %q has unknown image strategy %q", ErrInvalidTypeBundle, entry.Name, entry.ImageStrategy)
+		}
+		if entry.CounterFormat != "" && !strings.Contains(entry.CounterFormat, models.CounterPlaceholder) {
+			return nil, fmt.Errorf("%w: type %q has counter format %q without %s", ErrInvalidTypeBundle, entry.Name, entry.CounterFormat, models.CounterPlaceholder)
+		}
+		if entry.Image != "" {
+			entry.Image = path.Clean(entry.Image)
+		}
+		for j := range entry.Pool {
+			entry.Pool[j] = path.Clean(entry.Pool[j])
+		}
+		for _, name := range entry.images() {
+			if _, ok := bundle.Images[name]; ok {
+				continue
+			}
+			f, ok := files[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: image %s of type %q not found", ErrInvalidTypeBundle, name, entry.Name)
+			}
+			image, err := read(f)
+			if err != nil {
+				return nil, err
+			}
+			bundle.Images[name] = image
+		}
+	}
+	return &bundle, nil
+}
+
+// UniqueTypeName returns name, or name with the first free " (N)" suffix when
+// it is taken. Names are compared case-insensitively.
+func UniqueTypeName(name string, taken map[string]bool) string {
+	if !taken[strings.ToLower(name)] {
+		return name
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !taken[strings.ToLower(candidate)] {
+			return candidate
+		}
+	}
+}
+
+// TypeImportResult counts what an import did.
+type TypeImportResult struct {
+	Created  int
+	Replaced int
+	Skipped  int
+	// Renamed lists the new names of types imported under another name.
+	Renamed []string
+}
+
+// TypeBundleManager exports post types with their images and imports them
+// into this bot, uploading the images again to get file IDs of its own.
+type TypeBundleManager struct {
+	bot    *bot.Bot
+	repo   *db.PostTypeRepository
+	client *http.Client
+}
+
+func NewTypeBundleManager(b *bot.Bot, repo *db.PostTypeRepository) *TypeBundleManager {
+	return &TypeBundleManager{
+		bot:    b,
+		repo:   repo,
+		client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// DownloadFile downloads a file the bot has received.
+func (m *TypeBundleManager) DownloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	file, err := m.bot.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.bot.FileDownloadLink(file), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		// The URL contains the bot token, so it is kept out of the error.
+		return nil, errors.New("failed to download file")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxTypeBundleSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	if len(data) > MaxTypeBundleSize {
+		return nil, errors.New("file is too large")
+	}
+	return data, nil
+}
+
+// Export builds a bundle of the given types, downloading their images.
+func (m *TypeBundleManager) Export(ctx context.Context, typeIDs []int64) (*TypeBundle, error) {
+	bundle := &TypeBundle{
+		Version:    TypeBundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Images:     make(map[string][]byte),
+	}
+	for _, id := range typeIDs {
+		pt, err := m.repo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get post type %d: %w", id, err)
+		}
+		entry := newTypeBundleEntry(pt)
+		if pt.PhotoID != "" {
+			image, err := m.DownloadFile(ctx, pt.PhotoID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to download image of %q: %w", pt.Name, err)
+			}
+			entry.Image = fmt.Sprintf("images/%d.jpg", len(bundle.Types)+1)
+			bundle.Images[entry.Image] = image
+		}
+		pool, err := m.repo.GetImages(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get images of %q: %w", pt.Name, err)
+		}
+		for i, poolImage := range pool {
+			image, err := m.DownloadFile(ctx, poolImage.PhotoID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to download image of %q: %w", pt.Name, err)
+			}
+			name := fmt.Sprintf("images/%d-%d.jpg", len(bundle.Types)+1, i+2)
+			entry.Pool = append(entry.Pool, name)
+			bundle.Images[name] = image
+		}
+		bundle.Types = append(bundle.Types, entry)
+	}
+	return bundle, nil
+}
+
+func (m *TypeBundleManager) existingTypes() (map[string]*models.PostType, error) {
+	types, err := m.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.PostType, len(types))
+	for _, pt := range types {
+		byName[strings.ToLower(pt.Name)] = pt
+	}
+	return byName, nil
+}
+
+// Conflicts returns the names of bundle types that already exist.
+func (m *TypeBundleManager) Conflicts(bundle *TypeBundle) ([]string, error) {
+	existing, err := m.existingTypes()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range bundle.Types {
+		if existing[strings.ToLower(entry.Name)] != nil {
+			names = append(names, entry.Name)
+		}
+	}
+	return names, nil
+}
+
+// uploadImage sends the image to chatID to obtain a file ID and deletes the
+// message right away.
+func (m *TypeBundleManager) uploadImage(ctx context.Context, chatID int64, name string, image []byte) (string, error) {
+	msg, err := m.bot.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:              chatID,
+		Photo:               &tgmodels.InputFileUpload{Filename: path.Base(name), Data: bytes.NewReader(image)},
+		DisableNotification: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+	m.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: msg.ID})
+	if len(msg.Photo) == 0 {
+		return "", errors.New("failed to upload image: no photo in reply")
+	}
+	return msg.Photo[len(msg.Photo)-1].FileID, nil
+}
+
+// Import creates the bundle's types. Images are uploaded to chatID, usually
+// the chat of the admin who imports. Names that are taken are resolved by
+// strategy; a replaced type whose look changes gets a new version by
+// changedBy. All images are uploaded before anything is written, and the
+// types are saved in one transaction, so a failed import changes nothing.
+func (m *TypeBundleManager) Import(ctx context.Context, bundle *TypeBundle, chatID, changedBy int64, strategy string) (*TypeImportResult, error) {
+	existing, err := m.existingTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post types: %w", err)
+	}
+	taken := make(map[string]bool, len(existing))
+	for name := range existing {
+		taken[name] = true
+	}
+
+	result := &TypeImportResult{}
+	var imported []*db.ImportedType
+	for _, entry := range bundle.Types {
+		current := existing[strings.ToLower(entry.Name)]
+		if current != nil && strategy == ImportSkip {
+			result.Skipped++
+			continue
+		}
+
+		photoID := ""
+		if entry.Image != "" {
+			if photoID, err = m.uploadImage(ctx, chatID, entry.Image, bundle.Images[entry.Image]); err != nil {
+				return nil, fmt.Errorf("type %q: %w", entry.Name, err)
+			}
+		}
+		var pool []string
+		for _, name := range entry.Pool {
+			poolID, err := m.uploadImage(ctx, chatID, name, bundle.Images[name])
+			if err != nil {
+				return nil, fmt.Errorf("type %q: %w", entry.Name, err)
+			}
+			pool = append(pool, poolID)
+		}
+
+		if current != nil && strategy == ImportReplace {
+			item := &db.ImportedType{PostType: current, Pool: pool}
+			prevVersion := models.NewPostTypeVersion(current)
+			entry.apply(current)
+			current.PhotoID = photoID
+			if nextVersion := models.NewPostTypeVersion(current); len(nextVersion.Changes(prevVersion)) > 0 {
+				nextVersion.ChangedBy = changedBy
+				item.PrevVersion, item.Version = prevVersion, nextVersion
+			}
+			imported = append(imported, item)
+			result.Replaced++
+			continue
+		}
+
+		pt := &models.PostType{}
+		entry.apply(pt)
+		pt.PhotoID = photoID
+		pt.Name = UniqueTypeName(entry.Name, taken)
+		imported = append(imported, &db.ImportedType{PostType: pt, Pool: pool})
+		taken[strings.ToLower(pt.Name)] = true
+		if pt.Name != entry.Name {
+			result.Renamed = append(result.Renamed, pt.Name)
+		}
+		result.Created++
+	}
+
+	if err := m.repo.SaveImport(imported); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// replacePool makes photoIDs the type's additional images.
+func (m *TypeBundleManager) replacePool(typeID int64, photoIDs []string) error {
+	old, err := m.repo.GetImages(typeID)
+	if err != nil {
+		return err
+	}
+	for _, image := range old {
+		if err := m.repo.DeleteImage(image.ID); err != nil {
+			return err
+		}
+	}
+	for _, photoID := range photoIDs {
+		if _, err := m.repo.AddImage(typeID, photoID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
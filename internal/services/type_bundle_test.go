@@ -0,0 +1,192 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+func TestTypeBundleRoundTrip(t *testing.T) {
+	pt := &models.PostType{
+		Name:             "Анонсы",
+		Emoji:            "📢",
+		Template:         "Анонс: ...",
+		TemplateEntities: `[{"type":"bold","offset":0,"length":5}]`,
+		IsActive:         true,
+		DeliveryOptions:  models.DeliveryOptions{Silent: true}.String(),
+		InputMode:        models.InputModeHTML,
+		Category:         "События",
+		ImageStrategy:    models.ImageStrategyWeekday,
+		Header:           "📢 Анонс",
+		HeaderEntities:   `[{"type":"bold","offset":0,"length":8}]`,
+		Footer:           "#анонс",
+	}
+	entry := newTypeBundleEntry(pt)
+	entry.Image = "images/1.jpg"
+	entry.Pool = []string{"images/1-2.jpg"}
+	bundle := &TypeBundle{
+		Version: TypeBundleVersion,
+		Types:   []TypeBundleEntry{entry},
+		Images:  map[string][]byte{"images/1.jpg": []byte("jpeg"), "images/1-2.jpg": []byte("jpeg 2")},
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.WriteZip(&buf); err != nil {
+		t.Fatalf("WriteZip() error = %v", err)
+	}
+	got, err := ReadTypeBundle(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ReadTypeBundle() error = %v", err)
+	}
+	if len(got.Types) != 1 || string(got.Images["images/1.jpg"]) != "jpeg" || string(got.Images["images/1-2.jpg"]) != "jpeg 2" {
+		t.Fatalf("ReadTypeBundle() = %+v", got)
+	}
+
+	imported := &models.PostType{}
+	got.Types[0].apply(imported)
+	if imported.Name != pt.Name || imported.Emoji != pt.Emoji || imported.Template != pt.Template ||
+		imported.TemplateEntities != pt.TemplateEntities || imported.DeliveryOptions != pt.DeliveryOptions ||
+		imported.InputMode != pt.InputMode || imported.Category != pt.Category || imported.ImageStrategy != pt.ImageStrategy || !imported.IsActive ||
+		imported.Header != pt.Header || imported.HeaderEntities != pt.HeaderEntities || imported.Footer != pt.Footer || imported.FooterEntities != "" {
+		t.Errorf("Imported type = %+v, want settings of %+v", imported, pt)
+	}
+}
+
+func TestReadTypeBundleInvalid(t *testing.T) {
+	archive := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range files {
+			f, _ := zw.Create(name)
+			f.Write([]byte(content))
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	for name, data := range map[string][]byte{
+		"not a zip":        []byte("hello"),
+		"no manifest":      archive(map[string]string{"images/1.jpg": "jpeg"}),
+		"bad version":      archive(map[string]string{"manifest.json": `{"version":2,"types":[{"name":"A","template":"t"}]}`}),
+		"no types":         archive(map[string]string{"manifest.json": `{"version":1,"types":[]}`}),
+		"no template":      archive(map[string]string{"manifest.json": `{"version":1,"types":[{"name":"A"}]}`}),
+		"unknown mode":     archive(map[string]string{"manifest.json": `{"version":1,"types":[{"name":"A","template":"t","input_mode":"bbcode"}]}`}),
+		"image is missing": archive(map[string]string{"manifest.json": `{"version":1,"types":[{"name":"A","template":"t","image":"images/1.jpg"}]}`}),
+		"pool is missing":  archive(map[string]string{"manifest.json": `{"version":1,"types":[{"name":"A","template":"t","pool":["images/1-2.jpg"]}]}`}),
+		"unknown strategy": archive(map[string]string{"manifest.json": `{"version":1,"types":[{"name":"A","template":"t","image_strategy":"hourly"}]}`}),
+	} {
+		if _, err := ReadTypeBundle(data); !errors.Is(err, ErrInvalidTypeBundle) {
+			t.Errorf("%s: ReadTypeBundle() error = %v, want ErrInvalidTypeBundle", name, err)
+		}
+	}
+}
+
+func TestReadTypeBundleUnpackedLimit(t *testing.T) {
+	archive := func(images map[string]int, pool []string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		f, _ := zw.Create(typeBundleManifest)
+		manifest, _ := json.Marshal(TypeBundle{
+			Version: TypeBundleVersion,
+			Types:   []TypeBundleEntry{{Name: "A", Template: "t", Pool: pool}},
+		})
+		f.Write(manifest)
+		for name, size := range images {
+			f, _ := zw.Create(name)
+			f.Write(make([]byte, size))
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	// Files the manifest does not name are not unpacked.
+	data := archive(map[string]int{"images/1.jpg": 1, "junk.bin": MaxTypeBundleSize + 1}, []string{"images/1.jpg"})
+	if _, err := ReadTypeBundle(data); err != nil {
+		t.Errorf("ReadTypeBundle() with an unnamed large file error = %v", err)
+	}
+
+	images := make(map[string]int)
+	var pool []string
+	for i := range MaxTypeBundleUnpacked/MaxTypeBundleSize + 1 {
+		name := fmt.Sprintf("images/1-%d.jpg", i+2)
+		images[name] = MaxTypeBundleSize
+		pool = append(pool, name)
+	}
+	if _, err := ReadTypeBundle(archive(images, pool)); !errors.Is(err, ErrInvalidTypeBundle) {
+		t.Errorf("ReadTypeBundle() over the unpacked limit error = %v, want ErrInvalidTypeBundle", err)
+	}
+}
+
+func TestUniqueTypeName(t *testing.T) {
+	taken := map[string]bool{"новости": true, "новости (2)": true}
+	if got := UniqueTypeName("Анонсы", taken); got != "Анонсы" {
+		t.Errorf("UniqueTypeName() = %q, want the name unchanged", got)
+	}
+	if got := UniqueTypeName("Новости", taken); got != "Новости (3)" {
+		t.Errorf("UniqueTypeName() = %q, want %q", got, "Новости (3)")
+	}
+}
+
+func TestTypeBundleImportConflicts(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if err := db.InitSchema(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	queue := db.NewDBQueueForTest(sqlDB)
+	repo := db.NewPostTypeRepository(queue)
+	versionRepo := db.NewPostTypeVersionRepository(queue)
+	existing := &models.PostType{Name: "Новости", Template: "старый", IsActive: true, AllowedAdmins: "1"}
+	if err := repo.Create(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTypeBundleManager(nil, repo)
+	bundle := &TypeBundle{Version: TypeBundleVersion, Types: []TypeBundleEntry{
+		{Name: "Новости", Template: "новый", IsActive: true},
+		{Name: "Анонсы", Template: "анонс", IsActive: true},
+	}}
+	ctx := context.Background()
+
+	if conflicts, err := m.Conflicts(bundle); err != nil || len(conflicts) != 1 || conflicts[0] != "Новости" {
+		t.Fatalf("Conflicts() = %v, %v", conflicts, err)
+	}
+
+	result, err := m.Import(ctx, bundle, 0, 1, ImportSkip)
+	if err != nil || result.Created != 1 || result.Skipped != 1 {
+		t.Fatalf("Import(skip) = %+v, %v", result, err)
+	}
+
+	result, err = m.Import(ctx, bundle, 0, 1, ImportReplace)
+	if err != nil || result.Replaced != 2 {
+		t.Fatalf("Import(replace) = %+v, %v", result, err)
+	}
+	replaced, err := repo.GetByID(existing.ID)
+	if err != nil || replaced.Template != "новый" || replaced.AllowedAdmins != "1" {
+		t.Errorf("Expected the template replaced and access kept, got %+v, %v", replaced, err)
+	}
+	if version, err := versionRepo.Current(existing.ID); err != nil || version != 2 {
+		t.Errorf("Current() after replacing the template = %d, %v, want version 2", version, err)
+	}
+	versions, err := versionRepo.GetByType(existing.ID)
+	if err != nil || len(versions) != 2 || versions[0].Template != "новый" || versions[0].ChangedBy != 1 || versions[1].Template != "старый" {
+		t.Errorf("Versions = %+v, %v, want the old template kept as version 1", versions, err)
+	}
+
+	result, err = m.Import(ctx, bundle, 0, 1, ImportRename)
+	if err != nil || result.Created != 2 || len(result.Renamed) != 2 || result.Renamed[0] != "Новости (2)" {
+		t.Fatalf("Import(rename) = %+v, %v", result, err)
+	}
+}
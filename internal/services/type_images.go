@@ -0,0 +1,55 @@
+package services
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+// TypeImages lists the images a post of the type can get: the type's own
+// image first, then its pool.
+func TypeImages(pt *models.PostType, pool []*models.PostTypeImage) []string {
+	var images []string
+	if pt.PhotoID != "" {
+		images = append(images, pt.PhotoID)
+	}
+	for _, image := range pool {
+		images = append(images, image.PhotoID)
+	}
+	return images
+}
+
+// PickTypeImage chooses the image of a new post by the type's strategy. By
+// day of week, Monday gets the first image; with fewer than seven images the
+// list wraps around.
+func PickTypeImage(pt *models.PostType, images []string, now time.Time) string {
+	if len(images) == 0 {
+		return ""
+	}
+	switch pt.ImageStrategy {
+	case models.ImageStrategyRandom:
+		return images[rand.IntN(len(images))]
+	case models.ImageStrategyRoundRobin:
+		return images[max(pt.ImageCursor, 0)%len(images)]
+	case models.ImageStrategyWeekday:
+		return images[(int(now.Weekday())+6)%7%len(images)]
+	default:
+		return images[0]
+	}
+}
+
+// OtherTypeImage returns a random image different from current, or current
+// when there is no other.
+func OtherTypeImage(images []string, current string) string {
+	var others []string
+	for _, image := range images {
+		if image != current {
+			others = append(others, image)
+		}
+	}
+	if len(others) == 0 {
+		return current
+	}
+	return others[rand.IntN(len(others))]
+}
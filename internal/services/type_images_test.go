@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestPickTypeImage(t *testing.T) {
+	pt := &models.PostType{PhotoID: "main"}
+	images := TypeImages(pt, []*models.PostTypeImage{{PhotoID: "a"}, {PhotoID: "b"}})
+	if len(images) != 3 || images[0] != "main" {
+		t.Fatalf("TypeImages() = %v", images)
+	}
+
+	monday := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	if got := PickTypeImage(pt, images, monday); got != "main" {
+		t.Errorf("fixed: got %q, want %q", got, "main")
+	}
+
+	pt.ImageStrategy = models.ImageStrategyRoundRobin
+	pt.ImageCursor = 4
+	if got := PickTypeImage(pt, images, monday); got != "a" {
+		t.Errorf("round robin: got %q, want %q", got, "a")
+	}
+
+	pt.ImageStrategy = models.ImageStrategyWeekday
+	if got := PickTypeImage(pt, images, monday); got != "main" {
+		t.Errorf("weekday on Monday: got %q, want %q", got, "main")
+	}
+	if got := PickTypeImage(pt, images, monday.AddDate(0, 0, 2)); got != "b" {
+		t.Errorf("weekday on Wednesday: got %q, want %q", got, "b")
+	}
+	if got := PickTypeImage(pt, images, monday.AddDate(0, 0, 6)); got != "main" {
+		t.Errorf("weekday on Sunday: got %q, want %q", got, "main")
+	}
+
+	pt.ImageStrategy = models.ImageStrategyRandom
+	for range 20 {
+		if got := OtherTypeImage(images, "main"); got == "main" {
+			t.Fatal("OtherTypeImage() returned the current image")
+		}
+	}
+	if got := PickTypeImage(pt, nil, monday); got != "" {
+		t.Errorf("no images: got %q", got)
+	}
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+// Grouping of posts by date in UsageStats.
+const (
+	StatsPeriodWeek  = "w"
+	StatsPeriodMonth = "m"
+)
+
+const (
+	statsPeriodsShown = 12
+	statsNameWidth    = 14
+	statsBarWidth     = 10
+)
+
+// TypeUsage is the activity in one post type. Chars is the total length of
+// the posts' own text, without the type's header, footer and signature.
+type TypeUsage struct {
+	PostTypeID int64
+	Name       string
+	Posts      int
+	Chars      int
+	Edits      int
+	Deletions  int
+	ByPeriod   map[time.Time]int
+}
+
+func (u *TypeUsage) AvgLength() int {
+	if u.Posts == 0 {
+		return 0
+	}
+	return u.Chars / u.Posts
+}
+
+// AdminUsage is the activity of one admin. Edits and Deletions count both
+// posts and replies.
+type AdminUsage struct {
+	AdminID   int64
+	Name      string
+	Posts     int
+	Replies   int
+	Edits     int
+	Deletions int
+}
+
+// UsageStats is what admins did from From up to, but not including, To.
+// Days, periods and hours are counted in Location.
+type UsageStats struct {
+	From           time.Time
+	To             time.Time
+	Location       *time.Location
+	Period         string
+	Periods        []time.Time
+	Types          []*TypeUsage
+	Admins         []*AdminUsage
+	Posts          int
+	Replies        int
+	PostEdits      int
+	PostDeletions  int
+	ReplyEdits     int
+	ReplyDeletions int
+	PostHours      [24]int
+	ReplyHours     [24]int
+}
+
+// StatsPeriodStart returns the start of the week (Monday) or month t is in,
+// in loc.
+func StatsPeriodStart(t time.Time, period string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if period == StatsPeriodMonth {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+func nextStatsPeriod(start time.Time, period string) time.Time {
+	if period == StatsPeriodMonth {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+// NewUsageStats aggregates posts, replies and events of the range. All post
+// types are listed, so unused ones show up with zeros; posts of a type that
+// no longer exists are counted under its ID.
+func NewUsageStats(from, to time.Time, loc *time.Location, period string, types []*models.PostType, posts []*models.PublishedPost, replies []*models.Reply, events []*models.PostEvent, adminName func(int64) string) *UsageStats {
+	s := &UsageStats{From: from.In(loc), To: to.In(loc), Location: loc, Period: period}
+	for start := StatsPeriodStart(s.From, period, loc); start.Before(s.To); start = nextStatsPeriod(start, period) {
+		s.Periods = append(s.Periods, start)
+	}
+
+	byType := map[int64]*TypeUsage{}
+	typeUsage := func(id int64) *TypeUsage {
+		if u, ok := byType[id]; ok {
+			return u
+		}
+		u := &TypeUsage{PostTypeID: id, Name: fmt.Sprintf("Тип #%d", id), ByPeriod: map[time.Time]int{}}
+		byType[id] = u
+		s.Types = append(s.Types, u)
+		return u
+	}
+	for _, pt := range types {
+		typeUsage(pt.ID).Name = pt.Name
+	}
+
+	byAdmin := map[int64]*AdminUsage{}
+	adminUsage := func(id int64) *AdminUsage {
+		if u, ok := byAdmin[id]; ok {
+			return u
+		}
+		u := &AdminUsage{AdminID: id, Name: "без автора"}
+		if id != 0 {
+			u.Name = adminName(id)
+		}
+		byAdmin[id] = u
+		s.Admins = append(s.Admins, u)
+		return u
+	}
+
+	for _, post := range posts {
+		s.Posts++
+		s.PostHours[post.CreatedAt.In(loc).Hour()]++
+		u := typeUsage(post.PostTypeID)
+		u.Posts++
+		u.Chars += utf8.RuneCountInString(post.Text)
+		u.ByPeriod[StatsPeriodStart(post.CreatedAt, period, loc)]++
+		adminUsage(post.AuthorID).Posts++
+	}
+	for _, reply := range replies {
+		s.Replies++
+		s.ReplyHours[reply.CreatedAt.In(loc).Hour()]++
+		adminUsage(reply.AuthorID).Replies++
+	}
+	for _, event := range events {
+		switch event.Action {
+		case models.PostEventPostEdit:
+			s.PostEdits++
+			typeUsage(event.PostTypeID).Edits++
+			adminUsage(event.AdminID).Edits++
+		case models.PostEventPostDelete:
+			s.PostDeletions++
+			typeUsage(event.PostTypeID).Deletions++
+			adminUsage(event.AdminID).Deletions++
+		case models.PostEventReplyEdit:
+			s.ReplyEdits++
+			adminUsage(event.AdminID).Edits++
+		case models.PostEventReplyDelete:
+			s.ReplyDeletions++
+			adminUsage(event.AdminID).Deletions++
+		}
+	}
+
+	slices.SortStableFunc(s.Types, func(a, b *TypeUsage) int {
+		return cmp.Or(cmp.Compare(b.Posts, a.Posts), cmp.Compare(b.Edits+b.Deletions, a.Edits+a.Deletions))
+	})
+	slices.SortStableFunc(s.Admins, func(a, b *AdminUsage) int {
+		return cmp.Compare(b.Posts+b.Replies, a.Posts+a.Replies)
+	})
+	return s
+}
+
+func statsName(name string) string {
+	runes := []rune(name)
+	if len(runes) > statsNameWidth {
+		runes = append(runes[:statsNameWidth-1], '…')
+	}
+	return string(runes) + strings.Repeat(" ", statsNameWidth-len(runes))
+}
+
+func (s *UsageStats) periodLabel(start time.Time) string {
+	if s.Period == StatsPeriodMonth {
+		return start.Format("01.2006")
+	}
+	return start.Format("02.01") + "–" + start.AddDate(0, 0, 6).Format("02.01")
+}
+
+// statsText builds a message of plain paragraphs and monospace tables.
+type statsText struct {
+	text     strings.Builder
+	entities []tgmodels.MessageEntity
+}
+
+func (t *statsText) line(format string, args ...any) {
+	fmt.Fprintf(&t.text, format+"\n", args...)
+}
+
+func (t *statsText) table(title string, rows []string) {
+	t.line("\n%s", title)
+	table := strings.Join(rows, "\n")
+	t.entities = append(t.entities, tgmodels.MessageEntity{
+		Type:   tgmodels.MessageEntityTypePre,
+		Offset: UTF16Length(t.text.String()),
+		Length: UTF16Length(table),
+	})
+	t.line("%s", table)
+}
+
+// Text renders the statistics as a message with monospace tables. Only the
+// last periods are shown; the CSV has all of them.
+func (s *UsageStats) Text() (string, []tgmodels.MessageEntity) {
+	var t statsText
+	t.line("📊 Статистика за %s–%s (время %s)", s.From.Format("02.01.2006"), s.To.Add(-time.Second).Format("02.01.2006"), s.Location)
+	t.line("")
+	t.line("Постов: %d, ответов: %d", s.Posts, s.Replies)
+	t.line("Правок постов: %d, удалений: %d", s.PostEdits, s.PostDeletions)
+	t.line("Правок ответов: %d, удалений: %d", s.ReplyEdits, s.ReplyDeletions)
+
+	if len(s.Types) > 0 {
+		rows := []string{statsName("Тип") + " Посты Ср.дл Правки Удал"}
+		for _, u := range s.Types {
+			rows = append(rows, fmt.Sprintf("%s %5d %5d %6d %4d", statsName(u.Name), u.Posts, u.AvgLength(), u.Edits, u.Deletions))
+		}
+		t.table("По типам (Ср.дл — средняя длина текста в символах)", rows)
+	}
+
+	if len(s.Admins) > 0 {
+		rows := []string{statsName("Админ") + " Посты Отв Правки Удал"}
+		for _, u := range s.Admins {
+			rows = append(rows, fmt.Sprintf("%s %5d %3d %6d %4d", statsName(u.Name), u.Posts, u.Replies, u.Edits, u.Deletions))
+		}
+		t.table("По админам", rows)
+	}
+
+	title := "Посты по неделям"
+	if s.Period == StatsPeriodMonth {
+		title = "Посты по месяцам"
+	}
+	periods := s.Periods
+	if len(periods) > statsPeriodsShown {
+		periods = periods[len(periods)-statsPeriodsShown:]
+		title += fmt.Sprintf(" (последние %d, все — в CSV)", statsPeriodsShown)
+	}
+	var rows []string
+	for _, start := range periods {
+		total := 0
+		for _, u := range s.Types {
+			total += u.ByPeriod[start]
+		}
+		rows = append(rows, fmt.Sprintf("%s %5d", statsName(s.periodLabel(start)), total))
+		for _, u := range s.Types {
+			if n := u.ByPeriod[start]; n > 0 {
+				rows = append(rows, fmt.Sprintf("  %s %3d", statsName(u.Name), n))
+			}
+		}
+	}
+	if len(rows) > 0 {
+		t.table(title, rows)
+	}
+
+	busiest := 0
+	for hour := range 24 {
+		busiest = max(busiest, s.PostHours[hour]+s.ReplyHours[hour])
+	}
+	if busiest > 0 {
+		rows := []string{"Час Посты Отв"}
+		for hour := range 24 {
+			total := s.PostHours[hour] + s.ReplyHours[hour]
+			bar := strings.Repeat("█", (total*statsBarWidth+busiest-1)/busiest)
+			rows = append(rows, strings.TrimRight(fmt.Sprintf("%02d  %5d %3d %s", hour, s.PostHours[hour], s.ReplyHours[hour], bar), " "))
+		}
+		t.table(fmt.Sprintf("По времени суток (%s)", s.Location), rows)
+	}
+
+	return strings.TrimRight(t.text.String(), "\n"), t.entities
+}
+
+// CSV returns the statistics in long format: one value per row, with the
+// columns that do not apply to the section left empty.
+func (s *UsageStats) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	// The byte order mark makes spreadsheets read the file as UTF-8.
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"section", "period", "post_type_id", "post_type", "admin_id", "admin", "hour", "value"})
+
+	typeRow := func(section string, u *TypeUsage, value int) {
+		w.Write([]string{section, "", strconv.FormatInt(u.PostTypeID, 10), u.Name, "", "", "", strconv.Itoa(value)})
+	}
+	for _, u := range s.Types {
+		typeRow("type_posts", u, u.Posts)
+		typeRow("type_avg_length", u, u.AvgLength())
+		typeRow("type_edits", u, u.Edits)
+		typeRow("type_deletions", u, u.Deletions)
+	}
+	for _, start := range s.Periods {
+		for _, u := range s.Types {
+			w.Write([]string{"posts_by_period", start.Format("2006-01-02"), strconv.FormatInt(u.PostTypeID, 10), u.Name, "", "", "", strconv.Itoa(u.ByPeriod[start])})
+		}
+	}
+
+	adminRow := func(section string, u *AdminUsage, value int) {
+		w.Write([]string{section, "", "", "", strconv.FormatInt(u.AdminID, 10), u.Name, "", strconv.Itoa(value)})
+	}
+	for _, u := range s.Admins {
+		adminRow("admin_posts", u, u.Posts)
+		adminRow("admin_replies", u, u.Replies)
+		adminRow("admin_edits", u, u.Edits)
+		adminRow("admin_deletions", u, u.Deletions)
+	}
+
+	for hour := range 24 {
+		w.Write([]string{"posts_by_hour", "", "", "", "", "", strconv.Itoa(hour), strconv.Itoa(s.PostHours[hour])})
+		w.Write([]string{"replies_by_hour", "", "", "", "", "", strconv.Itoa(hour), strconv.Itoa(s.ReplyHours[hour])})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/models"
+)
+
+func TestNewUsageStats(t *testing.T) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d, hour int) time.Time { return time.Date(2026, 9, d, hour, 0, 0, 0, time.UTC) }
+
+	types := []*models.PostType{{ID: 1, Name: "Вакансии"}, {ID: 2, Name: "Резюме"}}
+	posts := []*models.PublishedPost{
+		{PostTypeID: 1, AuthorID: 10, Text: "четыре", CreatedAt: day(1, 9)},
+		{PostTypeID: 1, AuthorID: 10, Text: "ab", CreatedAt: day(8, 9)},
+		{PostTypeID: 3, AuthorID: 20, Text: "x", CreatedAt: day(30, 23)},
+	}
+	replies := []*models.Reply{{AuthorID: 20, CreatedAt: day(2, 9)}}
+	events := []*models.PostEvent{
+		{Action: models.PostEventPostEdit, AdminID: 10, PostTypeID: 1},
+		{Action: models.PostEventPostDelete, AdminID: 20, PostTypeID: 2},
+		{Action: models.PostEventReplyDelete, AdminID: 20},
+	}
+	s := NewUsageStats(from, to, time.UTC, StatsPeriodWeek, types, posts, replies, events, func(id int64) string { return "admin" })
+
+	if s.Posts != 3 || s.Replies != 1 || s.PostEdits != 1 || s.PostDeletions != 1 || s.ReplyDeletions != 1 {
+		t.Errorf("Totals = %+v", s)
+	}
+	if len(s.Periods) != 5 || !s.Periods[0].Equal(time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Periods = %v, want 5 weeks from Monday 31.08", s.Periods)
+	}
+	if len(s.Types) != 3 {
+		t.Fatalf("Types = %d, want the unused and the deleted type too", len(s.Types))
+	}
+	if u := s.Types[0]; u.Name != "Вакансии" || u.Posts != 2 || u.AvgLength() != 4 || u.Edits != 1 || u.ByPeriod[s.Periods[1]] != 1 {
+		t.Errorf("Types[0] = %+v", u)
+	}
+	if u := s.Types[1]; u.Name != "Тип #3" || u.Posts != 1 {
+		t.Errorf("Types[1] = %+v", u)
+	}
+	if u := s.Types[2]; u.Name != "Резюме" || u.Posts != 0 || u.Deletions != 1 {
+		t.Errorf("Types[2] = %+v", u)
+	}
+	if len(s.Admins) != 2 || s.Admins[0].AdminID != 10 || s.Admins[1].Replies != 1 || s.Admins[1].Deletions != 2 {
+		t.Errorf("Admins = %+v, %+v", s.Admins[0], s.Admins[1])
+	}
+	if s.PostHours[9] != 2 || s.ReplyHours[9] != 1 || s.PostHours[23] != 1 {
+		t.Errorf("Hours = %v, %v", s.PostHours, s.ReplyHours)
+	}
+
+	text, entities := s.Text()
+	if !strings.Contains(text, "01.09.2026–30.09.2026 (время UTC)") || len(entities) != 4 {
+		t.Errorf("Text() = %q with %d tables", text, len(entities))
+	}
+	for _, e := range entities {
+		if end := e.Offset + e.Length; end > UTF16Length(text) {
+			t.Errorf("Table %+v ends past the text", e)
+		}
+	}
+
+	data, err := s.CSV()
+	if err != nil {
+		t.Fatalf("CSV() error = %v", err)
+	}
+	if csv := string(data); !strings.Contains(csv, "posts_by_period,2026-09-07,1,Вакансии,,,,1") || !strings.Contains(csv, "posts_by_hour,,,,,,23,1") {
+		t.Errorf("CSV() = %s", csv)
+	}
+}
+
+func TestStatsPeriodStart(t *testing.T) {
+	sunday := time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)
+	if got := StatsPeriodStart(sunday, StatsPeriodWeek, time.UTC); !got.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Week start = %v", got)
+	}
+	if got := StatsPeriodStart(sunday, StatsPeriodMonth, time.UTC); !got.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Month start = %v", got)
+	}
+}
+
+func TestUsageStatsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, loc)
+	to := time.Date(2026, 11, 1, 0, 0, 0, 0, loc)
+	// Sunday 22:00 UTC is Monday 01:00 at UTC+3.
+	sunday := time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)
+	posts := []*models.PublishedPost{{PostTypeID: 1, CreatedAt: sunday}}
+	replies := []*models.Reply{{CreatedAt: sunday}}
+	s := NewUsageStats(from, to, loc, StatsPeriodWeek, nil, posts, replies, nil, func(id int64) string { return "admin" })
+
+	if s.PostHours[1] != 1 || s.ReplyHours[1] != 1 {
+		t.Errorf("Hours = %v, %v, want 01:00", s.PostHours, s.ReplyHours)
+	}
+	if week := time.Date(2026, 10, 19, 0, 0, 0, 0, loc); s.Types[0].ByPeriod[StatsPeriodStart(sunday, StatsPeriodWeek, loc)] != 1 || !StatsPeriodStart(sunday, StatsPeriodWeek, loc).Equal(week) {
+		t.Errorf("ByPeriod = %v, want the week of 19.10", s.Types[0].ByPeriod)
+	}
+	if text, _ := s.Text(); !strings.Contains(text, "(время UTC+3)") || !strings.Contains(text, "По времени суток (UTC+3)") {
+		t.Errorf("Text() = %q, want the time zone shown", text)
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	"github.com/ad/go-telegram-admin/internal/models"
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+)
+
+const (
+	profileRefreshTimeout = 30 * time.Second
+	// profileRetryInterval is how long RefreshMissing waits before asking
+	// for a profile again, so users who left or blocked the bot are not
+	// looked up on every render.
+	profileRetryInterval = time.Hour
+	// MessageAuthorRetention is how long authors of forum messages are kept
+	// for placeholders in replies.
+	MessageAuthorRetention = 30 * 24 * time.Hour
+)
+
+type UserManager struct {
+	bot        *bot.Bot
+	repo       *db.UserRepository
+	configRepo *db.AdminConfigRepository
+
+	mu sync.Mutex
+	// refreshedAt is when RefreshMissing last asked for each profile.
+	refreshedAt map[int64]time.Time
+}
+
+func NewUserManager(b *bot.Bot, repo *db.UserRepository, configRepo *db.AdminConfigRepository) *UserManager {
+	return &UserManager{
+		bot:         b,
+		repo:        repo,
+		configRepo:  configRepo,
+		refreshedAt: make(map[int64]time.Time),
+	}
+}
+
+// TrackUpdate stores the sender of a message or callback so that the admin
+// panel can show names instead of bare IDs. Authors of messages in the forum
+// are remembered for placeholders in replies.
+func (um *UserManager) TrackUpdate(update *tgmodels.Update) {
+	if update.Message != nil && update.Message.From != nil {
+		um.Track(update.Message.From)
+		if !update.Message.From.IsBot && um.isForumChat(update.Message.Chat.ID) {
+			if err := um.repo.SaveMessageAuthor(update.Message.Chat.ID, int64(update.Message.ID), update.Message.From.ID); err != nil {
+				log.Printf("[USERS] Failed to store message author: %v", err)
+			}
+		}
+	}
+	if update.CallbackQuery != nil {
+		um.Track(&update.CallbackQuery.From)
+	}
+}
+
+func (um *UserManager) isForumChat(chatID int64) bool {
+	config, err := um.configRepo.Get()
+	return err == nil && config.ForumChatID != 0 && config.ForumChatID == chatID
+}
+
+// PruneMessageAuthors forgets authors of messages older than
+// MessageAuthorRetention.
+func (um *UserManager) PruneMessageAuthors() (int64, error) {
+	return um.repo.DeleteMessageAuthorsBefore(time.Now().Add(-MessageAuthorRetention))
+}
+
+// RunPruning prunes message authors immediately and then on every tick until
+// ctx is cancelled.
+func (um *UserManager) RunPruning(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := um.PruneMessageAuthors(); err != nil {
+			log.Printf("[USERS] Failed to prune message authors: %v", err)
+		} else if n > 0 {
+			log.Printf("[USERS] Pruned %d message authors", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (um *UserManager) Track(from *tgmodels.User) {
+	if from == nil || from.ID == 0 || from.IsBot {
+		return
+	}
+
+	existing, err := um.repo.GetByID(from.ID)
+	if err == nil &&
+		existing.FirstName == from.FirstName &&
+		existing.LastName == from.LastName &&
+		existing.Username == from.Username &&
+		(from.LanguageCode == "" || existing.LanguageCode == from.LanguageCode) {
+		return
+	}
+
+	if err := um.repo.Upsert(&models.User{
+		ID:           from.ID,
+		FirstName:    from.FirstName,
+		LastName:     from.LastName,
+		Username:     from.Username,
+		LanguageCode: from.LanguageCode,
+	}); err != nil {
+		log.Printf("[USERS] Failed to store user %d: %v", from.ID, err)
+	}
+}
+
+// Refresh loads the user's current profile via getChat and stores it.
+func (um *UserManager) Refresh(ctx context.Context, userID int64) (*models.User, error) {
+	if um.bot == nil {
+		return nil, fmt.Errorf("bot is not configured")
+	}
+
+	chat, err := um.bot.GetChat(ctx, &bot.GetChatParams{ChatID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+
+	user := &models.User{
+		ID:        userID,
+		FirstName: chat.FirstName,
+		LastName:  chat.LastName,
+		Username:  chat.Username,
+	}
+	if err := um.repo.Upsert(user); err != nil {
+		return nil, fmt.Errorf("failed to store user: %w", err)
+	}
+	return um.repo.GetByID(userID)
+}
+
+// RefreshAll refreshes every given user and returns how many were updated.
+func (um *UserManager) RefreshAll(ctx context.Context, userIDs []int64) int {
+	refreshed := 0
+	for _, id := range userIDs {
+		if _, err := um.Refresh(ctx, id); err != nil {
+			log.Printf("[USERS] Failed to refresh user %d: %v", id, err)
+			continue
+		}
+		refreshed++
+	}
+	return refreshed
+}
+
+// Get returns the stored profile or nil when the user has never been seen.
+func (um *UserManager) Get(userID int64) *models.User {
+	user, err := um.repo.GetByID(userID)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// GetOrRefresh returns the stored profile and falls back to getChat for
+// users the bot has not seen yet.
+func (um *UserManager) GetOrRefresh(ctx context.Context, userID int64) *models.User {
+	if user := um.Get(userID); user != nil {
+		return user
+	}
+	user, err := um.Refresh(ctx, userID)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// RefreshMissing loads the profiles of users the bot has not seen yet in the
+// background, so screens listing them render right away from stored
+// profiles and show names on the next render. A profile that could not be
+// loaded is asked for again only after profileRetryInterval.
+func (um *UserManager) RefreshMissing(userIDs []int64) {
+	if um.bot == nil {
+		return
+	}
+	missing := um.claimRefresh(userIDs, time.Now())
+	if len(missing) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), profileRefreshTimeout)
+		defer cancel()
+		for _, id := range missing {
+			if _, err := um.Refresh(ctx, id); err != nil {
+				log.Printf("[USERS] Failed to refresh user %d: %v", id, err)
+			}
+		}
+	}()
+}
+
+// claimRefresh returns the users without a stored profile that were not
+// asked for within profileRetryInterval, and records the attempt.
+func (um *UserManager) claimRefresh(userIDs []int64, now time.Time) []int64 {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	var missing []int64
+	for _, id := range userIDs {
+		if last, ok := um.refreshedAt[id]; ok && now.Sub(last) < profileRetryInterval {
+			continue
+		}
+		if um.Get(id) != nil {
+			continue
+		}
+		um.refreshedAt[id] = now
+		missing = append(missing, id)
+	}
+	return missing
+}
+
+// MessageAuthor returns the sender of a group message seen by the bot, or nil
+// when the message was sent before the bot joined or by an anonymous admin.
+func (um *UserManager) MessageAuthor(chatID, messageID int64) *models.User {
+	userID, err := um.repo.GetMessageAuthor(chatID, messageID)
+	if err != nil {
+		return nil
+	}
+	if user := um.Get(userID); user != nil {
+		return user
+	}
+	return &models.User{ID: userID}
+}
+
+func (um *UserManager) DisplayName(userID int64) string {
+	if user := um.Get(userID); user != nil {
+		return user.DisplayName()
+	}
+	return strconv.FormatInt(userID, 10)
+}
+
+// MentionHTML renders the user as an HTML mention link for ParseModeHTML messages.
+func (um *UserManager) MentionHTML(userID int64) string {
+	return FormatLink(um.DisplayName(userID), MentionURL(userID))
+}
+
+// Mention appends the user's name to text and returns the new text together
+// with a text_link entity pointing at the user's profile.
+func (um *UserManager) Mention(text string, userID int64) (string, tgmodels.MessageEntity) {
+	name := um.DisplayName(userID)
+	entity := tgmodels.MessageEntity{
+		Type:   tgmodels.MessageEntityTypeTextLink,
+		Offset: UTF16Length(text),
+		Length: UTF16Length(name),
+		URL:    MentionURL(userID),
+	}
+	return text + name, entity
+}
+
+func MentionURL(userID int64) string {
+	return "tg://user?id=" + strconv.FormatInt(userID, 10)
+}
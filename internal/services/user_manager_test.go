@@ -0,0 +1,112 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad/go-telegram-admin/internal/db"
+	tgmodels "github.com/go-telegram/bot/models"
+	_ "modernc.org/sqlite"
+)
+
+func setupUserManager(t *testing.T) *UserManager {
+	testDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	t.Cleanup(func() { testDB.Close() })
+
+	if err := db.InitSchema(testDB); err != nil {
+		t.Fatalf("Failed to init schema: %v", err)
+	}
+
+	queue := db.NewDBQueueForTest(testDB)
+	return NewUserManager(nil, db.NewUserRepository(queue), db.NewAdminConfigRepository(queue))
+}
+
+func TestUserManagerDisplayName(t *testing.T) {
+	um := setupUserManager(t)
+
+	if got := um.DisplayName(42); got != "42" {
+		t.Errorf("Expected unknown user to be shown by ID, got %q", got)
+	}
+
+	um.Track(&tgmodels.User{ID: 42, Username: "alice"})
+	if got := um.DisplayName(42); got != "@alice" {
+		t.Errorf("Expected username fallback, got %q", got)
+	}
+
+	um.Track(&tgmodels.User{ID: 42, FirstName: "Алиса", LastName: "Смит", Username: "alice"})
+	if got := um.DisplayName(42); got != "Алиса Смит" {
+		t.Errorf("Expected full name, got %q", got)
+	}
+
+	um.Track(&tgmodels.User{ID: 7, FirstName: "Bot", IsBot: true})
+	if um.Get(7) != nil {
+		t.Error("Expected bots not to be tracked")
+	}
+}
+
+func TestUserManagerMentionEntity(t *testing.T) {
+	um := setupUserManager(t)
+	um.Track(&tgmodels.User{ID: 42, FirstName: "😀 Alice"})
+
+	text, entity := um.Mention("📌 Автор: ", 42)
+	if text != "📌 Автор: 😀 Alice" {
+		t.Fatalf("Unexpected text %q", text)
+	}
+	if entity.Type != tgmodels.MessageEntityTypeTextLink || entity.URL != "tg://user?id=42" {
+		t.Errorf("Unexpected entity %+v", entity)
+	}
+	if entity.Offset != UTF16Length("📌 Автор: ") || entity.Length != UTF16Length("😀 Alice") {
+		t.Errorf("Expected UTF-16 offsets, got offset=%d length=%d", entity.Offset, entity.Length)
+	}
+}
+
+func TestUserManagerMentionHTMLEscapesName(t *testing.T) {
+	um := setupUserManager(t)
+	um.Track(&tgmodels.User{ID: 42, FirstName: "<b>Eve</b>"})
+
+	expected := `<a href="tg://user?id=42">&lt;b&gt;Eve&lt;/b&gt;</a>`
+	if got := um.MentionHTML(42); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestUserManagerTrackUpdateStoresForumAuthorsOnly(t *testing.T) {
+	um := setupUserManager(t)
+	if err := um.configRepo.SetForumConfig(-100, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chatID := range []int64{-100, -200} {
+		um.TrackUpdate(&tgmodels.Update{Message: &tgmodels.Message{
+			ID:   5,
+			Chat: tgmodels.Chat{ID: chatID, Type: tgmodels.ChatTypeSupergroup},
+			From: &tgmodels.User{ID: 42, FirstName: "Алиса"},
+		}})
+	}
+	if author := um.MessageAuthor(-100, 5); author == nil || author.ID != 42 {
+		t.Errorf("Expected the forum message author to be stored, got %+v", author)
+	}
+	if author := um.MessageAuthor(-200, 5); author != nil {
+		t.Errorf("Expected messages of other chats not to be stored, got %+v", author)
+	}
+}
+
+func TestUserManagerClaimRefreshBacksOff(t *testing.T) {
+	um := setupUserManager(t)
+	um.Track(&tgmodels.User{ID: 1, FirstName: "Known"})
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	if got := um.claimRefresh([]int64{1, 2}, now); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("claimRefresh() = %v, want only the user without a profile", got)
+	}
+	if got := um.claimRefresh([]int64{1, 2}, now.Add(time.Minute)); len(got) != 0 {
+		t.Errorf("claimRefresh() right after an attempt = %v, want none", got)
+	}
+	if got := um.claimRefresh([]int64{2}, now.Add(profileRetryInterval)); len(got) != 1 {
+		t.Errorf("claimRefresh() after the retry interval = %v, want the user again", got)
+	}
+}